@@ -0,0 +1,176 @@
+package gontracts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// apiKeyPrefix marks api keys issued by the service
+const apiKeyPrefix = "gk_"
+
+var (
+	// ErrAPIKeyName api key name is empty
+	ErrAPIKeyName = errors.New("api key name is empty")
+	// ErrAPIKeyScope api key scope list is empty or contains unknown scope
+	ErrAPIKeyScope = errors.New("api key scopes are not valid")
+	// ErrAPIKeyNotFound api key doesn't exist or is already revoked
+	ErrAPIKeyNotFound = errors.New("api key doesn't exist")
+)
+
+// APIKeyRequest represents api key creation request
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// APIKeyResponse represents created api key.
+// The key is shown only once and can't be restored later
+type APIKeyResponse struct {
+	ID  int
+	Key string `json:"key"`
+}
+
+// GetAPIKeyList returns list of api keys
+func (a *AuthHandler) GetAPIKeyList(w http.ResponseWriter, r *http.Request) {
+	// read data from DB
+	k, err := a.keys.GetList(r.Context())
+	if err != nil {
+		a.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	keyList := make([]model.APIKey, 0, len(k))
+	for _, key := range k {
+		keyList = append(keyList, *key)
+	}
+
+	// fill response json
+	resp, err := json.Marshal(keyList)
+	if err != nil {
+		a.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// CreateAPIKey issues new api key
+func (a *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req APIKeyRequest
+
+	// read and validate request body
+	err := decodeBody(r, "APIKeyRequest", &req)
+	if err != nil {
+		a.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// generate and store the key
+	res, err := IssueAPIKey(r.Context(), a.keys, &req)
+	if err != nil {
+		a.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// fill response json
+	resp, err := json.Marshal(res)
+	if err != nil {
+		a.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resp)
+}
+
+// IssueAPIKey validates request and creates new random api key in the store.
+// It's used by the admin API and to issue the first admin key from command line
+func IssueAPIKey(ctx context.Context, keys model.APIKeyModel, req *APIKeyRequest) (*APIKeyResponse, error) {
+	// validity checks
+	if req.Name == "" {
+		return nil, ErrAPIKeyName
+	}
+	if !validScopes(req.Scopes) {
+		return nil, ErrAPIKeyScope
+	}
+
+	// generate random key
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(raw)
+
+	// only the hash of the key is persisted
+	idx, err := keys.CreateItem(ctx, &model.APIKey{
+		Name:      req.Name,
+		Prefix:    key[:len(apiKeyPrefix)+8],
+		Hash:      hashAPIKey(key),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &APIKeyResponse{idx, key}, nil
+}
+
+// RevokeAPIKey revokes api key
+func (a *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	// get id from request params
+	rvars := mux.Vars(r)
+	id, err := strconv.Atoi(rvars["id"])
+	if err != nil {
+		a.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// revoke key in DB
+	err = a.keys.RevokeItem(r.Context(), id)
+	if err != nil {
+		a.logError(r, err, "apikey", id)
+		writeProblem(w, r, notFound(err, ErrAPIKeyNotFound))
+		return
+	}
+
+	// setup response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(http.StatusText(http.StatusOK)))
+}
+
+// validScopes checks that scope list is not empty and contains known scopes only
+func validScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	id := Identity{Scopes: allScopes}
+	for _, s := range scopes {
+		if !id.HasScope(s) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,326 @@
+package gontracts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func testBearerToken(key []byte) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "test",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	tokenString, _ := token.SignedString(key)
+	return tokenString
+}
+
+func testOKHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(IdentityFromRequest(r).Subject))
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	cases := []struct {
+		Num     string
+		Request string
+		Status  int
+		Keys    model.APIKeyModel
+	}{
+		// normal creation
+		{
+			Num:     "1",
+			Request: `{"name":"batch","scopes":["read","write"]}`,
+			Status:  http.StatusCreated,
+			Keys:    &test.TestAPIKey{},
+		},
+		// creation with expiration date
+		{
+			Num:     "2",
+			Request: `{"name":"batch","scopes":["read"],"expiresAt":"2100-01-01T00:00:00Z"}`,
+			Status:  http.StatusCreated,
+			Keys:    &test.TestAPIKey{},
+		},
+		// empty name
+		{
+			Num:     "3",
+			Request: `{"scopes":["read"]}`,
+			Status:  http.StatusBadRequest,
+			Keys:    &test.TestAPIKey{},
+		},
+		// no scopes
+		{
+			Num:     "4",
+			Request: `{"name":"batch"}`,
+			Status:  http.StatusBadRequest,
+			Keys:    &test.TestAPIKey{},
+		},
+		// unknown scope
+		{
+			Num:     "5",
+			Request: `{"name":"batch","scopes":["root"]}`,
+			Status:  http.StatusBadRequest,
+			Keys:    &test.TestAPIKey{},
+		},
+		// error handling
+		{
+			Num:     "6",
+			Request: `{"name":"batch","scopes":["read"]}`,
+			Status:  http.StatusInternalServerError,
+			Keys:    test.TestAPIKeyErr{},
+		},
+	}
+
+	for _, c := range cases {
+		a := NewAuthHandler([]byte("test"), c.Keys)
+
+		req := httptest.NewRequest("POST", "/admin/apikey", bytes.NewBuffer([]byte(c.Request)))
+		w := httptest.NewRecorder()
+
+		testHandle("/admin/apikey", w, req, a.CreateAPIKey)
+		if w.Code != c.Status {
+			t.Errorf("[CreateAPIKey:%s]:\twrong StatusCode: got %d, expected %d", c.Num, w.Code, c.Status)
+			continue
+		}
+		if c.Status != http.StatusCreated {
+			continue
+		}
+
+		var resp APIKeyResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if !strings.HasPrefix(resp.Key, apiKeyPrefix) {
+			t.Errorf("[CreateAPIKey:%s]:\twrong key format: %s", c.Num, resp.Key)
+		}
+
+		// only the hash must be stored
+		k, err := c.Keys.GetByHash(context.Background(), hashAPIKey(resp.Key))
+		if err != nil {
+			t.Errorf("[CreateAPIKey:%s]:\tkey is not stored: %v", c.Num, err)
+		} else if k.Hash == resp.Key || !strings.HasPrefix(resp.Key, k.Prefix) {
+			t.Errorf("[CreateAPIKey:%s]:\twrong stored key: %+v", c.Num, k)
+		}
+	}
+}
+
+func TestGetAPIKeyList(t *testing.T) {
+	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
+
+	cases := []struct {
+		Num      string
+		Response string
+		Status   int
+		Keys     model.APIKeyModel
+	}{
+		{
+			Num:      "1",
+			Response: `[{"ID":1,"name":"batch","prefix":"gk_01234567","scopes":["read"],"expiresAt":null,"createdAt":"2000-01-01T00:00:00Z","lastUsedAt":null,"revokedAt":null}]`,
+			Status:   http.StatusOK,
+			Keys: &test.TestAPIKey{
+				KL: []*model.APIKey{
+					{ID: 1, Name: "batch", Prefix: "gk_01234567", Hash: "secret", Scopes: []string{"read"}, CreatedAt: time1},
+				},
+			},
+		},
+		{
+			Num:      "2",
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/admin/apikey"),
+			Status:   http.StatusInternalServerError,
+			Keys:     test.TestAPIKeyErr{},
+		},
+	}
+
+	for _, c := range cases {
+		a := NewAuthHandler([]byte("test"), c.Keys)
+
+		req := httptest.NewRequest("GET", "/admin/apikey", nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/admin/apikey", w, req, a.GetAPIKeyList)
+		testCheckResponse("GetAPIKeyList:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	cases := []struct {
+		Num      string
+		ID       int
+		Response string
+		Status   int
+	}{
+		{
+			Num:      "1",
+			ID:       1,
+			Response: http.StatusText(http.StatusOK),
+			Status:   http.StatusOK,
+		},
+		{
+			Num:      "2",
+			ID:       2,
+			Response: testProblem(http.StatusNotFound, "api_key_not_found", ErrAPIKeyNotFound.Error(), "/admin/apikey/2"),
+			Status:   http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		a := NewAuthHandler([]byte("test"), &test.TestAPIKey{
+			KL: []*model.APIKey{
+				{ID: 1, Name: "batch", Scopes: []string{"read"}},
+			},
+		})
+
+		url := fmt.Sprintf("/admin/apikey/%d", c.ID)
+		req := httptest.NewRequest("DELETE", url, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/admin/apikey/{id:[0-9]+}", w, req, a.RevokeAPIKey)
+		testCheckResponse("RevokeAPIKey:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	secret := []byte("test")
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	keys := &test.TestAPIKey{
+		KL: []*model.APIKey{
+			{ID: 1, Prefix: "gk_read", Hash: hashAPIKey("gk_read"), Scopes: []string{ScopeRead}},
+			{ID: 2, Prefix: "gk_write", Hash: hashAPIKey("gk_write"), Scopes: []string{ScopeRead, ScopeWrite}, ExpiresAt: &future},
+			{ID: 3, Prefix: "gk_revoked", Hash: hashAPIKey("gk_revoked"), Scopes: []string{ScopeRead}, RevokedAt: &past},
+			{ID: 4, Prefix: "gk_expired", Hash: hashAPIKey("gk_expired"), Scopes: []string{ScopeRead}, ExpiresAt: &past},
+			{ID: 5, Prefix: "gk_admin", Hash: hashAPIKey("gk_admin"), Scopes: []string{ScopeAdmin}},
+		},
+	}
+
+	cases := []struct {
+		Num      string
+		Method   string
+		Admin    bool
+		APIKey   string
+		Bearer   string
+		Response string
+		Status   int
+	}{
+		// read key reads data
+		{
+			Num:      "1",
+			Method:   "GET",
+			APIKey:   "gk_read",
+			Response: "apikey:gk_read",
+			Status:   http.StatusOK,
+		},
+		// read key can't change data
+		{
+			Num:      "2",
+			Method:   "POST",
+			APIKey:   "gk_read",
+			Response: testProblem(http.StatusForbidden, "scope_not_allowed", ErrScopeNotAllowed.Error(), "/"),
+			Status:   http.StatusForbidden,
+		},
+		// write key changes data
+		{
+			Num:      "3",
+			Method:   "POST",
+			APIKey:   "gk_write",
+			Response: "apikey:gk_write",
+			Status:   http.StatusOK,
+		},
+		// revoked key
+		{
+			Num:      "4",
+			Method:   "GET",
+			APIKey:   "gk_revoked",
+			Response: testProblem(http.StatusUnauthorized, "api_key_revoked", ErrAPIKeyRevoked.Error(), "/"),
+			Status:   http.StatusUnauthorized,
+		},
+		// expired key
+		{
+			Num:      "5",
+			Method:   "GET",
+			APIKey:   "gk_expired",
+			Response: testProblem(http.StatusUnauthorized, "api_key_expired", ErrAPIKeyExpired.Error(), "/"),
+			Status:   http.StatusUnauthorized,
+		},
+		// unknown key
+		{
+			Num:      "6",
+			Method:   "GET",
+			APIKey:   "gk_unknown",
+			Response: testProblem(http.StatusUnauthorized, "api_key_invalid", ErrAPIKeyInvalid.Error(), "/"),
+			Status:   http.StatusUnauthorized,
+		},
+		// admin route with non-admin key
+		{
+			Num:      "7",
+			Method:   "GET",
+			Admin:    true,
+			APIKey:   "gk_write",
+			Response: testProblem(http.StatusForbidden, "scope_not_allowed", ErrScopeNotAllowed.Error(), "/"),
+			Status:   http.StatusForbidden,
+		},
+		// admin route with admin key
+		{
+			Num:      "8",
+			Method:   "GET",
+			Admin:    true,
+			APIKey:   "gk_admin",
+			Response: "apikey:gk_admin",
+			Status:   http.StatusOK,
+		},
+		// bearer token still works
+		{
+			Num:      "9",
+			Method:   "POST",
+			Bearer:   testBearerToken(secret),
+			Response: "test",
+			Status:   http.StatusOK,
+		},
+		// bearer token can't manage api keys
+		{
+			Num:      "10",
+			Method:   "POST",
+			Admin:    true,
+			Bearer:   testBearerToken(secret),
+			Response: testProblem(http.StatusForbidden, "scope_not_allowed", ErrScopeNotAllowed.Error(), "/"),
+			Status:   http.StatusForbidden,
+		},
+	}
+
+	for _, c := range cases {
+		a := NewAuthHandler(secret, keys)
+
+		h := a.HandlerFunc(testOKHandler)
+		if c.Admin {
+			h = a.AdminHandlerFunc(testOKHandler)
+		}
+
+		req := httptest.NewRequest(c.Method, "/", nil)
+		if c.APIKey != "" {
+			req.Header.Set(APIKeyHeader, c.APIKey)
+		}
+		if c.Bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Bearer)
+		}
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, req)
+		testCheckResponse("APIKeyAuth:"+c.Num, t, w, c.Status, c.Response)
+	}
+
+	// successful authentication is tracked
+	if keys.KL[0].LastUsedAt == nil {
+		t.Error("[APIKeyAuth]:\tlast usage is not tracked")
+	}
+	if keys.KL[2].LastUsedAt != nil {
+		t.Error("[APIKeyAuth]:\tlast usage is tracked for revoked key")
+	}
+}
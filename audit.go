@@ -0,0 +1,101 @@
+package gontracts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// auditEntities are entities recorded in audit trail
+var auditEntities = []string{model.EntityCompany, model.EntityContract, model.EntityPurchase}
+
+// auditSortFields are sort fields of audit trail
+var auditSortFields = []string{"ID", "time"}
+
+// requestActor identifies principal and correlation ID of the request changing items
+type requestActor struct{}
+
+// Actor returns subject of authenticated identity and ID of the request
+func (requestActor) Actor(ctx context.Context) model.AuditActor {
+	a := model.AuditActor{RequestID: RequestIDFromContext(ctx)}
+	if id := IdentityFromContext(ctx); id != nil {
+		a.Actor = id.Subject
+	}
+	return a
+}
+
+// SetAudit enables audit trail of all changes of companies, contracts and purchases.
+// Changes are recorded by their storage in the same transaction, audit is the trail read by GetAuditList
+func (h *Handler) SetAudit(audit model.AuditModel) {
+	h.audit = audit
+	h.svc.SetAuditor(requestActor{})
+}
+
+// parseAuditQuery reads audit trail request from URL query
+func parseAuditQuery(v url.Values) (q model.AuditQuery, err error) {
+	if q.Entity = v.Get("entity"); q.Entity != "" && !contains(auditEntities, q.Entity) {
+		return q, fmt.Errorf("entity: must be one of %s", strings.Join(auditEntities, ", "))
+	}
+	if q.EntityID, err = parseInt(v, "entityID"); err != nil {
+		return q, err
+	}
+	q.Actor = v.Get("actor")
+	if q.From, err = parseTime(v, "from"); err != nil {
+		return q, err
+	}
+	if q.To, err = parseTime(v, "to"); err != nil {
+		return q, err
+	}
+	if q.Sort, err = parseSort(v, auditSortFields); err != nil {
+		return q, err
+	}
+	q.Page, err = parsePage(v)
+	return q, err
+}
+
+// GetAuditList returns page of audit trail records
+func (h *Handler) GetAuditList(w http.ResponseWriter, r *http.Request) {
+	// get list params from request query
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// read data from DB, audit trail is empty if it isn't kept
+	var p []*model.AuditRecord
+	var next string
+	if h.audit != nil {
+		p, next, err = h.audit.GetList(r.Context(), q)
+		if err != nil {
+			h.logError(r, err)
+			writeProblem(w, r, err)
+			return
+		}
+	}
+
+	recList := make([]model.AuditRecord, 0, len(p))
+	for _, rec := range p {
+		recList = append(recList, *rec)
+	}
+
+	// fill response json
+	resp, err := json.Marshal(recList)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	setNextPage(w, r, next)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
@@ -0,0 +1,165 @@
+package gontracts
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+// testAuditModels returns client test models recording changes to audit
+func testAuditModels(audit test.AuditTrail) testModelSet {
+	ms := testClientModels()
+	company := ms.company.(test.TestCompany)
+	company.Audit = audit
+	contract := ms.contract.(test.TestContract)
+	contract.Audit = audit
+	purchase := ms.purchase.(test.TestPurchase)
+	purchase.Audit = audit
+	return testModelSet{company, contract, purchase}
+}
+
+func TestAudit(t *testing.T) {
+	audit := &test.TestAudit{}
+	ms := testAuditModels(audit)
+	h := NewHandler(ms.company, ms.contract, ms.purchase)
+	h.SetAudit(audit)
+	keys := &test.TestAPIKey{KL: []*model.APIKey{
+		{ID: 1, Prefix: "gk_admin", Hash: hashAPIKey("gk_admin"), Scopes: []string{ScopeAdmin}},
+	}}
+	srv := RequestID(newRouter(h, NewAuthHandler([]byte("test"), keys), NewHealthHandler(), NewMetrics(nil)))
+	token := testBearerToken([]byte("test"))
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		if method == "GET" {
+			// audit trail is read with admin key
+			req.Header.Set(APIKeyHeader, "gk_admin")
+		} else {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set(RequestIDHeader, "req-"+method)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	// every change is recorded with its actor and request
+	changes := []struct {
+		Method string
+		URL    string
+		Body   string
+		Status int
+	}{
+		{"POST", "/company", `{"name":"Newcom"}`, http.StatusCreated},
+		{"PUT", "/contract", `{"ID":1,"sellerID":1,"clientID":2,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":200}`, http.StatusOK},
+		{"DELETE", "/company/3", ``, http.StatusOK},
+		{"POST", "/purchase", `{"contractID":1,"datetime":"2000-06-01T00:00:00Z","amount":5}`, http.StatusCreated},
+		// rejected change isn't recorded
+		{"POST", "/purchase", `{"contractID":1,"datetime":"2000-06-01T00:00:00Z","amount":500}`, http.StatusConflict},
+	}
+	for i, c := range changes {
+		if w := do(c.Method, c.URL, c.Body); w.Code != c.Status {
+			t.Fatalf("[Audit:%d]:\twrong StatusCode: got %d, expected %d, %s", i+1, w.Code, c.Status, w.Body.String())
+		}
+	}
+
+	records := []struct {
+		Entity   string
+		EntityID int
+		Action   string
+		Request  string
+		Before   string
+		After    string
+	}{
+		{"company", 4, "create", "req-POST", `null`, `{"ID":4,"name":"Newcom","regcode":null}`},
+		{"contract", 1, "update", "req-PUT",
+			`{"ID":1,"sellerID":1,"clientID":2,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":100}`,
+			`{"ID":1,"sellerID":1,"clientID":2,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":200}`},
+		{"company", 3, "delete", "req-DELETE", `{"ID":3,"name":"Hypercom","regcode":"HPC333"}`, `null`},
+		{"purchase", 3, "create", "req-POST", `null`, `{"ID":3,"contractID":1,"datetime":"2000-06-01T00:00:00Z","amount":5}`},
+	}
+	if len(audit.RL) != len(records) {
+		t.Fatalf("[Audit]:\tgot %d records, expected %d", len(audit.RL), len(records))
+	}
+	for i, r := range records {
+		rec := audit.RL[i]
+		before, _ := json.Marshal(rec.Before)
+		after, _ := json.Marshal(rec.After)
+		if rec.Actor != "test" || rec.Entity != r.Entity || rec.EntityID != r.EntityID || rec.Action != r.Action ||
+			rec.RequestID != r.Request || string(before) != r.Before || string(after) != r.After || rec.Time.IsZero() {
+			t.Errorf("[Audit:%d]:\twrong record: got %+v %s %s, expected %+v", i+1, *rec, before, after, r)
+		}
+	}
+
+	// audit trail is filtered by entity and actor
+	w := do("GET", "/audit?entity=company&actor=test", "")
+	var list []struct {
+		EntityID int    `json:"entityID"`
+		Action   string `json:"action"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("[Audit:list]:\tgot %d %s", w.Code, w.Body.String())
+	}
+	if len(list) != 2 || list[0].EntityID != 4 || list[1].Action != "delete" {
+		t.Errorf("[Audit:list]:\twrong response %s", w.Body.String())
+	}
+	w = do("GET", "/audit?actor=nobody", "")
+	testCheckResponse("Audit:empty", t, w, http.StatusOK, `[]`)
+}
+
+func TestGetAuditList(t *testing.T) {
+	cases := []struct {
+		Num      string
+		URL      string
+		Audit    model.AuditModel
+		Response string
+		Status   int
+	}{
+		// audit trail isn't kept
+		{
+			Num:      "1",
+			URL:      "/audit",
+			Response: `[]`,
+			Status:   http.StatusOK,
+		},
+		{
+			Num:      "2",
+			URL:      "/audit?entity=apikey",
+			Audit:    &test.TestAudit{},
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, "entity: must be one of company, contract, purchase", "/audit"),
+			Status:   http.StatusBadRequest,
+		},
+		{
+			Num:      "3",
+			URL:      "/audit?sort=actor",
+			Audit:    &test.TestAudit{},
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, `unknown sort field "actor", expected one of ID, time`, "/audit"),
+			Status:   http.StatusBadRequest,
+		},
+		{
+			Num:      "4",
+			URL:      "/audit",
+			Audit:    test.TestAuditErr{},
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/audit"),
+			Status:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(nil, nil, nil)
+		if c.Audit != nil {
+			h.SetAudit(c.Audit)
+		}
+
+		req := httptest.NewRequest("GET", c.URL, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/audit", w, req, h.GetAuditList)
+		testCheckResponse("GetAuditList:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
@@ -1,31 +1,151 @@
 package gontracts
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware"
 	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/ilyakaznacheev/gontracts/model"
 )
 
+// APIKeyHeader is a request header containing api key
+const APIKeyHeader = "X-API-Key"
+
+// Access scopes
+const (
+	// ScopeRead allows reading data
+	ScopeRead = "read"
+	// ScopeWrite allows changing data
+	ScopeWrite = "write"
+	// ScopeAdmin allows managing api keys
+	ScopeAdmin = "admin"
+)
+
+var allScopes = []string{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// bearerScopes are granted to bearer token holders. Tokens are issued without
+// authentication, so they can't manage api keys
+var bearerScopes = []string{ScopeRead, ScopeWrite}
+
+var (
+	// ErrAPIKeyInvalid api key doesn't exist
+	ErrAPIKeyInvalid = errors.New("api key is invalid")
+	// ErrAPIKeyRevoked api key was revoked
+	ErrAPIKeyRevoked = errors.New("api key is revoked")
+	// ErrAPIKeyExpired api key is expired
+	ErrAPIKeyExpired = errors.New("api key is expired")
+	// ErrScopeNotAllowed request is outside of the granted scopes
+	ErrScopeNotAllowed = errors.New("operation is not allowed in granted scopes")
+	// ErrTokenInvalid bearer token is missing or invalid
+	ErrTokenInvalid = errors.New("bearer token is missing or invalid")
+	// ErrSigningKeyMissing token signing key isn't loaded
+	ErrSigningKeyMissing = errors.New("token signing key is not loaded")
+)
+
+type identityKey struct{}
+
+// Identity is an authenticated request principal
+type Identity struct {
+	Subject string
+	Scopes  []string
+}
+
+// HasScope checks is scope granted to identity
+func (i *Identity) HasScope(scope string) bool {
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IdentityFromRequest returns authenticated principal of the request or nil
+func IdentityFromRequest(r *http.Request) *Identity {
+	return IdentityFromContext(r.Context())
+}
+
+// IdentityFromContext returns authenticated principal of HTTP or gRPC request context or nil
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// WithIdentity returns context of operation made by principal outside of API requests, e.g. from command line
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
 // AuthHandler authentication handler
 type AuthHandler struct {
 	jwtmiddleware.JWTMiddleware
 	secretKey []byte
+	keys      model.APIKeyModel
+	subjects  map[string][]string
+	log       *slog.Logger
+	// idempotency replays repeated requests of the same subject
+	idempotency *Idempotency
 }
 
-// NewAuthHandler creates new authentication handler
-func NewAuthHandler(key []byte) *AuthHandler {
-	return &AuthHandler{
-		JWTMiddleware: *jwtmiddleware.New(jwtmiddleware.Options{
-			ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
-				return key, nil
-			},
-			SigningMethod: jwt.SigningMethodHS256,
-		}),
+// NewAuthHandler creates new authentication handler.
+// API key authentication is disabled if keys is nil
+func NewAuthHandler(key []byte, keys model.APIKeyModel) *AuthHandler {
+	a := &AuthHandler{
 		secretKey: key,
+		keys:      keys,
+		log:       slog.Default(),
+	}
+	a.JWTMiddleware = *jwtmiddleware.New(jwtmiddleware.Options{
+		ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
+			return key, nil
+		},
+		SigningMethod: jwt.SigningMethodHS256,
+		ErrorHandler:  a.tokenError,
+	})
+	return a
+}
+
+// tokenError responds to requests without valid bearer token
+func (a *AuthHandler) tokenError(w http.ResponseWriter, r *http.Request, msg string) {
+	a.logError(r, ErrTokenInvalid, "reason", msg)
+	writeProblem(w, r, ErrTokenInvalid)
+}
+
+// SetLogger sets request logger
+func (a *AuthHandler) SetLogger(logger *slog.Logger) {
+	a.log = logger
+}
+
+// logError logs request failure with request correlation fields
+func (a *AuthHandler) logError(r *http.Request, err error, args ...any) {
+	requestLogger(a.log, r).Error("request failed", append([]any{"error", err}, args...)...)
+}
+
+// SetIdempotency enables replay of POST requests repeated with the same idempotency key.
+// Keys are checked after authentication to scope them by the subject
+func (a *AuthHandler) SetIdempotency(i *Idempotency) {
+	a.idempotency = i
+}
+
+// SetClientSubjects enables client certificate authentication.
+// Subjects maps full subject DN of certificate to granted scopes
+func (a *AuthHandler) SetClientSubjects(subjects map[string][]string) {
+	a.subjects = subjects
+}
+
+// CheckSigningKey checks that token signing key is loaded
+func (a *AuthHandler) CheckSigningKey() error {
+	if len(a.secretKey) == 0 {
+		return ErrSigningKeyMissing
 	}
+	return nil
 }
 
 // GenerateToken returns new authentication token
@@ -50,3 +170,131 @@ func (a *AuthHandler) GenerateToken(w http.ResponseWriter, r *http.Request) {
 func (a *AuthHandler) HandlerFunc(f func(w http.ResponseWriter, r *http.Request)) http.Handler {
 	return a.Handler(http.HandlerFunc(f))
 }
+
+// AdminHandlerFunc wraps handler function into auth handler object that requires admin scope
+func (a *AuthHandler) AdminHandlerFunc(f func(w http.ResponseWriter, r *http.Request)) http.Handler {
+	return a.authenticate(ScopeAdmin, http.HandlerFunc(f))
+}
+
+// ReadHandler wraps handler into auth handler object that requires read scope for any method
+func (a *AuthHandler) ReadHandler(h http.Handler) http.Handler {
+	return a.authenticate(ScopeRead, h)
+}
+
+// Handler wraps handler into auth handler object.
+// The request scope is derived from HTTP method
+func (a *AuthHandler) Handler(h http.Handler) http.Handler {
+	return a.authenticate("", h)
+}
+
+func (a *AuthHandler) authenticate(scope string, h http.Handler) http.Handler {
+	bearer := a.JWTMiddleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := r.Context().Value(a.Options.UserProperty).(*jwt.Token)
+		a.serveIdentity(w, r, h, scope, tokenIdentity(token))
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key != "" && a.keys != nil {
+			id, err := a.checkAPIKey(r.Context(), requestLogger(a.log, r), key)
+			if err != nil {
+				a.logError(r, err)
+				writeProblem(w, r, err)
+				return
+			}
+			a.serveIdentity(w, r, h, scope, id)
+			return
+		}
+
+		// known client certificate replaces bearer token
+		if id := certIdentity(r.TLS, a.subjects); id != nil {
+			a.serveIdentity(w, r, h, scope, id)
+			return
+		}
+
+		bearer.ServeHTTP(w, r)
+	})
+}
+
+// serveIdentity checks identity scopes and passes request further
+func (a *AuthHandler) serveIdentity(w http.ResponseWriter, r *http.Request, h http.Handler, scope string, id *Identity) {
+	setRequestSubject(r, id.Subject)
+	if scope == "" {
+		scope = methodScope(r.Method)
+	}
+	if !id.HasScope(scope) {
+		a.logError(r, ErrScopeNotAllowed, "scope", scope)
+		writeProblem(w, r, ErrScopeNotAllowed)
+		return
+	}
+	if a.idempotency != nil {
+		h = a.idempotency.Middleware(h)
+	}
+	h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+}
+
+// methodScope returns scope required by HTTP method
+func methodScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	default:
+		return ScopeWrite
+	}
+}
+
+// checkToken validates bearer token the same way as the token middleware
+func (a *AuthHandler) checkToken(tokenString string) (*Identity, error) {
+	token, err := jwt.Parse(tokenString, a.Options.ValidationKeyGetter)
+	if err != nil || !token.Valid || token.Header["alg"] != a.Options.SigningMethod.Alg() {
+		return nil, ErrTokenInvalid
+	}
+	return tokenIdentity(token), nil
+}
+
+// tokenIdentity returns identity of bearer token holder.
+// Bearer token holders can read and change data, but not manage api keys
+func tokenIdentity(token *jwt.Token) *Identity {
+	id := &Identity{Scopes: bearerScopes}
+	if token != nil {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			id.Subject, _ = claims["sub"].(string)
+		}
+	}
+	return id
+}
+
+// checkAPIKey validates api key and tracks its usage
+func (a *AuthHandler) checkAPIKey(ctx context.Context, log *slog.Logger, key string) (*Identity, error) {
+	k, err := a.keys.GetByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if k.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if k.ExpiresAt != nil && now.After(*k.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	// usage tracking failure must not block the request
+	if err = a.keys.UpdateLastUsed(ctx, k.ID, now); err != nil {
+		log.Warn("api key usage tracking failed", "error", err, "apikey", k.ID)
+	}
+
+	return &Identity{
+		Subject: "apikey:" + k.Prefix,
+		Scopes:  k.Scopes,
+	}, nil
+}
+
+// hashAPIKey returns hex encoded SHA-256 hash of api key
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
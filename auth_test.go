@@ -20,7 +20,7 @@ func testCheckAuthResponse(loc string, t *testing.T, w *httptest.ResponseRecorde
 	}
 }
 func TestGetToken(t *testing.T) {
-	a := NewAuthHandler([]byte("test"))
+	a := NewAuthHandler([]byte("test"), nil)
 
 	url := "/get-token"
 	req := httptest.NewRequest("GET", url, nil)
@@ -0,0 +1,159 @@
+package gontracts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+const (
+	// maxBatchSize limits number of purchases in a single batch
+	maxBatchSize = 1000
+	// maxBatchBody limits size of batch request body
+	maxBatchBody = 1 << 20
+)
+
+var (
+	// ErrBatchEmpty batch has no purchases
+	ErrBatchEmpty = errors.New("batch has no purchases")
+	// ErrBatchTooLarge batch has too many purchases
+	ErrBatchTooLarge = errors.New("batch has more than " + strconv.Itoa(maxBatchSize) + " purchases")
+)
+
+// Batch item statuses
+const (
+	// BatchCreated purchase is created
+	BatchCreated = "created"
+	// BatchValid purchase is valid but not created because of dry run or other invalid items of atomic batch
+	BatchValid = "valid"
+	// BatchFailed purchase is invalid or failed to be created
+	BatchFailed = "failed"
+)
+
+// BatchResult is a report of batch purchase
+type BatchResult struct {
+	DryRun  bool        `json:"dryRun"`
+	Atomic  bool        `json:"atomic"`
+	Total   int         `json:"total"`
+	Valid   int         `json:"valid"`
+	Created int         `json:"created"`
+	Items   []BatchItem `json:"items"`
+}
+
+// BatchItem is a result of purchase at index of the batch
+type BatchItem struct {
+	Index  int          `json:"index"`
+	Status string       `json:"status"`
+	ID     int          `json:"ID,omitempty"`
+	Code   string       `json:"code,omitempty"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// CreatePurchases checks purchase documents in order and creates valid ones.
+// Every purchase is checked as a single one, spending credits left by preceding purchases of the batch
+func (h *Handler) CreatePurchases(ctx context.Context, docs []any, opts ImportOptions) (*BatchResult, error) {
+	if len(docs) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	if len(docs) > maxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	rows := make([]importDoc, len(docs))
+	for i, d := range docs {
+		rows[i] = importDoc{row: i, doc: d}
+	}
+	report, err := h.svc.CreatePurchases(ctx, decodeRows[model.Purchase](rows, "Purchase"), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BatchResult{
+		DryRun:  opts.DryRun,
+		Atomic:  opts.Atomic,
+		Total:   len(docs),
+		Valid:   report.Valid,
+		Created: len(report.Created),
+		Items:   make([]BatchItem, len(docs)),
+	}
+	for i := range res.Items {
+		res.Items[i] = BatchItem{Index: i, Status: BatchValid}
+	}
+	for _, c := range report.Created {
+		res.Items[c.Row].Status = BatchCreated
+		res.Items[c.Row].ID = c.ID
+	}
+	for _, e := range report.Errors {
+		ae := toAPIError(purchaseError(e.Err))
+		item := &res.Items[e.Row]
+		item.Status = BatchFailed
+		item.Code = ae.Code
+		item.Detail = ae.Error()
+		var ve *ValidationError
+		if errors.As(e.Err, &ve) {
+			item.Errors = ve.Fields
+		}
+	}
+	return res, nil
+}
+
+// PurchaseBatch creates purchase documents from JSON array of request body
+func (h *Handler) PurchaseBatch(w http.ResponseWriter, r *http.Request) {
+	// get batch options from request query
+	opts, err := parseImportOptions(r.URL.Query())
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// read request body
+	var docs []any
+	dc := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody))
+	dc.UseNumber()
+	if err = dc.Decode(&docs); err != nil {
+		h.logError(r, err)
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			writeProblem(w, r, ErrBatchTooLarge)
+			return
+		}
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// check and create purchase documents
+	res, err := h.CreatePurchases(r.Context(), docs, opts)
+	if err != nil {
+		h.logError(r, err, "batch", len(docs))
+		writeProblem(w, r, err)
+		return
+	}
+
+	status := http.StatusCreated
+	switch {
+	case opts.DryRun:
+		status = http.StatusOK
+	case res.Created == 0:
+		// nothing is created because of invalid purchases
+		status = http.StatusUnprocessableEntity
+	}
+
+	// fill response json
+	resp, err := json.Marshal(res)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
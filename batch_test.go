@@ -0,0 +1,90 @@
+package gontracts
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPurchaseBatch(t *testing.T) {
+	// contract 1 has 10 credits remaining
+	const batch = `[{"contractID":1,"datetime":"2000-06-01T00:00:00Z","amount":6},` +
+		`{"contractID":1,"datetime":"2000-06-01T00:00:00Z","amount":5},` +
+		`{"contractID":9,"datetime":"2000-06-01T00:00:00Z","amount":1},` +
+		`{"contractID":1,"datetime":"2000-06-01T00:00:00Z","amount":4}]`
+
+	cases := []struct {
+		Num      string
+		URL      string
+		Body     string
+		Response string
+		Status   int
+	}{
+		// atomic batch of valid purchases
+		{
+			Num:      "1",
+			URL:      "/purchase/batch",
+			Body:     `[{"contractID":1,"datetime":"2000-06-01T00:00:00Z","amount":5},{"contractID":1,"datetime":"2000-07-01T00:00:00Z","amount":5}]`,
+			Response: `{"dryRun":false,"atomic":true,"total":2,"valid":2,"created":2,"items":[{"index":0,"status":"created","ID":3},{"index":1,"status":"created","ID":4}]}`,
+			Status:   http.StatusCreated,
+		},
+		// atomic batch with invalid purchase creates nothing
+		{
+			Num:      "2",
+			URL:      "/purchase/batch",
+			Body:     batch,
+			Response: `{"dryRun":false,"atomic":true,"total":4,"valid":2,"created":0,"items":[{"index":0,"status":"valid"},{"index":1,"status":"failed","code":"not_enough_money","detail":"not enough money for the purchase"},{"index":2,"status":"failed","code":"contract_not_found","detail":"contract doesn't exist"},{"index":3,"status":"valid"}]}`,
+			Status:   http.StatusUnprocessableEntity,
+		},
+		// best-effort batch skips invalid purchases
+		{
+			Num:      "3",
+			URL:      "/purchase/batch?atomic=false",
+			Body:     batch,
+			Response: `{"dryRun":false,"atomic":false,"total":4,"valid":2,"created":2,"items":[{"index":0,"status":"created","ID":3},{"index":1,"status":"failed","code":"not_enough_money","detail":"not enough money for the purchase"},{"index":2,"status":"failed","code":"contract_not_found","detail":"contract doesn't exist"},{"index":3,"status":"created","ID":3}]}`,
+			Status:   http.StatusCreated,
+		},
+		{
+			Num:      "4",
+			URL:      "/purchase/batch?dryRun=true",
+			Body:     `[{"contractID":1,"datetime":"2002-01-01T00:00:00Z","amount":1},{"contractID":1,"amount":1}]`,
+			Response: `{"dryRun":true,"atomic":true,"total":2,"valid":0,"created":0,"items":[{"index":0,"status":"failed","code":"purchase_date_not_valid","detail":"purchase date is outside the contract date range"},{"index":1,"status":"failed","code":"validation_failed","detail":"request body is not valid","errors":[{"field":"datetime","reason":"is required"}]}]}`,
+			Status:   http.StatusOK,
+		},
+		// request errors
+		{
+			Num:      "5",
+			URL:      "/purchase/batch",
+			Body:     `[]`,
+			Response: testProblem(http.StatusBadRequest, "batch_empty", ErrBatchEmpty.Error(), "/purchase/batch"),
+			Status:   http.StatusBadRequest,
+		},
+		{
+			Num:      "6",
+			URL:      "/purchase/batch",
+			Body:     `{"contractID":1}`,
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, "json: cannot unmarshal object into Go value of type []interface {}", "/purchase/batch"),
+			Status:   http.StatusBadRequest,
+		},
+		{
+			Num:      "7",
+			URL:      "/purchase/batch",
+			Body:     "[" + strings.Repeat(" ", maxBatchBody) + "]",
+			Response: testProblem(http.StatusRequestEntityTooLarge, "batch_too_large", ErrBatchTooLarge.Error(), "/purchase/batch"),
+			Status:   http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, c := range cases {
+		ms := testClientModels()
+		h := NewHandler(ms.company, ms.contract, ms.purchase)
+
+		req := httptest.NewRequest("POST", c.URL, bytes.NewBufferString(c.Body))
+		w := httptest.NewRecorder()
+
+		testHandle("/purchase/batch", w, req, h.PurchaseBatch)
+		testCheckResponse("PurchaseBatch:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
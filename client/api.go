@@ -0,0 +1,353 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// mergePatchContentType is a media type of RFC 7396 merge patch
+const mergePatchContentType = "application/merge-patch+json"
+
+// newID is a response of create requests
+type newID struct {
+	ID int
+}
+
+// GetCompany returns company by id with its current version
+func (c *Client) GetCompany(ctx context.Context, id int) (*model.Company, error) {
+	var company model.Company
+	h, err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/company/" + strconv.Itoa(id),
+		out:    &company,
+	})
+	if err != nil {
+		return nil, err
+	}
+	company.Version = readETag(h)
+	return &company, nil
+}
+
+// ListCompanies returns page of companies and next page cursor, empty on the last page
+func (c *Client) ListCompanies(ctx context.Context, q model.CompanyQuery) ([]*model.Company, string, error) {
+	v := listQuery(q.Sort, q.Page)
+	setBool(v, "includeDeleted", q.IncludeDeleted)
+
+	var list []*model.Company
+	h, err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/company",
+		query:  v,
+		out:    &list,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return list, h.Get(nextCursorHeader), nil
+}
+
+// SearchCompanies returns companies matching the query, the most relevant first
+func (c *Client) SearchCompanies(ctx context.Context, q model.CompanySearch) ([]*model.CompanyMatch, error) {
+	v := url.Values{"q": {q.Query}}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	var list []*model.CompanyMatch
+	_, err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/company/search",
+		query:  v,
+		out:    &list,
+	})
+	return list, err
+}
+
+// CreateCompany creates new company and returns its id
+func (c *Client) CreateCompany(ctx context.Context, company *model.Company) (int, error) {
+	var res newID
+	_, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/company",
+		body:   company,
+		out:    &res,
+	})
+	return res.ID, err
+}
+
+// UpdateCompany creates company without id or updates existing one.
+// Non-zero version is sent as If-Match, the new version is set on success
+func (c *Client) UpdateCompany(ctx context.Context, company *model.Company) error {
+	var res model.Company
+	h, err := c.do(ctx, &request{
+		method: http.MethodPut,
+		path:   "/company",
+		header: ifMatch(company.Version),
+		body:   company,
+		out:    &res,
+	})
+	if err != nil {
+		return err
+	}
+	company.ID = res.ID
+	company.Version = readETag(h)
+	return nil
+}
+
+// PatchCompany applies JSON merge patch to company of expected version, zero matches any version
+func (c *Client) PatchCompany(ctx context.Context, id, version int, patch any) (*model.Company, error) {
+	header := ifMatch(version)
+	header.Set("Content-Type", mergePatchContentType)
+	var company model.Company
+	h, err := c.do(ctx, &request{
+		method: http.MethodPatch,
+		path:   "/company/" + strconv.Itoa(id),
+		header: header,
+		body:   patch,
+		out:    &company,
+	})
+	if err != nil {
+		return nil, err
+	}
+	company.Version = readETag(h)
+	return &company, nil
+}
+
+// DeleteCompany soft deletes company of expected version, zero matches any version.
+// Company with contracts isn't deleted unless cascade deletes its contracts as well
+func (c *Client) DeleteCompany(ctx context.Context, id, version int, cascade bool) error {
+	v := url.Values{}
+	setBool(v, "cascade", cascade)
+	_, err := c.do(ctx, &request{
+		method: http.MethodDelete,
+		path:   "/company/" + strconv.Itoa(id),
+		query:  v,
+		header: ifMatch(version),
+	})
+	return err
+}
+
+// RestoreCompany restores soft deleted company of expected version, zero matches any version
+func (c *Client) RestoreCompany(ctx context.Context, id, version int) (*model.Company, error) {
+	var company model.Company
+	h, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/company/" + strconv.Itoa(id) + "/restore",
+		header: ifMatch(version),
+		out:    &company,
+	})
+	if err != nil {
+		return nil, err
+	}
+	company.Version = readETag(h)
+	return &company, nil
+}
+
+// GetContract returns contract by id with its current version
+func (c *Client) GetContract(ctx context.Context, id int) (*model.Contract, error) {
+	var contract model.Contract
+	h, err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/contract/" + strconv.Itoa(id),
+		out:    &contract,
+	})
+	if err != nil {
+		return nil, err
+	}
+	contract.Version = readETag(h)
+	return &contract, nil
+}
+
+// ListContracts returns page of contracts and next page cursor, empty on the last page
+func (c *Client) ListContracts(ctx context.Context, q model.ContractQuery) ([]*model.Contract, string, error) {
+	v := listQuery(q.Sort, q.Page)
+	if q.SellerID != 0 {
+		v.Set("seller", strconv.Itoa(q.SellerID))
+	}
+	if q.ClientID != 0 {
+		v.Set("client", strconv.Itoa(q.ClientID))
+	}
+	setTime(v, "activeFrom", q.ActiveFrom)
+	setTime(v, "activeTo", q.ActiveTo)
+	setBool(v, "includeDeleted", q.IncludeDeleted)
+
+	var list []*model.Contract
+	h, err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/contract",
+		query:  v,
+		out:    &list,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return list, h.Get(nextCursorHeader), nil
+}
+
+// CreateContract creates new contract and returns its id
+func (c *Client) CreateContract(ctx context.Context, contract *model.Contract) (int, error) {
+	var res newID
+	_, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/contract",
+		body:   contract,
+		out:    &res,
+	})
+	return res.ID, err
+}
+
+// UpdateContract creates contract without id or updates existing one.
+// Non-zero version is sent as If-Match, the new version is set on success
+func (c *Client) UpdateContract(ctx context.Context, contract *model.Contract) error {
+	var res model.Contract
+	h, err := c.do(ctx, &request{
+		method: http.MethodPut,
+		path:   "/contract",
+		header: ifMatch(contract.Version),
+		body:   contract,
+		out:    &res,
+	})
+	if err != nil {
+		return err
+	}
+	contract.ID = res.ID
+	contract.Version = readETag(h)
+	return nil
+}
+
+// PatchContract applies JSON merge patch to contract of expected version, zero matches any version
+func (c *Client) PatchContract(ctx context.Context, id, version int, patch any) (*model.Contract, error) {
+	header := ifMatch(version)
+	header.Set("Content-Type", mergePatchContentType)
+	var contract model.Contract
+	h, err := c.do(ctx, &request{
+		method: http.MethodPatch,
+		path:   "/contract/" + strconv.Itoa(id),
+		header: header,
+		body:   patch,
+		out:    &contract,
+	})
+	if err != nil {
+		return nil, err
+	}
+	contract.Version = readETag(h)
+	return &contract, nil
+}
+
+// DeleteContract soft deletes contract of expected version, zero matches any version
+func (c *Client) DeleteContract(ctx context.Context, id, version int) error {
+	_, err := c.do(ctx, &request{
+		method: http.MethodDelete,
+		path:   "/contract/" + strconv.Itoa(id),
+		header: ifMatch(version),
+	})
+	return err
+}
+
+// RestoreContract restores soft deleted contract of expected version, zero matches any version
+func (c *Client) RestoreContract(ctx context.Context, id, version int) (*model.Contract, error) {
+	var contract model.Contract
+	h, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/contract/" + strconv.Itoa(id) + "/restore",
+		header: ifMatch(version),
+		out:    &contract,
+	})
+	if err != nil {
+		return nil, err
+	}
+	contract.Version = readETag(h)
+	return &contract, nil
+}
+
+// GetPurchaseHistory returns page of contract purchases and next page cursor, empty on the last page
+func (c *Client) GetPurchaseHistory(ctx context.Context, q model.PurchaseQuery) ([]*model.Purchase, string, error) {
+	v := listQuery(q.Sort, q.Page)
+	setTime(v, "from", q.From)
+	setTime(v, "to", q.To)
+	if q.MinAmount != nil {
+		v.Set("minAmount", strconv.Itoa(*q.MinAmount))
+	}
+	if q.MaxAmount != nil {
+		v.Set("maxAmount", strconv.Itoa(*q.MaxAmount))
+	}
+
+	var list []*model.Purchase
+	h, err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/contract/" + strconv.Itoa(q.ContractID) + "/purchase",
+		query:  v,
+		out:    &list,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return list, h.Get(nextCursorHeader), nil
+}
+
+// CreatePurchase creates new purchase document and returns its id
+func (c *Client) CreatePurchase(ctx context.Context, purchase *model.Purchase) (int, error) {
+	var res newID
+	_, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/purchase",
+		body:   purchase,
+		out:    &res,
+	})
+	return res.ID, err
+}
+
+// listQuery encodes sort and page of list request
+func listQuery(s model.Sort, p model.Page) url.Values {
+	v := make(url.Values)
+	if s.Field != "" {
+		field := s.Field
+		if s.Desc {
+			field = "-" + field
+		}
+		v.Set("sort", field)
+	}
+	if p.Limit > 0 {
+		v.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Cursor != "" {
+		v.Set("cursor", p.Cursor)
+	}
+	return v
+}
+
+func setTime(v url.Values, key string, t *time.Time) {
+	if t != nil {
+		v.Set(key, t.Format(time.RFC3339))
+	}
+}
+
+// setBool sets flag param if it's true
+func setBool(v url.Values, key string, b bool) {
+	if b {
+		v.Set(key, "true")
+	}
+}
+
+// ifMatch returns headers expecting item version, zero matches any version
+func ifMatch(version int) http.Header {
+	h := make(http.Header)
+	if version > 0 {
+		h.Set("If-Match", `"`+strconv.Itoa(version)+`"`)
+	}
+	return h
+}
+
+// readETag returns item version of response entity tag, zero if it is missing
+func readETag(h http.Header) int {
+	tag := h.Get("ETag")
+	if len(tag) < 2 {
+		return 0
+	}
+	version, _ := strconv.Atoi(tag[1 : len(tag)-1])
+	return version
+}
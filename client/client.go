@@ -0,0 +1,279 @@
+// Package client is a typed Go client of the contract microservice API
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retry defaults
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 200 * time.Millisecond
+)
+
+// tokenRefreshMargin is a time before token expiration when a new token is requested
+const tokenRefreshMargin = time.Minute
+
+// Request headers
+const (
+	apiKeyHeader         = "X-API-Key"
+	idempotencyKeyHeader = "Idempotency-Key"
+	nextCursorHeader     = "X-Next-Cursor"
+)
+
+// Client is a gontracts API client, safe for concurrent use.
+// Requests are authenticated with API key if it is set, otherwise with a bearer token
+// acquired from the server and refreshed when it expires
+type Client struct {
+	// BaseURL is a server URL, e.g. http://localhost:8000
+	BaseURL string
+	// HTTPClient sends requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// APIKey is a long-lived key used instead of bearer token
+	APIKey string
+	// MaxRetries limits retries of requests failed with network errors or temporary statuses
+	MaxRetries int
+	// RetryWait is a delay before the first retry, doubled for every next one
+	RetryWait time.Duration
+
+	mx       sync.Mutex
+	token    string
+	tokenExp time.Time
+}
+
+// NewClient returns client of server at base URL with default retry settings
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		MaxRetries: DefaultMaxRetries,
+		RetryWait:  DefaultRetryWait,
+	}
+}
+
+// request describes API call
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	// out is decoded from successful response body if set
+	out any
+}
+
+// Token returns current bearer token, a new one is acquired if it is missing or expires soon
+func (c *Client) Token(ctx context.Context) (string, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.token != "" && (c.tokenExp.IsZero() || time.Now().Add(tokenRefreshMargin).Before(c.tokenExp)) {
+		return c.token, nil
+	}
+
+	var token string
+	err := c.retry(ctx, func() (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/get-token", nil)
+		if err != nil {
+			return false, err
+		}
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = readError(resp)
+			return retryable(err), err
+		}
+		b, err := io.ReadAll(resp.Body)
+		token = strings.TrimSpace(string(b))
+		return true, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.token, c.tokenExp = token, tokenExpiration(token)
+	return c.token, nil
+}
+
+// resetToken drops rejected token
+func (c *Client) resetToken(token string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// do sends request and decodes response, returns response headers.
+// POST requests get an idempotency key, so all requests are safe to retry
+func (c *Client) do(ctx context.Context, rq *request) (http.Header, error) {
+	var body []byte
+	if rq.body != nil {
+		var err error
+		if body, err = json.Marshal(rq.body); err != nil {
+			return nil, err
+		}
+	}
+
+	header := rq.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if body != nil && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	if rq.method == http.MethodPost && header.Get(idempotencyKeyHeader) == "" {
+		header.Set(idempotencyKeyHeader, newIdempotencyKey())
+	}
+
+	target := c.BaseURL + rq.path
+	if len(rq.query) > 0 {
+		target += "?" + rq.query.Encode()
+	}
+
+	var resHeader http.Header
+	tokenRefreshed := false
+	err := c.retry(ctx, func() (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, rq.method, target, bytes.NewReader(body))
+		if err != nil {
+			return false, err
+		}
+		req.Header = header.Clone()
+
+		token, err := c.authorize(ctx, req)
+		if err != nil {
+			return false, err
+		}
+
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			err = readError(resp)
+			// expired or revoked token is replaced once
+			if token != "" && !tokenRefreshed && errors.Is(err, ErrTokenInvalid) {
+				c.resetToken(token)
+				tokenRefreshed = true
+				return true, errRetryNow
+			}
+			return retryable(err), err
+		}
+
+		resHeader = resp.Header
+		if rq.out != nil && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+			if err = json.NewDecoder(resp.Body).Decode(rq.out); err != nil {
+				return false, fmt.Errorf("gontracts: decode response: %w", err)
+			}
+		}
+		return false, nil
+	})
+	return resHeader, err
+}
+
+// authorize adds credentials to the request, returns bearer token used
+func (c *Client) authorize(ctx context.Context, req *http.Request) (string, error) {
+	if c.APIKey != "" {
+		req.Header.Set(apiKeyHeader, c.APIKey)
+		return "", nil
+	}
+	token, err := c.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return token, nil
+}
+
+// errRetryNow repeats the attempt without waiting and counting it
+var errRetryNow = errors.New("retry now")
+
+// retry calls attempt until it succeeds, fails permanently or retries are exhausted.
+// Attempt reports whether its error is temporary
+func (c *Client) retry(ctx context.Context, attempt func() (bool, error)) error {
+	wait := c.RetryWait
+	for n := 0; ; {
+		temporary, err := attempt()
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, errRetryNow) {
+			continue
+		}
+		if !temporary || n >= c.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+		n++
+
+		delay := wait
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.retryAfter > 0 {
+			delay = apiErr.retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// tokenExpiration reads expiration time of JWT, zero if it is unknown
+func tokenExpiration(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// retryAfter reads delay of Retry-After header in seconds
+func retryAfter(h http.Header) time.Duration {
+	sec, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || sec <= 0 {
+		return 0
+	}
+	return time.Duration(sec) * time.Second
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// problemContentType is a media type of API error responses
+const problemContentType = "application/problem+json"
+
+// Errors of the API matched by code with errors.Is
+var (
+	ErrInvalidRequest       = &Error{Code: "invalid_request"}
+	ErrInvalidQuery         = &Error{Code: "invalid_query"}
+	ErrValidationFailed     = &Error{Code: "validation_failed"}
+	ErrTokenInvalid         = &Error{Code: "token_invalid"}
+	ErrAPIKeyInvalid        = &Error{Code: "api_key_invalid"}
+	ErrAPIKeyRevoked        = &Error{Code: "api_key_revoked"}
+	ErrAPIKeyExpired        = &Error{Code: "api_key_expired"}
+	ErrScopeNotAllowed      = &Error{Code: "scope_not_allowed"}
+	ErrCompanyNotFound      = &Error{Code: "company_not_found"}
+	ErrContractNotFound     = &Error{Code: "contract_not_found"}
+	ErrSellerNotFound       = &Error{Code: "seller_not_found"}
+	ErrClientNotFound       = &Error{Code: "client_not_found"}
+	ErrPurchaseDateNotValid = &Error{Code: "purchase_date_not_valid"}
+	ErrNotEnoughMoney       = &Error{Code: "not_enough_money"}
+	ErrCompanyHasContracts  = &Error{Code: "company_has_contracts"}
+	ErrVersionMismatch      = &Error{Code: "version_mismatch"}
+	ErrIfMatchRequired      = &Error{Code: "if_match_required"}
+	ErrIdempotencyKeyReused = &Error{Code: "idempotency_key_reused"}
+	ErrIdempotencyKeyInUse  = &Error{Code: "idempotency_key_in_use"}
+	ErrStorageUnavailable   = &Error{Code: "storage_unavailable"}
+	ErrInternal             = &Error{Code: "internal_error"}
+)
+
+// FieldError describes invalid field of request body
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Error is an RFC 7807 problem returned by the API
+type Error struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	Instance  string       `json:"instance"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId"`
+	Errors    []FieldError `json:"errors"`
+
+	retryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("gontracts: %d %s", e.Status, e.Code)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, f := range e.Errors {
+		msg += "; " + f.Field + ": " + f.Reason
+	}
+	return msg
+}
+
+// Is matches errors with the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// readError decodes error response.
+// Responses that aren't problem documents get a code derived from the status
+func readError(resp *http.Response) error {
+	e := &Error{
+		Status:     resp.StatusCode,
+		Title:      http.StatusText(resp.StatusCode),
+		retryAfter: retryAfter(resp.Header),
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), problemContentType) && json.Unmarshal(body, e) == nil && e.Code != "" {
+		return e
+	}
+
+	e.Detail = strings.TrimSpace(string(body))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		e.Code = ErrTokenInvalid.Code
+	case resp.StatusCode >= http.StatusInternalServerError:
+		e.Code = ErrInternal.Code
+	default:
+		e.Code = strings.ToLower(strings.ReplaceAll(e.Title, " ", "_"))
+	}
+	return e
+}
+
+// retryable reports whether request may succeed if repeated
+func retryable(err error) bool {
+	e, ok := err.(*Error)
+	if !ok {
+		return false
+	}
+	switch e.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return e.Code == ErrIdempotencyKeyInUse.Code
+}
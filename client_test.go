@@ -0,0 +1,299 @@
+package gontracts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/client"
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+// testCountingPurchase counts created purchase documents
+type testCountingPurchase struct {
+	test.TestPurchase
+	added *int32
+}
+
+func (t testCountingPurchase) AddItem(ctx context.Context, pur *model.Purchase) (int, error) {
+	atomic.AddInt32(t.added, 1)
+	return t.TestPurchase.AddItem(ctx, pur)
+}
+
+func testClientModels() testModelSet {
+	contracts := []*model.Contract{
+		{1, 1, 2, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), 100, 2, nil},
+	}
+	return testModelSet{
+		company: test.TestCompany{
+			CL: []*model.Company{
+				{1, "Megacom", testStrPtr("MGC111"), 3, nil},
+				{2, "Supercom", nil, 1, nil},
+				{3, "Hypercom", testStrPtr("HPC333"), 1, nil},
+			},
+			Contracts: contracts,
+		},
+		contract: test.TestContract{
+			CL: contracts,
+		},
+		purchase: test.TestPurchase{
+			CL: []*model.Purchase{
+				{1, 1, time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC), 30},
+				{2, 1, time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC), 60},
+			},
+		},
+	}
+}
+
+// testClientServer runs the service router with the models and token signing key
+func testClientServer(ms testModelSet, key string) http.Handler {
+	h := NewHandler(ms.company, ms.contract, ms.purchase)
+	return RequestID(newRouter(h, NewAuthHandler([]byte(key), nil), NewHealthHandler(), NewMetrics(nil)))
+}
+
+func testNewClient(url string) *client.Client {
+	c := client.NewClient(url)
+	c.RetryWait = time.Millisecond
+	return c
+}
+
+func TestClientCompany(t *testing.T) {
+	srv := httptest.NewServer(testClientServer(testClientModels(), "test"))
+	defer srv.Close()
+	c := testNewClient(srv.URL)
+	ctx := context.Background()
+
+	comp, err := c.GetCompany(ctx, 1)
+	if err != nil {
+		t.Fatalf("[ClientCompany]:\tGetCompany: %v", err)
+	}
+	if exp := (&model.Company{1, "Megacom", testStrPtr("MGC111"), 3, nil}); !reflect.DeepEqual(comp, exp) {
+		t.Errorf("[ClientCompany]:\tGetCompany: got %+v, expected %+v", comp, exp)
+	}
+
+	_, err = c.GetCompany(ctx, 9)
+	if !errors.Is(err, client.ErrCompanyNotFound) {
+		t.Errorf("[ClientCompany]:\tGetCompany: got error %v, expected %v", err, client.ErrCompanyNotFound)
+	}
+
+	list, next, err := c.ListCompanies(ctx, model.CompanyQuery{Page: model.Page{Limit: 2}})
+	if err != nil || len(list) != 2 || next == "" {
+		t.Fatalf("[ClientCompany]:\tListCompanies: got %d items, cursor %q, error %v", len(list), next, err)
+	}
+	list, next, err = c.ListCompanies(ctx, model.CompanyQuery{Page: model.Page{Limit: 2, Cursor: next}})
+	if err != nil || len(list) != 1 || list[0].ID != 3 || next != "" {
+		t.Errorf("[ClientCompany]:\tListCompanies: got %d items, cursor %q, error %v", len(list), next, err)
+	}
+
+	found, err := c.SearchCompanies(ctx, model.CompanySearch{Query: "hpc"})
+	if err != nil || len(found) != 1 || found[0].Highlight["regcode"] != "<em>HPC</em>333" {
+		t.Errorf("[ClientCompany]:\tSearchCompanies: got %+v, error %v", found, err)
+	}
+
+	id, err := c.CreateCompany(ctx, &model.Company{Name: "Newcom"})
+	if err != nil || id != 4 {
+		t.Errorf("[ClientCompany]:\tCreateCompany: got id %d, error %v", id, err)
+	}
+
+	_, err = c.CreateCompany(ctx, &model.Company{Name: " "})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "validation_failed" || len(apiErr.Errors) != 1 || apiErr.Errors[0].Field != "name" {
+		t.Errorf("[ClientCompany]:\tCreateCompany: got error %v, expected validation failure of name", err)
+	}
+
+	comp.Name = "Megacom Ltd"
+	if err = c.UpdateCompany(ctx, comp); err != nil || comp.Version != 4 {
+		t.Errorf("[ClientCompany]:\tUpdateCompany: got version %d, error %v", comp.Version, err)
+	}
+
+	comp.Version = 2
+	err = c.UpdateCompany(ctx, comp)
+	if !errors.Is(err, client.ErrVersionMismatch) {
+		t.Errorf("[ClientCompany]:\tUpdateCompany: got error %v, expected %v", err, client.ErrVersionMismatch)
+	}
+
+	patched, err := c.PatchCompany(ctx, 2, 1, map[string]any{"regcode": "SPC222"})
+	if err != nil || patched.RegCode == nil || *patched.RegCode != "SPC222" || patched.Version != 2 {
+		t.Errorf("[ClientCompany]:\tPatchCompany: got %+v, error %v", patched, err)
+	}
+
+	err = c.DeleteCompany(ctx, 2, 1, false)
+	if !errors.Is(err, client.ErrCompanyHasContracts) {
+		t.Errorf("[ClientCompany]:\tDeleteCompany: got error %v, expected %v", err, client.ErrCompanyHasContracts)
+	}
+
+	if err = c.DeleteCompany(ctx, 3, 1, false); err != nil {
+		t.Errorf("[ClientCompany]:\tDeleteCompany: %v", err)
+	}
+	list, _, err = c.ListCompanies(ctx, model.CompanyQuery{IncludeDeleted: true})
+	if err != nil || len(list) != 3 || list[2].DeletedAt == nil {
+		t.Errorf("[ClientCompany]:\tListCompanies: got %+v, error %v, expected deleted company", list, err)
+	}
+
+	restored, err := c.RestoreCompany(ctx, 3, 1)
+	if err != nil || restored.ID != 3 || restored.DeletedAt != nil || restored.Version != 1 {
+		t.Errorf("[ClientCompany]:\tRestoreCompany: got %+v, error %v", restored, err)
+	}
+}
+
+func TestClientContract(t *testing.T) {
+	ms := testClientModels()
+	srv := httptest.NewServer(testClientServer(ms, "test"))
+	defer srv.Close()
+	c := testNewClient(srv.URL)
+	ctx := context.Background()
+
+	contr, err := c.GetContract(ctx, 1)
+	if err != nil || contr.CreditAmount != 100 || contr.Version != 2 {
+		t.Fatalf("[ClientContract]:\tGetContract: got %+v, error %v", contr, err)
+	}
+
+	list, _, err := c.ListContracts(ctx, model.ContractQuery{SellerID: 2})
+	if err != nil || len(list) != 0 {
+		t.Errorf("[ClientContract]:\tListContracts: got %d items, error %v", len(list), err)
+	}
+
+	_, err = c.CreateContract(ctx, &model.Contract{
+		SellerID:  1,
+		ClientID:  9,
+		ValidFrom: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:   time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if !errors.Is(err, client.ErrClientNotFound) {
+		t.Errorf("[ClientContract]:\tCreateContract: got error %v, expected %v", err, client.ErrClientNotFound)
+	}
+
+	patched, err := c.PatchContract(ctx, 1, contr.Version, map[string]any{"amount": 150})
+	if err != nil || patched.CreditAmount != 150 || patched.Version != 3 {
+		t.Errorf("[ClientContract]:\tPatchContract: got %+v, error %v", patched, err)
+	}
+
+	min := 50
+	hist, next, err := c.GetPurchaseHistory(ctx, model.PurchaseQuery{ContractID: 1, MinAmount: &min})
+	if err != nil || len(hist) != 1 || hist[0].ID != 2 || next != "" {
+		t.Errorf("[ClientContract]:\tGetPurchaseHistory: got %d items, cursor %q, error %v", len(hist), next, err)
+	}
+
+	_, err = c.CreatePurchase(ctx, &model.Purchase{
+		ContractID:       1,
+		PurchaseDateTime: time.Date(2000, 4, 1, 0, 0, 0, 0, time.UTC),
+		CreditSpent:      20,
+	})
+	if !errors.Is(err, client.ErrNotEnoughMoney) {
+		t.Errorf("[ClientContract]:\tCreatePurchase: got error %v, expected %v", err, client.ErrNotEnoughMoney)
+	}
+}
+
+// TestClientTokenRefresh checks that token rejected after server key rotation is replaced
+func TestClientTokenRefresh(t *testing.T) {
+	ms := testClientModels()
+	var current atomic.Value
+	current.Store(testClientServer(ms, "first"))
+	var tokens int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/get-token" {
+			atomic.AddInt32(&tokens, 1)
+		}
+		current.Load().(http.Handler).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	c := testNewClient(srv.URL)
+	ctx := context.Background()
+
+	if _, err := c.GetCompany(ctx, 1); err != nil {
+		t.Fatalf("[ClientTokenRefresh]:\tGetCompany: %v", err)
+	}
+	if _, err := c.GetCompany(ctx, 2); err != nil {
+		t.Fatalf("[ClientTokenRefresh]:\tGetCompany: %v", err)
+	}
+	if n := atomic.LoadInt32(&tokens); n != 1 {
+		t.Errorf("[ClientTokenRefresh]:\tgot %d token requests, expected 1", n)
+	}
+
+	current.Store(testClientServer(ms, "second"))
+	if _, err := c.GetCompany(ctx, 1); err != nil {
+		t.Fatalf("[ClientTokenRefresh]:\tGetCompany after key rotation: %v", err)
+	}
+	if n := atomic.LoadInt32(&tokens); n != 2 {
+		t.Errorf("[ClientTokenRefresh]:\tgot %d token requests, expected 2", n)
+	}
+
+	c = testNewClient(srv.URL)
+	c.APIKey = "unknown"
+	_, err := c.GetCompany(ctx, 1)
+	if !errors.Is(err, client.ErrTokenInvalid) {
+		t.Errorf("[ClientTokenRefresh]:\tgot error %v, expected %v", err, client.ErrTokenInvalid)
+	}
+}
+
+// TestClientRetry checks that requests are retried and repeated purchase isn't created twice
+func TestClientRetry(t *testing.T) {
+	ms := testClientModels()
+	var added int32
+	ms.purchase = testCountingPurchase{ms.purchase.(test.TestPurchase), &added}
+	api := testClientServer(ms, "test")
+
+	var mx sync.Mutex
+	var failures int
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mx.Lock()
+		defer mx.Unlock()
+		switch r.URL.Path {
+		case "/company/1":
+			// service is temporarily unavailable
+			if failures < 2 {
+				failures++
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/purchase":
+			// purchase is created but the response is lost
+			keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+			if len(keys) == 1 {
+				api.ServeHTTP(httptest.NewRecorder(), r)
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+		}
+		api.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	c := testNewClient(srv.URL)
+	ctx := context.Background()
+
+	if _, err := c.GetCompany(ctx, 1); err != nil || failures != 2 {
+		t.Errorf("[ClientRetry]:\tGetCompany: got %d failures, error %v", failures, err)
+	}
+
+	id, err := c.CreatePurchase(ctx, &model.Purchase{
+		ContractID:       1,
+		PurchaseDateTime: time.Date(2000, 4, 1, 0, 0, 0, 0, time.UTC),
+		CreditSpent:      5,
+	})
+	if err != nil || id != 3 {
+		t.Errorf("[ClientRetry]:\tCreatePurchase: got id %d, error %v", id, err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("[ClientRetry]:\tCreatePurchase: got idempotency keys %q, expected two equal keys", keys)
+	}
+	if n := atomic.LoadInt32(&added); n != 1 {
+		t.Errorf("[ClientRetry]:\tCreatePurchase: got %d purchases created, expected 1", n)
+	}
+
+	c.MaxRetries = 1
+	failures = 0
+	_, err = c.GetCompany(ctx, 1)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+		t.Errorf("[ClientRetry]:\tGetCompany: got error %v, expected status 503", err)
+	}
+}
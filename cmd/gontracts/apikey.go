@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/ilyakaznacheev/gontracts"
+	"github.com/ilyakaznacheev/gontracts/db"
+)
+
+// runAPIKey issues api key directly in DB and prints it.
+// This is the way to get the first admin key, bearer tokens can't manage api keys
+func runAPIKey(args []string) int {
+	fs := flag.NewFlagSet("apikey", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gontracts apikey [flags]")
+		fs.PrintDefaults()
+	}
+	dsn := fs.String("dsn", db.DefaultDSN, "MySQL data source name")
+	name := fs.String("name", "", "name of the key")
+	scopes := fs.String("scopes", gontracts.ScopeAdmin, "comma separated scopes: read, write, admin")
+	fs.Parse(args)
+	if fs.NArg() != 0 || *name == "" {
+		fs.Usage()
+		return 2
+	}
+
+	dbConn, err := db.Connect(*dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	logger := gontracts.NewLogger(os.Stderr, slog.LevelWarn)
+	res, err := gontracts.IssueAPIKey(context.Background(), db.NewAPIKeyDAC(dbConn, logger), &gontracts.APIKeyRequest{
+		Name:   *name,
+		Scopes: strings.Split(*scopes, ","),
+	})
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	fmt.Println(res.Key)
+	return 0
+}
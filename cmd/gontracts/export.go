@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts"
+	"github.com/ilyakaznacheev/gontracts/db"
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// runExport writes companies, contracts or purchases from DB to file or standard output
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gontracts export [flags]")
+		fs.PrintDefaults()
+	}
+	dsn := fs.String("dsn", db.DefaultDSN, "MySQL data source name")
+	kind := fs.String("kind", gontracts.KindCompany, "kind of exported items: company, contract or purchase")
+	format := fs.String("format", gontracts.ExportCSV, "export format: csv or jsonl")
+	company := fs.Int("company", 0, "export only the company, its contracts and their purchases")
+	from := fs.String("from", "", "start of the date range, RFC 3339")
+	to := fs.String("to", "", "end of the date range, RFC 3339")
+	out := fs.String("o", "-", "output file, - writes standard output")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	q := model.ExportQuery{CompanyID: *company}
+	for _, t := range []struct {
+		s   string
+		dst **time.Time
+	}{{*from, &q.From}, {*to, &q.To}} {
+		if t.s == "" {
+			continue
+		}
+		tm, err := time.Parse(time.RFC3339, t.s)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*t.dst = &tm
+	}
+
+	var dst io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		dst = f
+	}
+	bw := bufio.NewWriter(dst)
+
+	dbConn, err := db.Connect(*dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	logger := gontracts.NewLogger(os.Stderr, slog.LevelWarn)
+	h := gontracts.NewHandler(
+		db.NewCompanyDAC(dbConn, logger),
+		db.NewContractDAC(dbConn, logger),
+		db.NewPurchaseDAC(dbConn, logger),
+	)
+	h.SetLogger(logger)
+
+	if err := h.ExportItems(context.Background(), *kind, *format, q, bw); err != nil {
+		log.Print(err)
+		return 1
+	}
+	if err := bw.Flush(); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
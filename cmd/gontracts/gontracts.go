@@ -1,10 +1,86 @@
 package main
 
 import (
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+
 	"github.com/ilyakaznacheev/gontracts"
+	"github.com/ilyakaznacheev/gontracts/db"
 )
 
 func main() {
-	s := gontracts.Server{}
-	s.Start()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "apikey":
+			os.Exit(runAPIKey(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		}
+	}
+
+	addr := flag.String("addr", ":8000", "TCP address to listen on")
+	dsn := flag.String("dsn", db.DefaultDSN, "MySQL data source name")
+	drain := flag.Duration("drain-timeout", gontracts.DefaultDrainTimeout, "time to wait for in-flight requests on shutdown")
+	delay := flag.Duration("shutdown-delay", 0, "time between readiness probe failure and closing listeners on shutdown")
+	cert := flag.String("tls-cert", "", "PEM encoded server certificate, enables HTTPS")
+	key := flag.String("tls-key", "", "PEM encoded server private key")
+	clientCA := flag.String("tls-client-ca", "", "PEM encoded CA bundle to verify client certificates")
+	requireCert := flag.Bool("tls-require-client-cert", false, "reject clients without valid certificate")
+	subjects := flag.String("tls-client-subjects", "", "JSON file mapping client certificate subjects to scopes")
+	logLevel := flag.String("log-level", "info", "minimal log level: debug, info, warn or error")
+	traceExporter := flag.String("trace-exporter", "", "OpenTelemetry trace exporter: stdout or otlp, tracing is disabled if empty")
+	traceEndpoint := flag.String("trace-endpoint", "", "OTLP/HTTP collector address, localhost:4318 by default")
+	traceInsecure := flag.Bool("trace-insecure", false, "send traces to OTLP collector without TLS")
+	grpcAddr := flag.String("grpc-addr", "", "TCP address of gRPC API, gRPC is disabled if empty")
+	requireIfMatch := flag.Bool("require-if-match", false, "reject changes of existing items without If-Match header")
+	flag.Parse()
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		log.Fatal(err)
+	}
+
+	s := gontracts.Server{
+		Addr:           *addr,
+		DSN:            *dsn,
+		DrainTimeout:   *drain,
+		ShutdownDelay:  *delay,
+		Logger:         gontracts.NewLogger(os.Stderr, level),
+		RequireIfMatch: *requireIfMatch,
+		GRPCAddr:       *grpcAddr,
+	}
+
+	if *cert != "" {
+		s.TLS = &gontracts.TLSConfig{
+			CertFile:          *cert,
+			KeyFile:           *key,
+			ClientCAFile:      *clientCA,
+			RequireClientCert: *requireCert,
+		}
+		if *subjects != "" {
+			m, err := gontracts.LoadClientSubjects(*subjects)
+			if err != nil {
+				log.Fatal(err)
+			}
+			s.TLS.ClientSubjects = m
+		}
+	}
+
+	if *traceExporter != "" {
+		s.Tracing = &gontracts.TracingConfig{
+			Exporter: *traceExporter,
+			Endpoint: *traceEndpoint,
+			Insecure: *traceInsecure,
+		}
+	}
+
+	if err := s.Start(); err != nil {
+		log.Fatal(err)
+	}
 }
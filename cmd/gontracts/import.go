@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/ilyakaznacheev/gontracts"
+	"github.com/ilyakaznacheev/gontracts/db"
+)
+
+// importFormats maps source formats and file extensions to media types
+var importFormats = map[string]string{
+	"csv":    gontracts.CSVContentType,
+	"jsonl":  gontracts.JSONLinesContentType,
+	"ndjson": gontracts.JSONLinesContentType,
+}
+
+// runImport imports companies or contracts from file directly into DB.
+// Returns exit code, 1 if any row failed
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gontracts import [flags] FILE\n\nFILE is CSV or JSON Lines, - reads standard input.")
+		fs.PrintDefaults()
+	}
+	dsn := fs.String("dsn", db.DefaultDSN, "MySQL data source name")
+	kind := fs.String("kind", gontracts.KindCompany, "kind of imported items: company or contract")
+	format := fs.String("format", "", "source format: csv or jsonl, detected by file extension if empty")
+	dryRun := fs.Bool("dry-run", false, "validate rows without creating items")
+	atomic := fs.Bool("atomic", true, "create all rows in a single transaction or nothing if any row is invalid")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	name := fs.Arg(0)
+
+	if *format == "" {
+		*format = filepath.Ext(name)
+		if len(*format) > 0 {
+			*format = (*format)[1:]
+		}
+	}
+	mediaType, ok := importFormats[*format]
+	if !ok {
+		log.Fatalf("unknown import format %q, expected csv or jsonl", *format)
+	}
+
+	var src io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		src = f
+	}
+
+	dbConn, err := db.Connect(*dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	logger := gontracts.NewLogger(os.Stderr, slog.LevelWarn)
+	h := gontracts.NewHandler(
+		db.NewCompanyDAC(dbConn, logger),
+		db.NewContractDAC(dbConn, logger),
+		db.NewPurchaseDAC(dbConn, logger),
+	)
+	h.SetLogger(logger)
+	h.SetAudit(db.NewAuditDAC(dbConn, logger))
+
+	res, err := h.ImportItems(cliContext(), *kind, mediaType, src, gontracts.ImportOptions{
+		DryRun: *dryRun,
+		Atomic: *atomic,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(res)
+	if len(res.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cliContext returns context of changes made by command line user, recorded in audit trail
+func cliContext() context.Context {
+	subject := "cli"
+	if u, err := user.Current(); err == nil {
+		subject += ":" + u.Username
+	}
+	return gontracts.WithIdentity(context.Background(), &gontracts.Identity{Subject: subject})
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/ilyakaznacheev/gontracts/db"
+)
+
+// runMigrate applies changes of schema.sql missing in DB created with an older schema.sql
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gontracts migrate [flags]")
+		fs.PrintDefaults()
+	}
+	dsn := fs.String("dsn", db.DefaultDSN, "MySQL data source name")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	dbConn, err := db.Connect(*dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	applied, err := db.Migrate(dbConn)
+	for _, name := range applied {
+		fmt.Println("applied", name)
+	}
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if len(applied) == 0 {
+		fmt.Println("schema is up to date")
+	}
+	return 0
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// APIKeyDAC is an api key table data access class
+type APIKeyDAC struct {
+	db  *sql.DB
+	mx  *sync.Mutex
+	log *slog.Logger
+}
+
+// NewAPIKeyDAC creates new api key DAC
+func NewAPIKeyDAC(db *sql.DB, logger *slog.Logger) *APIKeyDAC {
+	return &APIKeyDAC{
+		db:  db,
+		mx:  &sync.Mutex{},
+		log: logger,
+	}
+}
+
+func scanAPIKey(rows *sql.Rows) (*model.APIKey, error) {
+	keyItem := &model.APIKey{}
+	var scopes string
+	err := rows.Scan(
+		&keyItem.ID,
+		&keyItem.Name,
+		&keyItem.Prefix,
+		&keyItem.Hash,
+		&scopes,
+		&keyItem.ExpiresAt,
+		&keyItem.CreatedAt,
+		&keyItem.LastUsedAt,
+		&keyItem.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	keyItem.Scopes = strings.Fields(scopes)
+	return keyItem, nil
+}
+
+// GetList returns list of all api keys
+func (dac *APIKeyDAC) GetList(ctx context.Context) (_ []*model.APIKey, err error) {
+	ctx, end := startQuery(ctx, dac.log, "apikey.list")
+	defer end(&err)
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT id, name, prefix, hash, scopes, expiresat, createdat, lastusedat, revokedat
+			FROM apikey`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keyList := make([]*model.APIKey, 0)
+
+	for rows.Next() {
+		keyItem, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keyList = append(keyList, keyItem)
+	}
+
+	return keyList, rows.Err()
+}
+
+// GetByHash returns api key by its hash
+func (dac *APIKeyDAC) GetByHash(ctx context.Context, hash string) (_ *model.APIKey, err error) {
+	ctx, end := startQuery(ctx, dac.log, "apikey.get")
+	defer end(&err)
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT id, name, prefix, hash, scopes, expiresat, createdat, lastusedat, revokedat
+			FROM apikey
+			WHERE
+				hash = ?`,
+		hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, model.ErrNotFound
+	}
+
+	return scanAPIKey(rows)
+}
+
+// CreateItem creates new api key
+func (dac *APIKeyDAC) CreateItem(ctx context.Context, key *model.APIKey) (_ int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "apikey.create")
+	defer end(&err)
+	dac.mx.Lock()
+	defer dac.mx.Unlock()
+	res, err := dac.db.ExecContext(ctx,
+		`INSERT
+			INTO apikey (name, prefix, hash, scopes, expiresat, createdat)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		strings.Join(key.Scopes, " "),
+		key.ExpiresAt,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return lastInsertID(res)
+}
+
+// RevokeItem marks api key as revoked
+func (dac *APIKeyDAC) RevokeItem(ctx context.Context, id int) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "apikey.revoke", "id", id)
+	defer end(&err)
+	dac.mx.Lock()
+	res, err := dac.db.ExecContext(ctx,
+		`UPDATE apikey
+			SET
+				revokedat=?
+			WHERE
+				id=? AND revokedat IS NULL`,
+		time.Now(),
+		id,
+	)
+	dac.mx.Unlock()
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+// UpdateLastUsed stores last usage time of api key
+func (dac *APIKeyDAC) UpdateLastUsed(ctx context.Context, id int, t time.Time) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "apikey.touch", "id", id)
+	defer end(&err)
+	res, err := dac.db.ExecContext(ctx,
+		`UPDATE apikey
+			SET
+				lastusedat=?
+			WHERE
+				id=?`,
+		t,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// AuditDAC is an append-only audit table data access class.
+// Records are appended by DACs of audited items in transactions of the changes
+type AuditDAC struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+// NewAuditDAC creates new audit DAC
+func NewAuditDAC(db *sql.DB, logger *slog.Logger) *AuditDAC {
+	return &AuditDAC{
+		db:  db,
+		log: logger,
+	}
+}
+
+// auditKeyset lists audit records by time or ID, by time if sort isn't set
+var auditKeyset = &keyset[model.AuditRecord]{
+	def: "time",
+	columns: map[string]sortColumn[model.AuditRecord]{
+		"ID":   intColumn("id", func(r *model.AuditRecord) int { return r.ID }),
+		"time": timeColumn("changedat", func(r *model.AuditRecord) time.Time { return r.Time }),
+	},
+	id: func(r *model.AuditRecord) int { return r.ID },
+}
+
+// nullJSON stores missing snapshot as NULL
+func nullJSON(b []byte) any {
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// writeAudit records change in its transaction, so that the change fails if it can't be recorded.
+// Nil record of not audited change isn't written
+func writeAudit(ctx context.Context, tx execer, rec *model.AuditRecord) error {
+	if rec == nil {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT
+			INTO audit (changedat, actor, requestid, entity, entityid, action, beforedata, afterdata)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Time,
+		rec.Actor,
+		rec.RequestID,
+		rec.Entity,
+		rec.EntityID,
+		rec.Action,
+		nullJSON(rec.Before),
+		nullJSON(rec.After),
+	)
+	return err
+}
+
+// GetList returns page of audit records and next page cursor
+func (dac *AuditDAC) GetList(ctx context.Context, q model.AuditQuery) (_ []*model.AuditRecord, _ string, err error) {
+	ctx, end := startQuery(ctx, dac.log, "audit.list")
+	defer end(&err)
+
+	var f filter
+	if q.Entity != "" {
+		f.add("entity = ?", q.Entity)
+	}
+	if q.EntityID != 0 {
+		f.add("entityid = ?", q.EntityID)
+	}
+	if q.Actor != "" {
+		f.add("actor = ?", q.Actor)
+	}
+	if q.From != nil {
+		f.add("changedat >= ?", *q.From)
+	}
+	if q.To != nil {
+		f.add("changedat <= ?", *q.To)
+	}
+
+	stmt, args, err := auditKeyset.query(
+		`SELECT id, changedat, actor, requestid, entity, entityid, action, beforedata, afterdata
+			FROM audit`,
+		f, q.Sort, q.Page,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := dac.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	recList := make([]*model.AuditRecord, 0)
+
+	for rows.Next() {
+		recItem := &model.AuditRecord{}
+		var before, after []byte
+		err = rows.Scan(
+			&recItem.ID,
+			&recItem.Time,
+			&recItem.Actor,
+			&recItem.RequestID,
+			&recItem.Entity,
+			&recItem.EntityID,
+			&recItem.Action,
+			&before,
+			&after,
+		)
+		if err != nil {
+			return nil, "", err
+		}
+		recItem.Before, recItem.After = before, after
+		recList = append(recList, recItem)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	recList, next := auditKeyset.page(recList, q.Sort, q.Page)
+	return recList, next, nil
+}
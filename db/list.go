@@ -0,0 +1,177 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// sortColumn maps sort field to table column
+type sortColumn[T any] struct {
+	column string
+	parse  func(string) (any, error)
+	value  func(*T) string
+}
+
+func intColumn[T any](column string, value func(*T) int) sortColumn[T] {
+	return sortColumn[T]{
+		column: column,
+		parse:  func(s string) (any, error) { return strconv.Atoi(s) },
+		value:  func(item *T) string { return strconv.Itoa(value(item)) },
+	}
+}
+
+func timeColumn[T any](column string, value func(*T) time.Time) sortColumn[T] {
+	return sortColumn[T]{
+		column: column,
+		parse:  func(s string) (any, error) { return time.Parse(time.RFC3339Nano, s) },
+		value:  func(item *T) string { return value(item).Format(time.RFC3339Nano) },
+	}
+}
+
+func stringColumn[T any](column string, value func(*T) string) sortColumn[T] {
+	return sortColumn[T]{
+		column: column,
+		parse:  func(s string) (any, error) { return s, nil },
+		value:  value,
+	}
+}
+
+// cursor points after the last item of a page.
+// It's bound to the sort order it was issued for
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// likeEscaper escapes LIKE pattern wildcards
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filter is a set of SQL conditions joined with AND
+type filter struct {
+	where []string
+	args  []any
+}
+
+func (f *filter) add(cond string, args ...any) {
+	f.where = append(f.where, cond)
+	f.args = append(f.args, args...)
+}
+
+// sql appends WHERE clause of the conditions to select statement
+func (f *filter) sql(selectSQL string) string {
+	if len(f.where) == 0 {
+		return selectSQL
+	}
+	return selectSQL + "\n\t\t\tWHERE " + strings.Join(f.where, " AND ")
+}
+
+// inList returns placeholders of IN condition and its args
+func inList(ids []int) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return "(" + strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",") + ")", args
+}
+
+// keyset builds keyset paginated list queries of table rows.
+// Rows are ordered by sort column and then by id
+type keyset[T any] struct {
+	def     string
+	columns map[string]sortColumn[T]
+	id      func(*T) int
+}
+
+func (k *keyset[T]) sortKey(s model.Sort) string {
+	field := s.Field
+	if field == "" {
+		field = k.def
+	}
+	if s.Desc {
+		return "-" + field
+	}
+	return field
+}
+
+// query returns SELECT statement with filter, page condition, order and limit.
+// One extra row is requested to find out if there is a next page
+func (k *keyset[T]) query(selectSQL string, f filter, s model.Sort, p model.Page) (string, []any, error) {
+	field := strings.TrimPrefix(k.sortKey(s), "-")
+	col, ok := k.columns[field]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: unknown sort field %q", model.ErrInvalidQuery, field)
+	}
+
+	dir, op := "ASC", ">"
+	if s.Desc {
+		dir, op = "DESC", "<"
+	}
+
+	if p.Cursor != "" {
+		c, err := decodeCursor(p.Cursor)
+		if err != nil || c.Sort != k.sortKey(s) {
+			return "", nil, fmt.Errorf("%w: malformed cursor", model.ErrInvalidQuery)
+		}
+		v, err := col.parse(c.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: malformed cursor", model.ErrInvalidQuery)
+		}
+		f.add(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", col.column, op), v, v, c.ID)
+	}
+
+	stmt := f.sql(selectSQL)
+	stmt += fmt.Sprintf("\n\t\t\tORDER BY %s %s, id %s\n\t\t\tLIMIT ?", col.column, dir, dir)
+
+	return stmt, append(f.args, pageLimit(p)+1), nil
+}
+
+// page cuts extra row off and returns next page cursor, empty on the last page
+func (k *keyset[T]) page(items []*T, s model.Sort, p model.Page) ([]*T, string) {
+	limit := pageLimit(p)
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	last := items[limit-1]
+
+	key := k.sortKey(s)
+	col := k.columns[strings.TrimPrefix(key, "-")]
+	return items, encodeCursor(cursor{
+		Sort:  key,
+		Value: col.value(last),
+		ID:    k.id(last),
+	})
+}
+
+// pageLimit returns page size within model limits
+func pageLimit(p model.Page) int {
+	switch {
+	case p.Limit <= 0:
+		return model.DefaultLimit
+	case p.Limit > model.MaxLimit:
+		return model.MaxLimit
+	}
+	return p.Limit
+}
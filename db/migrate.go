@@ -0,0 +1,126 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// schemaChange is a change made to schema.sql after the first release.
+// Databases created with an older schema.sql get it from Migrate
+type schemaChange struct {
+	// name of the change in logs and schema check errors
+	name string
+	// check counts schema objects created by the change
+	check string
+	args  []any
+	// stmt applies the change
+	stmt string
+}
+
+// addColumn returns change adding column to table
+func addColumn(table, column, def string) schemaChange {
+	return schemaChange{
+		name: fmt.Sprintf("column %s.%s", table, column),
+		check: `SELECT COUNT(*)
+			FROM information_schema.columns
+			WHERE
+				table_schema = DATABASE()
+				AND table_name = ?
+				AND column_name = ?`,
+		args: []any{table, column},
+		stmt: fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, column, def),
+	}
+}
+
+// addIndex returns change adding index to table
+func addIndex(table, index, def string) schemaChange {
+	return schemaChange{
+		name: fmt.Sprintf("index %s.%s", table, index),
+		check: `SELECT COUNT(*)
+			FROM information_schema.statistics
+			WHERE
+				table_schema = DATABASE()
+				AND table_name = ?
+				AND index_name = ?`,
+		args: []any{table, index},
+		stmt: fmt.Sprintf("ALTER TABLE `%s` ADD %s", table, def),
+	}
+}
+
+// createTable returns change creating table
+func createTable(table, def string) schemaChange {
+	return schemaChange{
+		name: "table " + table,
+		check: `SELECT COUNT(*)
+			FROM information_schema.tables
+			WHERE
+				table_schema = DATABASE()
+				AND table_name = ?`,
+		args: []any{table},
+		stmt: fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (%s) ENGINE=InnoDB DEFAULT CHARSET=utf8", table, def),
+	}
+}
+
+// schemaChanges lists changes of schema.sql in order they were made.
+// A change of schema.sql must be added here too
+var schemaChanges = []schemaChange{
+	addColumn("company", "version", "int(11) NOT NULL DEFAULT 1"),
+	addColumn("contract", "version", "int(11) NOT NULL DEFAULT 1"),
+	addIndex("company", "company_name_IX", "KEY `company_name_IX` (`name`, `id`)"),
+	addIndex("company", "company_search_FT", "FULLTEXT KEY `company_search_FT` (`name`, `regcode`) WITH PARSER ngram"),
+	addIndex("purchase", "purchase_contract_date_IX", "KEY `purchase_contract_date_IX` (`contractid`, `purchasedatetime`, `id`)"),
+	createTable("apikey", "`id` int(11) NOT NULL AUTO_INCREMENT,"+
+		"`name` varchar(255) NOT NULL,"+
+		"`prefix` varchar(16) NOT NULL,"+
+		"`hash` char(64) NOT NULL,"+
+		"`scopes` varchar(255) NOT NULL,"+
+		"`expiresat` datetime DEFAULT NULL,"+
+		"`createdat` datetime NOT NULL,"+
+		"`lastusedat` datetime DEFAULT NULL,"+
+		"`revokedat` datetime DEFAULT NULL,"+
+		"PRIMARY KEY (`id`),"+
+		"UNIQUE KEY `apikey_hash_UN` (`hash`)"),
+	createTable("audit", "`id` int(11) NOT NULL AUTO_INCREMENT,"+
+		"`changedat` datetime(6) NOT NULL,"+
+		"`actor` varchar(255) NOT NULL,"+
+		"`requestid` varchar(64) NOT NULL,"+
+		"`entity` varchar(16) NOT NULL,"+
+		"`entityid` int(11) NOT NULL,"+
+		"`action` varchar(16) NOT NULL,"+
+		"`beforedata` json DEFAULT NULL,"+
+		"`afterdata` json DEFAULT NULL,"+
+		"PRIMARY KEY (`id`),"+
+		"KEY `audit_entity_IX` (`entity`, `entityid`, `changedat`, `id`),"+
+		"KEY `audit_actor_IX` (`actor`, `changedat`, `id`)"),
+	addColumn("company", "deletedat", "datetime DEFAULT NULL"),
+	addColumn("contract", "deletedat", "datetime DEFAULT NULL"),
+}
+
+// applied checks whether change exists in DB
+func (c schemaChange) applied(db *sql.DB) (bool, error) {
+	var count int
+	if err := db.QueryRow(c.check, c.args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("check %s: %w", c.name, err)
+	}
+	return count > 0, nil
+}
+
+// Migrate brings DB created with an older schema.sql up to date and returns names of applied changes.
+// It applies only changes missing in DB, so it can be run any number of times.
+// MySQL commits schema changes implicitly, so changes applied before an error stay applied
+func Migrate(db *sql.DB) (applied []string, err error) {
+	for _, c := range schemaChanges {
+		ok, err := c.applied(db)
+		if err != nil {
+			return applied, err
+		}
+		if ok {
+			continue
+		}
+		if _, err = db.Exec(c.stmt); err != nil {
+			return applied, fmt.Errorf("apply %s: %w", c.name, err)
+		}
+		applied = append(applied, c.name)
+	}
+	return applied, nil
+}
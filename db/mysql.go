@@ -1,52 +1,315 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
 	"sync"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql" //use MySQL driver
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/ilyakaznacheev/gontracts/model"
 )
 
-func readIndex(db *sql.DB) (int, error) {
-	rows, err := db.Query(
-		`SELECT LAST_INSERT_ID()`,
+// tracerName is an instrumentation name of DB spans
+const tracerName = "github.com/ilyakaznacheev/gontracts/db"
+
+// startQuery starts a span of DB operation. Returned func ends the span,
+// converts driver errors to model errors and logs operation with its duration.
+// It is called deferred, so err points to the named error result of the operation
+func startQuery(ctx context.Context, logger *slog.Logger, op string, args ...any) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(queryAttributes(op, args)...),
 	)
+
+	return ctx, func(err *error) {
+		*err = storageError(*err)
+		args = append([]any{"op", op, "duration", time.Since(start)}, args...)
+		defer span.End()
+		if *err != nil && !expectedError(*err) {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+			logger.Error("query failed", append(args, "error", *err)...)
+			return
+		}
+		logger.Debug("query", args...)
+	}
+}
+
+// expectedError checks is error a regular result of the operation rather than its failure
+func expectedError(err error) bool {
+	return errors.Is(err, model.ErrNotFound) ||
+		errors.Is(err, model.ErrInvalidQuery) ||
+		errors.Is(err, model.ErrVersionMismatch) ||
+		errors.Is(err, model.ErrReferenced) ||
+		errors.Is(err, model.ErrSellerDeleted) ||
+		errors.Is(err, model.ErrClientDeleted) ||
+		errors.Is(err, model.ErrContractDeleted)
+}
+
+// queryAttributes converts operation and its log args to span attributes
+func queryAttributes(op string, args []any) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "mysql"),
+		attribute.String("db.operation", op),
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		key := "gontracts." + fmt.Sprint(args[i])
+		if v, ok := args[i+1].(int); ok {
+			attrs = append(attrs, attribute.Int(key, v))
+			continue
+		}
+		attrs = append(attrs, attribute.String(key, fmt.Sprint(args[i+1])))
+	}
+	return attrs
+}
+
+// storageError converts driver error to model error.
+// Connection failures are reported as model.ErrUnavailable
+func storageError(err error) error {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return model.ErrNotFound
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return fmt.Errorf("%w: %w", model.ErrUnavailable, err)
+	}
+	return err
+}
+
+// checkAffected returns model.ErrNotFound if statement didn't match any row
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
 	if err != nil {
-		return 0, err
+		return err
+	}
+	if n == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// Conditions of soft deletion state
+const (
+	notDeleted  = "deletedat IS NULL"
+	softDeleted = "deletedat IS NOT NULL"
+)
+
+// execer runs statements on DB or within transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// setDeleted marks locked item as deleted at the time, or restores it if the time is nil.
+// Version isn't changed, so that deleted item is restored with the version it had
+func setDeleted(ctx context.Context, tx *sql.Tx, table string, id int, at *time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE `+table+`
+			SET
+				deletedat=?
+			WHERE
+				id=?`,
+		at,
+		id,
+	)
+	return err
+}
+
+// checkLocked checks result of reading item locked until the end of transaction.
+// Missing item returns model.ErrNotFound, item of other than expected version model.ErrVersionMismatch
+func checkLocked(err error, current, version int) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != current {
+		return model.ErrVersionMismatch
+	}
+	return nil
+}
+
+// lockLive locks existing table item for share until the end of transaction,
+// so that it can't be deleted before the referencing change commits. Missing or deleted item returns deleted error
+func lockLive(ctx context.Context, tx *sql.Tx, table string, id int, deleted error) error {
+	var n int
+	err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*)
+			FROM `+table+`
+			WHERE
+				id=? AND `+notDeleted+`
+			FOR SHARE`,
+		id,
+	).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return deleted
+	}
+	return nil
+}
+
+// lockCompanies locks seller and client companies of contract for share.
+// Companies are locked before the contract, in the same order as company deletion does
+func lockCompanies(ctx context.Context, tx *sql.Tx, c *model.Contract) error {
+	if err := lockLive(ctx, tx, "company", c.SellerID, model.ErrSellerDeleted); err != nil {
+		return err
+	}
+	return lockLive(ctx, tx, "company", c.ClientID, model.ErrClientDeleted)
+}
+
+// lastInsertID returns id of the row inserted by the statement
+func lastInsertID(res sql.Result) (int, error) {
+	idx, err := res.LastInsertId()
+	return int(idx), err
+}
+
+// insertAll executes insert query with args of every item in a single transaction
+// and returns ids of inserted rows in the same order. Created items are recorded in audit trail as entity.
+// Optional lock locks items referenced by the item within the transaction before it is inserted
+func insertAll[T any](ctx context.Context, db *sql.DB, entity, query string, items []*T, args func(*T) []any, setID func(*T, int),
+	lock func(context.Context, *sql.Tx, *T) error) (_ []int, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		if lock != nil {
+			if err := lock(ctx, tx, item); err != nil {
+				return nil, err
+			}
+		}
+		res, err := stmt.ExecContext(ctx, args(item)...)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := lastInsertID(res)
+		if err != nil {
+			return nil, err
+		}
+		created := *item
+		setID(&created, idx)
+		err = writeAudit(ctx, tx, model.NewAuditRecord(ctx, entity, idx, model.ActionCreate, nil, &created))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, idx)
+	}
+	return ids, tx.Commit()
+}
+
+// exportRows calls fn for every row of the query as soon as it is read from the DB cursor
+func exportRows[T any](ctx context.Context, db *sql.DB, stmt string, args []any, scan func(*sql.Rows, *T) error, fn func(*T) error) error {
+	rows, err := db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
-	rows.Next()
-	var idx int
-	err = rows.Scan(&idx)
+	for rows.Next() {
+		item := new(T)
+		if err = scan(rows, item); err != nil {
+			return err
+		}
+		if err = fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
 
-	return idx, err
+// activeFilter selects contracts valid at some moment of the export window
+func activeFilter(q model.ExportQuery) filter {
+	var f filter
+	if q.From != nil {
+		f.add("validto >= ?", *q.From)
+	}
+	if q.To != nil {
+		f.add("validfrom <= ?", *q.To)
+	}
+	return f
 }
 
 // CompanyDAC is a company table data access class
 type CompanyDAC struct {
-	db *sql.DB
-	mx *sync.Mutex
+	db  *sql.DB
+	mx  *sync.Mutex
+	log *slog.Logger
 }
 
 // NewCompanyDAC creates new company DAC
-func NewCompanyDAC(db *sql.DB) *CompanyDAC {
+func NewCompanyDAC(db *sql.DB, logger *slog.Logger) *CompanyDAC {
 	return &CompanyDAC{
-		db: db,
-		mx: &sync.Mutex{},
+		db:  db,
+		mx:  &sync.Mutex{},
+		log: logger,
 	}
 }
 
-// GetList returns list of all companies
-func (dac *CompanyDAC) GetList() ([]*model.Company, error) {
-	rows, err := dac.db.Query(
-		`SELECT id, name, regcode
+// companyKeyset lists companies by ID or name
+var companyKeyset = &keyset[model.Company]{
+	def: "ID",
+	columns: map[string]sortColumn[model.Company]{
+		"ID":   intColumn("id", func(c *model.Company) int { return c.ID }),
+		"name": stringColumn("name", func(c *model.Company) string { return c.Name }),
+	},
+	id: func(c *model.Company) int { return c.ID },
+}
+
+// GetList returns page of companies and next page cursor
+func (dac *CompanyDAC) GetList(ctx context.Context, q model.CompanyQuery) (_ []*model.Company, _ string, err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.list")
+	defer end(&err)
+
+	var f filter
+	if !q.IncludeDeleted {
+		f.add(notDeleted)
+	}
+
+	stmt, args, err := companyKeyset.query(
+		`SELECT id, name, regcode, deletedat
 			FROM company`,
+		f, q.Sort, q.Page,
 	)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	rows, err := dac.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, "", err
 	}
+	defer rows.Close()
 	compList := make([]*model.Company, 0)
 
 	for rows.Next() {
@@ -55,45 +318,92 @@ func (dac *CompanyDAC) GetList() ([]*model.Company, error) {
 			&compItem.ID,
 			&compItem.Name,
 			&compItem.RegCode,
+			&compItem.DeletedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		compList = append(compList, compItem)
 	}
-	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
 
-	return compList, nil
+	compList, next := companyKeyset.page(compList, q.Sort, q.Page)
+	return compList, next, nil
 }
 
 // GetItem returns company by id
-func (dac *CompanyDAC) GetItem(id int) (*model.Company, error) {
-	rows, err := dac.db.Query(
-		`SELECT id, name, regcode
+func (dac *CompanyDAC) GetItem(ctx context.Context, id int) (_ *model.Company, err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.get", "id", id)
+	defer end(&err)
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT id, name, regcode, version
 			FROM company
 			WHERE
-				id = ?`,
+				id = ? AND deletedat IS NULL`,
 		id,
 	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, model.ErrNotFound
+	}
 	compItem := &model.Company{}
-	rows.Next()
 	err = rows.Scan(
 		&compItem.ID,
 		&compItem.Name,
 		&compItem.RegCode,
+		&compItem.Version,
 	)
 
 	return compItem, err
 }
 
+// lockCompany locks company in the deletion state until the end of transaction and checks its version
+func lockCompany(ctx context.Context, tx *sql.Tx, id, version int, state string) (*model.Company, error) {
+	compItem := &model.Company{}
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, name, regcode, version
+			FROM company
+			WHERE
+				id=? AND `+state+`
+			FOR UPDATE`,
+		id,
+	).Scan(
+		&compItem.ID,
+		&compItem.Name,
+		&compItem.RegCode,
+		&compItem.Version,
+	)
+	if err = checkLocked(err, compItem.Version, version); err != nil {
+		return nil, err
+	}
+	return compItem, nil
+}
+
 // CreateItem creates new company
-func (dac *CompanyDAC) CreateItem(company *model.Company) (int, error) {
+func (dac *CompanyDAC) CreateItem(ctx context.Context, company *model.Company) (_ int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.create")
+	defer end(&err)
 	dac.mx.Lock()
 	defer dac.mx.Unlock()
-	_, err := dac.db.Exec(
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx,
 		`INSERT 
 			INTO company (name, regcode) 
 			VALUES (?, ?)`,
@@ -103,86 +413,395 @@ func (dac *CompanyDAC) CreateItem(company *model.Company) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	idx, err := lastInsertID(res)
+	if err != nil {
+		return 0, err
+	}
+	created := *company
+	created.ID = idx
+	err = writeAudit(ctx, tx, model.NewAuditRecord(ctx, model.EntityCompany, idx, model.ActionCreate, nil, &created))
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	// new items start with default version
+	company.Version = 1
+	return idx, nil
+}
+
+// CreateItems creates companies in a single transaction
+func (dac *CompanyDAC) CreateItems(ctx context.Context, companies []*model.Company) (_ []int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.create_all", "count", len(companies))
+	defer end(&err)
+	dac.mx.Lock()
+	defer dac.mx.Unlock()
+	ids, err := insertAll(ctx, dac.db, model.EntityCompany,
+		`INSERT 
+			INTO company (name, regcode) 
+			VALUES (?, ?)`,
+		companies,
+		func(c *model.Company) []any { return []any{c.Name, c.RegCode} },
+		func(c *model.Company, id int) { c.ID = id },
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	return readIndex(dac.db)
+	// new items start with default version
+	for _, c := range companies {
+		c.Version = 1
+	}
+	return ids, nil
 }
 
-// UpdateItem updates company
-func (dac *CompanyDAC) UpdateItem(company *model.Company) error {
+// UpdateItem updates company of expected version and sets its new version
+func (dac *CompanyDAC) UpdateItem(ctx context.Context, company *model.Company) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.update", "id", company.ID, "version", company.Version)
+	defer end(&err)
 	dac.mx.Lock()
-	_, err := dac.db.Exec(
+	defer dac.mx.Unlock()
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	before, err := lockCompany(ctx, tx, company.ID, company.Version, notDeleted)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
 		`UPDATE company
 			SET
 				name=?,
-				regcode=?
+				regcode=?,
+				version=version+1
 			WHERE
 				id=?`,
 		company.Name,
 		company.RegCode,
 		company.ID,
 	)
-	dac.mx.Unlock()
-	return err
+	if err != nil {
+		return err
+	}
+	err = writeAudit(ctx, tx, model.NewAuditRecord(ctx, model.EntityCompany, company.ID, model.ActionUpdate, before, company))
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	company.Version = before.Version + 1
+	return nil
 }
 
-// DeleteItem removes company
-func (dac *CompanyDAC) DeleteItem(id int) error {
+// DeleteItem soft deletes company of expected version.
+// Company with contracts isn't deleted unless cascade deletes its contracts in the same transaction
+func (dac *CompanyDAC) DeleteItem(ctx context.Context, id, version int, cascade bool) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.delete", "id", id, "version", version, "cascade", cascade)
+	defer end(&err)
 	dac.mx.Lock()
-	_, err := dac.db.Exec(
-		`DELETE FROM company
-			WHERE
-				id=?`,
-		id,
-	)
-	dac.mx.Unlock()
-	return err
+	defer dac.mx.Unlock()
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	before, err := lockCompany(ctx, tx, id, version, notDeleted)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err = setDeleted(ctx, tx, "company", id, &now); err != nil {
+		return err
+	}
+
+	var contracts []*model.Contract
+	if cascade {
+		contracts, err = lockCompanyContracts(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE contract
+				SET
+					deletedat=?
+				WHERE
+					(sellerid=? OR clientid=?) AND deletedat IS NULL`,
+			now,
+			id,
+			id,
+		)
+	} else {
+		var referenced bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT EXISTS(
+				SELECT 1
+					FROM contract
+					WHERE (sellerid=? OR clientid=?) AND deletedat IS NULL
+			)`,
+			id,
+			id,
+		).Scan(&referenced)
+		if err == nil && referenced {
+			err = model.ErrReferenced
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// cascade deletion of every contract is recorded as well
+	err = writeAudit(ctx, tx, model.NewAuditRecord[model.Company](ctx, model.EntityCompany, id, model.ActionDelete, before, nil))
+	if err != nil {
+		return err
+	}
+	for _, c := range contracts {
+		err = writeAudit(ctx, tx, model.NewAuditRecord[model.Contract](ctx, model.EntityContract, c.ID, model.ActionDelete, c, nil))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RestoreItem restores soft deleted company of expected version
+func (dac *CompanyDAC) RestoreItem(ctx context.Context, id, version int) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.restore", "id", id, "version", version)
+	defer end(&err)
+	dac.mx.Lock()
+	defer dac.mx.Unlock()
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	restored, err := lockCompany(ctx, tx, id, version, softDeleted)
+	if err != nil {
+		return err
+	}
+	if err = setDeleted(ctx, tx, "company", id, nil); err != nil {
+		return err
+	}
+	err = writeAudit(ctx, tx, model.NewAuditRecord(ctx, model.EntityCompany, id, model.ActionRestore, nil, restored))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // CheckExist checks are company with id exists
-func (dac *CompanyDAC) CheckExist(id int) bool {
-	rows, err := dac.db.Query(
+func (dac *CompanyDAC) CheckExist(ctx context.Context, id int) (_ bool, err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.exist", "id", id)
+	defer end(&err)
+	var exist bool
+	err = dac.db.QueryRowContext(ctx,
 		`SELECT EXISTS(
 			SELECT 1 
 				FROM company 
-				WHERE id=?
+				WHERE id=? AND deletedat IS NULL
 		)`,
 		id,
+	).Scan(&exist)
+	return exist, err
+}
+
+// Search returns companies matching the query by name or registration code.
+// Full-text ngram relevance is boosted by plain substring matches
+func (dac *CompanyDAC) Search(ctx context.Context, q model.CompanySearch) (_ []*model.CompanyMatch, err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.search", "query", q.Query)
+	defer end(&err)
+
+	like := "%" + likeEscaper.Replace(q.Query) + "%"
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT id, name, regcode,
+				MATCH(name, regcode) AGAINST (? IN NATURAL LANGUAGE MODE)
+					+ (name LIKE ?) + COALESCE(regcode LIKE ?, 0) AS score
+			FROM company
+			WHERE
+				deletedat IS NULL AND (
+					MATCH(name, regcode) AGAINST (? IN NATURAL LANGUAGE MODE)
+					OR name LIKE ?
+					OR regcode LIKE ?
+				)
+			ORDER BY score DESC, id
+			LIMIT ?`,
+		q.Query, like, like,
+		q.Query, like, like,
+		pageLimit(model.Page{Limit: q.Limit}),
 	)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	var exist bool
-	rows.Next()
-	err = rows.Scan(&exist)
+	defer rows.Close()
+
+	res := make([]*model.CompanyMatch, 0)
+	for rows.Next() {
+		m := &model.CompanyMatch{}
+		err = rows.Scan(
+			&m.ID,
+			&m.Name,
+			&m.RegCode,
+			&m.Score,
+		)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+// GetItems returns existing companies of ids ordered by id
+func (dac *CompanyDAC) GetItems(ctx context.Context, ids []int) (_ []*model.Company, err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.by_ids", "count", len(ids))
+	defer end(&err)
+	compList := make([]*model.Company, 0, len(ids))
+	if len(ids) == 0 {
+		return compList, nil
+	}
+	in, args := inList(ids)
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT id, name, regcode, version
+			FROM company
+			WHERE
+				id IN `+in+` AND deletedat IS NULL
+			ORDER BY id`,
+		args...,
+	)
 	if err != nil {
-		return false
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		compItem := &model.Company{}
+		err = rows.Scan(
+			&compItem.ID,
+			&compItem.Name,
+			&compItem.RegCode,
+			&compItem.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		compList = append(compList, compItem)
+	}
+	return compList, rows.Err()
+}
+
+// Export calls fn for every company matching the query ordered by id
+func (dac *CompanyDAC) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Company) error) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "company.export", "company", q.CompanyID)
+	defer end(&err)
+
+	var f filter
+	f.add(notDeleted)
+	if q.CompanyID != 0 {
+		f.add("id = ?", q.CompanyID)
+	}
+	if q.From != nil || q.To != nil {
+		// companies having contracts in the window
+		af := activeFilter(q)
+		af.add(notDeleted)
+		f.add("id IN ("+af.sql(`SELECT sellerid FROM contract`)+" UNION "+af.sql(`SELECT clientid FROM contract`)+")",
+			append(af.args, af.args...)...)
 	}
-	return exist
+
+	return exportRows(ctx, dac.db,
+		f.sql(`SELECT id, name, regcode, version
+			FROM company`)+`
+			ORDER BY id`,
+		f.args,
+		func(rows *sql.Rows, c *model.Company) error {
+			return rows.Scan(&c.ID, &c.Name, &c.RegCode, &c.Version)
+		},
+		fn,
+	)
 }
 
 // ContractDAC is a company table data access class
 type ContractDAC struct {
-	db *sql.DB
-	mx *sync.Mutex
+	db  *sql.DB
+	mx  *sync.Mutex
+	log *slog.Logger
 }
 
 // NewContractDAC creates new company DAC
-func NewContractDAC(db *sql.DB) *ContractDAC {
+func NewContractDAC(db *sql.DB, logger *slog.Logger) *ContractDAC {
 	return &ContractDAC{
-		db: db,
-		mx: &sync.Mutex{},
+		db:  db,
+		mx:  &sync.Mutex{},
+		log: logger,
 	}
 }
 
-// GetList returns list of all contracts
-func (dac *ContractDAC) GetList() ([]*model.Contract, error) {
-	rows, err := dac.db.Query(
-		`SELECT id, clientid, sellerid, validfrom, validto, creditamount
+// contractKeyset lists contracts by ID, validity dates or credit amount
+var contractKeyset = &keyset[model.Contract]{
+	def: "ID",
+	columns: map[string]sortColumn[model.Contract]{
+		"ID":        intColumn("id", func(c *model.Contract) int { return c.ID }),
+		"validFrom": timeColumn("validfrom", func(c *model.Contract) time.Time { return c.ValidFrom }),
+		"validTo":   timeColumn("validto", func(c *model.Contract) time.Time { return c.ValidTo }),
+		"amount":    intColumn("creditamount", func(c *model.Contract) int { return c.CreditAmount }),
+	},
+	id: func(c *model.Contract) int { return c.ID },
+}
+
+// GetList returns page of contracts and next page cursor
+func (dac *ContractDAC) GetList(ctx context.Context, q model.ContractQuery) (_ []*model.Contract, _ string, err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.list")
+	defer end(&err)
+
+	var f filter
+	if !q.IncludeDeleted {
+		f.add(notDeleted)
+	}
+	if q.SellerID != 0 {
+		f.add("sellerid = ?", q.SellerID)
+	}
+	if q.ClientID != 0 {
+		f.add("clientid = ?", q.ClientID)
+	}
+	if q.ActiveFrom != nil {
+		f.add("validto >= ?", *q.ActiveFrom)
+	}
+	if q.ActiveTo != nil {
+		f.add("validfrom <= ?", *q.ActiveTo)
+	}
+
+	stmt, args, err := contractKeyset.query(
+		`SELECT id, clientid, sellerid, validfrom, validto, creditamount, deletedat
 			FROM contract`,
+		f, q.Sort, q.Page,
 	)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	rows, err := dac.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, "", err
 	}
+	defer rows.Close()
 	contrList := make([]*model.Contract, 0)
 
 	for rows.Next() {
@@ -194,31 +813,43 @@ func (dac *ContractDAC) GetList() ([]*model.Contract, error) {
 			&contrItem.ValidFrom,
 			&contrItem.ValidTo,
 			&contrItem.CreditAmount,
+			&contrItem.DeletedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		contrList = append(contrList, contrItem)
 	}
-	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
 
-	return contrList, nil
+	contrList, next := contractKeyset.page(contrList, q.Sort, q.Page)
+	return contrList, next, nil
 }
 
 // GetItem returns contract by id
-func (dac *ContractDAC) GetItem(id int) (*model.Contract, error) {
-	rows, err := dac.db.Query(
-		`SELECT id, clientid, sellerid, validfrom, validto, creditamount
+func (dac *ContractDAC) GetItem(ctx context.Context, id int) (_ *model.Contract, err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.get", "id", id)
+	defer end(&err)
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT id, clientid, sellerid, validfrom, validto, creditamount, version
 			FROM contract
 			WHERE
-				id = ?`,
+				id = ? AND deletedat IS NULL`,
 		id,
 	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, model.ErrNotFound
+	}
 	contrItem := &model.Contract{}
-	rows.Next()
 	err = rows.Scan(
 		&contrItem.ID,
 		&contrItem.ClientID,
@@ -226,16 +857,94 @@ func (dac *ContractDAC) GetItem(id int) (*model.Contract, error) {
 		&contrItem.ValidFrom,
 		&contrItem.ValidTo,
 		&contrItem.CreditAmount,
+		&contrItem.Version,
 	)
 
 	return contrItem, err
 }
 
-// CreateItem creates new contract
-func (dac *ContractDAC) CreateItem(contract *model.Contract) (int, error) {
-	dac.mx.Lock()
-	defer dac.mx.Unlock()
-	_, err := dac.db.Exec(
+// lockContract locks contract in the deletion state until the end of transaction and checks its version
+func lockContract(ctx context.Context, tx *sql.Tx, id, version int, state string) (*model.Contract, error) {
+	contrItem := &model.Contract{}
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, clientid, sellerid, validfrom, validto, creditamount, version
+			FROM contract
+			WHERE
+				id=? AND `+state+`
+			FOR UPDATE`,
+		id,
+	).Scan(
+		&contrItem.ID,
+		&contrItem.ClientID,
+		&contrItem.SellerID,
+		&contrItem.ValidFrom,
+		&contrItem.ValidTo,
+		&contrItem.CreditAmount,
+		&contrItem.Version,
+	)
+	if err = checkLocked(err, contrItem.Version, version); err != nil {
+		return nil, err
+	}
+	return contrItem, nil
+}
+
+// lockCompanyContracts locks existing contracts of company as seller or client until the end of transaction
+func lockCompanyContracts(ctx context.Context, tx *sql.Tx, companyID int) ([]*model.Contract, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, clientid, sellerid, validfrom, validto, creditamount, version
+			FROM contract
+			WHERE
+				(sellerid=? OR clientid=?) AND deletedat IS NULL
+			ORDER BY id
+			FOR UPDATE`,
+		companyID,
+		companyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contrList := make([]*model.Contract, 0)
+	for rows.Next() {
+		contrItem := &model.Contract{}
+		err = rows.Scan(
+			&contrItem.ID,
+			&contrItem.ClientID,
+			&contrItem.SellerID,
+			&contrItem.ValidFrom,
+			&contrItem.ValidTo,
+			&contrItem.CreditAmount,
+			&contrItem.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		contrList = append(contrList, contrItem)
+	}
+	return contrList, rows.Err()
+}
+
+// CreateItem creates new contract
+func (dac *ContractDAC) CreateItem(ctx context.Context, contract *model.Contract) (_ int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.create")
+	defer end(&err)
+	dac.mx.Lock()
+	defer dac.mx.Unlock()
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = lockCompanies(ctx, tx, contract); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx,
 		`INSERT 
 			INTO contract (clientid, sellerid, validfrom, validto, creditamount) 
 			VALUES (?, ?, ?, ?, ?)`,
@@ -248,21 +957,85 @@ func (dac *ContractDAC) CreateItem(contract *model.Contract) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	idx, err := lastInsertID(res)
+	if err != nil {
+		return 0, err
+	}
+	created := *contract
+	created.ID = idx
+	err = writeAudit(ctx, tx, model.NewAuditRecord(ctx, model.EntityContract, idx, model.ActionCreate, nil, &created))
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	// new items start with default version
+	contract.Version = 1
+	return idx, nil
+}
+
+// CreateItems creates contracts in a single transaction
+func (dac *ContractDAC) CreateItems(ctx context.Context, contracts []*model.Contract) (_ []int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.create_all", "count", len(contracts))
+	defer end(&err)
+	dac.mx.Lock()
+	defer dac.mx.Unlock()
+	ids, err := insertAll(ctx, dac.db, model.EntityContract,
+		`INSERT 
+			INTO contract (clientid, sellerid, validfrom, validto, creditamount) 
+			VALUES (?, ?, ?, ?, ?)`,
+		contracts,
+		func(c *model.Contract) []any {
+			return []any{c.ClientID, c.SellerID, c.ValidFrom, c.ValidTo, c.CreditAmount}
+		},
+		func(c *model.Contract, id int) { c.ID = id },
+		lockCompanies,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	return readIndex(dac.db)
+	// new items start with default version
+	for _, c := range contracts {
+		c.Version = 1
+	}
+	return ids, nil
 }
 
-// UpdateItem updates contract
-func (dac *ContractDAC) UpdateItem(contract *model.Contract) error {
+// UpdateItem updates contract of expected version and sets its new version
+func (dac *ContractDAC) UpdateItem(ctx context.Context, contract *model.Contract) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.update", "id", contract.ID, "version", contract.Version)
+	defer end(&err)
 	dac.mx.Lock()
-	_, err := dac.db.Exec(
+	defer dac.mx.Unlock()
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = lockCompanies(ctx, tx, contract); err != nil {
+		return err
+	}
+	before, err := lockContract(ctx, tx, contract.ID, contract.Version, notDeleted)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
 		`UPDATE contract
 			SET
 				clientid=?, 
 				sellerid=?, 
 				validfrom=?, 
 				validto=?, 
-				creditamount=?
+				creditamount=?,
+				version=version+1
 			WHERE
 				id=?`,
 		contract.ClientID,
@@ -272,64 +1045,222 @@ func (dac *ContractDAC) UpdateItem(contract *model.Contract) error {
 		contract.CreditAmount,
 		contract.ID,
 	)
-	dac.mx.Unlock()
-	return err
+	if err != nil {
+		return err
+	}
+	err = writeAudit(ctx, tx, model.NewAuditRecord(ctx, model.EntityContract, contract.ID, model.ActionUpdate, before, contract))
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	contract.Version = before.Version + 1
+	return nil
+}
+
+// DeleteItem soft deletes contract of expected version, its purchases are kept
+func (dac *ContractDAC) DeleteItem(ctx context.Context, id, version int) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.delete", "id", id, "version", version)
+	defer end(&err)
+	dac.mx.Lock()
+	defer dac.mx.Unlock()
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	before, err := lockContract(ctx, tx, id, version, notDeleted)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err = setDeleted(ctx, tx, "contract", id, &now); err != nil {
+		return err
+	}
+	err = writeAudit(ctx, tx, model.NewAuditRecord[model.Contract](ctx, model.EntityContract, id, model.ActionDelete, before, nil))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// DeleteItem removes contract
-func (dac *ContractDAC) DeleteItem(id int) error {
+// RestoreItem restores soft deleted contract of expected version.
+// Seller and client companies are checked in the same transaction, so that they can't be deleted meanwhile
+func (dac *ContractDAC) RestoreItem(ctx context.Context, id, version int) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.restore", "id", id, "version", version)
+	defer end(&err)
 	dac.mx.Lock()
-	_, err := dac.db.Exec(
-		`DELETE FROM contract
+	defer dac.mx.Unlock()
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// companies of deleted contract don't change
+	refs := &model.Contract{}
+	err = tx.QueryRowContext(ctx,
+		`SELECT sellerid, clientid
+			FROM contract
 			WHERE
-				id=?`,
+				id=? AND `+softDeleted,
 		id,
-	)
-	dac.mx.Unlock()
-	return err
+	).Scan(&refs.SellerID, &refs.ClientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err = lockCompanies(ctx, tx, refs); err != nil {
+		return err
+	}
+
+	restored, err := lockContract(ctx, tx, id, version, softDeleted)
+	if err != nil {
+		return err
+	}
+	if err = setDeleted(ctx, tx, "contract", id, nil); err != nil {
+		return err
+	}
+	err = writeAudit(ctx, tx, model.NewAuditRecord(ctx, model.EntityContract, id, model.ActionRestore, nil, restored))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // CheckExist checks are company with id exists
-func (dac *ContractDAC) CheckExist(id int) bool {
-	rows, err := dac.db.Query(
+func (dac *ContractDAC) CheckExist(ctx context.Context, id int) (_ bool, err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.exist", "id", id)
+	defer end(&err)
+	var exist bool
+	err = dac.db.QueryRowContext(ctx,
 		`SELECT EXISTS(
 			SELECT 1 
 				FROM contract 
-				WHERE id=?
+				WHERE id=? AND deletedat IS NULL
 		)`,
 		id,
+	).Scan(&exist)
+	return exist, err
+}
+
+// GetCompanyItems returns contracts of companies as sellers or clients ordered by id
+func (dac *ContractDAC) GetCompanyItems(ctx context.Context, companyIDs []int) (_ []*model.Contract, err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.by_companies", "count", len(companyIDs))
+	defer end(&err)
+	contrList := make([]*model.Contract, 0)
+	if len(companyIDs) == 0 {
+		return contrList, nil
+	}
+	in, args := inList(companyIDs)
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT id, clientid, sellerid, validfrom, validto, creditamount, version
+			FROM contract
+			WHERE
+				(sellerid IN `+in+` OR clientid IN `+in+`) AND deletedat IS NULL
+			ORDER BY id`,
+		append(args, args...)...,
 	)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	var exist bool
-	rows.Next()
-	err = rows.Scan(&exist)
-	if err != nil {
-		return false
+	defer rows.Close()
+
+	for rows.Next() {
+		contrItem := &model.Contract{}
+		err = rows.Scan(
+			&contrItem.ID,
+			&contrItem.ClientID,
+			&contrItem.SellerID,
+			&contrItem.ValidFrom,
+			&contrItem.ValidTo,
+			&contrItem.CreditAmount,
+			&contrItem.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		contrList = append(contrList, contrItem)
 	}
-	return exist
+	return contrList, rows.Err()
+}
+
+// Export calls fn for every contract matching the query ordered by id
+func (dac *ContractDAC) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Contract) error) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "contract.export", "company", q.CompanyID)
+	defer end(&err)
+
+	f := activeFilter(q)
+	f.add(notDeleted)
+	if q.CompanyID != 0 {
+		f.add("(sellerid = ? OR clientid = ?)", q.CompanyID, q.CompanyID)
+	}
+
+	return exportRows(ctx, dac.db,
+		f.sql(`SELECT id, clientid, sellerid, validfrom, validto, creditamount, version
+			FROM contract`)+`
+			ORDER BY id`,
+		f.args,
+		func(rows *sql.Rows, c *model.Contract) error {
+			return rows.Scan(&c.ID, &c.ClientID, &c.SellerID, &c.ValidFrom, &c.ValidTo, &c.CreditAmount, &c.Version)
+		},
+		fn,
+	)
 }
 
 // PurchaseDAC is a purchase table data access class
 type PurchaseDAC struct {
-	db *sql.DB
-	mx *sync.Mutex
+	db  *sql.DB
+	mx  *sync.Mutex
+	log *slog.Logger
 }
 
 // NewPurchaseDAC creates new company DAC
-func NewPurchaseDAC(db *sql.DB) *PurchaseDAC {
+func NewPurchaseDAC(db *sql.DB, logger *slog.Logger) *PurchaseDAC {
 	return &PurchaseDAC{
-		db: db,
-		mx: &sync.Mutex{},
+		db:  db,
+		mx:  &sync.Mutex{},
+		log: logger,
 	}
 }
 
+// lockPurchaseContract locks contract of purchase for share, so that it can't be deleted meanwhile
+func lockPurchaseContract(ctx context.Context, tx *sql.Tx, p *model.Purchase) error {
+	return lockLive(ctx, tx, "contract", p.ContractID, model.ErrContractDeleted)
+}
+
 // AddItem creates new purchase document
-func (dac *PurchaseDAC) AddItem(purchase *model.Purchase) (int, error) {
+func (dac *PurchaseDAC) AddItem(ctx context.Context, purchase *model.Purchase) (_ int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "purchase.create", "contract", purchase.ContractID)
+	defer end(&err)
 	dac.mx.Lock()
 	defer dac.mx.Unlock()
-	_, err := dac.db.Exec(
+	tx, err := dac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = lockPurchaseContract(ctx, tx, purchase); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx,
 		`INSERT 
 			INTO purchase (contractid, purchasedatetime, creditspent) 
 			VALUES (?, ?, ?)`,
@@ -340,24 +1271,82 @@ func (dac *PurchaseDAC) AddItem(purchase *model.Purchase) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	idx, err := lastInsertID(res)
+	if err != nil {
+		return 0, err
+	}
+	created := *purchase
+	created.ID = idx
+	err = writeAudit(ctx, tx, model.NewAuditRecord(ctx, model.EntityPurchase, idx, model.ActionCreate, nil, &created))
+	if err != nil {
+		return 0, err
+	}
+	return idx, tx.Commit()
+}
 
-	return readIndex(dac.db)
+// CreateItems creates all purchase documents in a single transaction
+func (dac *PurchaseDAC) CreateItems(ctx context.Context, purchases []*model.Purchase) (_ []int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "purchase.create_all", "count", len(purchases))
+	defer end(&err)
+	dac.mx.Lock()
+	defer dac.mx.Unlock()
+	return insertAll(ctx, dac.db, model.EntityPurchase,
+		`INSERT 
+			INTO purchase (contractid, purchasedatetime, creditspent) 
+			VALUES (?, ?, ?)`,
+		purchases,
+		func(p *model.Purchase) []any {
+			return []any{p.ContractID, p.PurchaseDateTime, p.CreditSpent}
+		},
+		func(p *model.Purchase, id int) { p.ID = id },
+		lockPurchaseContract,
+	)
+}
+
+// purchaseKeyset lists purchases by date, ID or spent credit, by date if sort isn't set
+var purchaseKeyset = &keyset[model.Purchase]{
+	def: "datetime",
+	columns: map[string]sortColumn[model.Purchase]{
+		"ID":       intColumn("id", func(p *model.Purchase) int { return p.ID }),
+		"datetime": timeColumn("purchasedatetime", func(p *model.Purchase) time.Time { return p.PurchaseDateTime }),
+		"amount":   intColumn("creditspent", func(p *model.Purchase) int { return p.CreditSpent }),
+	},
+	id: func(p *model.Purchase) int { return p.ID },
 }
 
-// GetContractHistory returns purchase history of contract
-func (dac *PurchaseDAC) GetContractHistory(id int) ([]*model.Purchase, error) {
-	rows, err := dac.db.Query(
+// GetContractHistory returns page of purchase history of contract and next page cursor
+func (dac *PurchaseDAC) GetContractHistory(ctx context.Context, q model.PurchaseQuery) (_ []*model.Purchase, _ string, err error) {
+	ctx, end := startQuery(ctx, dac.log, "purchase.history", "contract", q.ContractID)
+	defer end(&err)
+
+	var f filter
+	f.add("contractid = ?", q.ContractID)
+	if q.From != nil {
+		f.add("purchasedatetime >= ?", *q.From)
+	}
+	if q.To != nil {
+		f.add("purchasedatetime <= ?", *q.To)
+	}
+	if q.MinAmount != nil {
+		f.add("creditspent >= ?", *q.MinAmount)
+	}
+	if q.MaxAmount != nil {
+		f.add("creditspent <= ?", *q.MaxAmount)
+	}
+
+	stmt, args, err := purchaseKeyset.query(
 		`SELECT id, contractid, purchasedatetime, creditspent
-			FROM purchase
-			WHERE 
-				contractid=?
-			ORDER BY
-				purchasedatetime`,
-		id,
+			FROM purchase`,
+		f, q.Sort, q.Page,
 	)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	rows, err := dac.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, "", err
 	}
+	defer rows.Close()
 
 	purList := make([]*model.Purchase, 0)
 
@@ -370,40 +1359,143 @@ func (dac *PurchaseDAC) GetContractHistory(id int) ([]*model.Purchase, error) {
 			&purItem.CreditSpent,
 		)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		purList = append(purList, purItem)
 	}
-	rows.Close()
-	return purList, nil
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	purList, next := purchaseKeyset.page(purList, q.Sort, q.Page)
+	return purList, next, nil
 }
 
 // GetContractSum returns purchase sum of contract
-func (dac *PurchaseDAC) GetContractSum(id int) int {
-	rows, err := dac.db.Query(
-		`SELECT sum(creditspent) as credit
+func (dac *PurchaseDAC) GetContractSum(ctx context.Context, id int) (_ int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "purchase.sum", "contract", id)
+	defer end(&err)
+	var sum int
+	err = dac.db.QueryRowContext(ctx,
+		`SELECT COALESCE(sum(creditspent), 0) as credit
 			FROM purchase
 			WHERE 
 				contractid=?`,
 		id,
+	).Scan(&sum)
+	return sum, err
+}
+
+// GetContractItems returns purchases of contracts ordered by date
+func (dac *PurchaseDAC) GetContractItems(ctx context.Context, contractIDs []int) (_ []*model.Purchase, err error) {
+	ctx, end := startQuery(ctx, dac.log, "purchase.by_contracts", "count", len(contractIDs))
+	defer end(&err)
+	purList := make([]*model.Purchase, 0)
+	if len(contractIDs) == 0 {
+		return purList, nil
+	}
+	in, args := inList(contractIDs)
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT id, contractid, purchasedatetime, creditspent
+			FROM purchase
+			WHERE
+				contractid IN `+in+`
+			ORDER BY purchasedatetime, id`,
+		args...,
 	)
 	if err != nil {
-		return 0
+		return nil, err
 	}
+	defer rows.Close()
 
-	var sum int
-	rows.Next()
-	_ = rows.Scan(&sum)
+	for rows.Next() {
+		purItem := &model.Purchase{}
+		err = rows.Scan(
+			&purItem.ID,
+			&purItem.ContractID,
+			&purItem.PurchaseDateTime,
+			&purItem.CreditSpent,
+		)
+		if err != nil {
+			return nil, err
+		}
+		purList = append(purList, purItem)
+	}
+	return purList, rows.Err()
+}
+
+// GetContractSums returns purchase sums of contracts, contracts without purchases are omitted
+func (dac *PurchaseDAC) GetContractSums(ctx context.Context, contractIDs []int) (_ map[int]int, err error) {
+	ctx, end := startQuery(ctx, dac.log, "purchase.sums", "count", len(contractIDs))
+	defer end(&err)
+	sums := make(map[int]int, len(contractIDs))
+	if len(contractIDs) == 0 {
+		return sums, nil
+	}
+	in, args := inList(contractIDs)
+	rows, err := dac.db.QueryContext(ctx,
+		`SELECT contractid, sum(creditspent) as credit
+			FROM purchase
+			WHERE
+				contractid IN `+in+`
+			GROUP BY contractid`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	return sum
+	for rows.Next() {
+		var id, sum int
+		if err = rows.Scan(&id, &sum); err != nil {
+			return nil, err
+		}
+		sums[id] = sum
+	}
+	return sums, rows.Err()
+}
+
+// Export calls fn for every purchase matching the query ordered by id
+func (dac *PurchaseDAC) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Purchase) error) (err error) {
+	ctx, end := startQuery(ctx, dac.log, "purchase.export", "company", q.CompanyID)
+	defer end(&err)
+
+	// purchases of deleted contracts are kept, but not exported as the contracts
+	var f filter
+	if q.CompanyID != 0 {
+		f.add("contractid IN (SELECT id FROM contract WHERE (sellerid = ? OR clientid = ?) AND "+notDeleted+")", q.CompanyID, q.CompanyID)
+	} else {
+		f.add("contractid IN (SELECT id FROM contract WHERE " + notDeleted + ")")
+	}
+	if q.From != nil {
+		f.add("purchasedatetime >= ?", *q.From)
+	}
+	if q.To != nil {
+		f.add("purchasedatetime <= ?", *q.To)
+	}
+
+	return exportRows(ctx, dac.db,
+		f.sql(`SELECT id, contractid, purchasedatetime, creditspent
+			FROM purchase`)+`
+			ORDER BY id`,
+		f.args,
+		func(rows *sql.Rows, p *model.Purchase) error {
+			return rows.Scan(&p.ID, &p.ContractID, &p.PurchaseDateTime, &p.CreditSpent)
+		},
+		fn,
+	)
 }
 
 // Connection to DB
 
-// Connect returns connection to MySQL
-func Connect() (*sql.DB, error) {
-	// hardcoded, change to config in real app
-	db, err := sql.Open("mysql", "default:1234@/gontracts?parseTime=true")
+// DefaultDSN is a data source name of local development DB
+const DefaultDSN = "default:1234@/gontracts?parseTime=true&clientFoundRows=true"
+
+// Connect returns connection to MySQL of data source name.
+// DSN must enable parseTime and clientFoundRows options
+func Connect(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		defer db.Close()
 		return nil, err
@@ -415,3 +1507,66 @@ func Connect() (*sql.DB, error) {
 	}
 	return db, nil
 }
+
+// pingTimeout limits DB availability check
+const pingTimeout = 2 * time.Second
+
+// schemaTables lists tables created by mysql schema
+var schemaTables = []string{"company", "contract", "purchase", "apikey", "audit"}
+
+// Ping checks that DB is reachable
+func Ping(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+// CheckSchema checks that all schema tables exist in DB and all schema changes are applied by Migrate
+func CheckSchema(db *sql.DB) error {
+	rows, err := db.Query(
+		`SELECT table_name
+			FROM information_schema.tables
+			WHERE
+				table_schema = DATABASE()`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tables := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return err
+		}
+		tables[strings.ToLower(name)] = true
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, t := range schemaTables {
+		if !tables[t] {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("schema is not applied, missing tables: %s", strings.Join(missing, ", "))
+	}
+
+	for _, c := range schemaChanges {
+		ok, err := c.applied(db)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			missing = append(missing, c.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("schema is not migrated, missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
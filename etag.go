@@ -0,0 +1,77 @@
+package gontracts
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// ErrIfMatchRequired change request has no If-Match header
+var ErrIfMatchRequired = errors.New("If-Match header is required")
+
+// etag returns entity tag of item version
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseETag returns item version of strong entity tag
+func parseETag(tag string) (int, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// setETag adds entity tag of item version to the response
+func setETag(w http.ResponseWriter, version int) {
+	if version > 0 {
+		w.Header().Set("ETag", etag(version))
+	}
+}
+
+// ifMatch returns item version expected by If-Match header, zero if any version matches.
+// Tag that can't match any version results in model.ErrVersionMismatch
+func (h *Handler) ifMatch(r *http.Request) (int, error) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch tag {
+	case "":
+		if h.requireIfMatch {
+			return 0, ErrIfMatchRequired
+		}
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+	version, ok := parseETag(tag)
+	if !ok {
+		return 0, model.ErrVersionMismatch
+	}
+	return version, nil
+}
+
+// notModified checks is item version matched by If-None-Match header.
+// Weak comparison is used as for any read request
+func notModified(r *http.Request, version int) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if v, ok := parseETag(strings.TrimPrefix(tag, "W/")); ok && v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package gontracts
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func TestConditionalGet(t *testing.T) {
+	company := test.TestCompany{
+		CL: []*model.Company{
+			{1, "test1", testStrPtr("123"), 3, nil},
+		},
+	}
+
+	cases := []struct {
+		Num         string
+		IfNoneMatch string
+		Response    string
+		Status      int
+	}{
+		// no condition
+		{"1", "", `{"ID":1,"name":"test1","regcode":"123"}`, http.StatusOK},
+		// current version
+		{"2", `"3"`, ``, http.StatusNotModified},
+		// weak tag in list
+		{"3", `"1", W/"3"`, ``, http.StatusNotModified},
+		// any version
+		{"4", `*`, ``, http.StatusNotModified},
+		// outdated version
+		{"5", `"2"`, `{"ID":1,"name":"test1","regcode":"123"}`, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(company, nil, nil)
+
+		req := httptest.NewRequest("GET", "/company/1", nil)
+		if c.IfNoneMatch != "" {
+			req.Header.Set("If-None-Match", c.IfNoneMatch)
+		}
+		w := httptest.NewRecorder()
+
+		testHandle("/company/{id:[0-9]+}", w, req, h.GetCompany)
+		testCheckResponse("ConditionalGet:"+c.Num, t, w, c.Status, c.Response)
+
+		if tag := w.Header().Get("ETag"); tag != `"3"` {
+			t.Errorf("[ConditionalGet:%s]:\twrong ETag: got %s, expected %s", c.Num, tag, `"3"`)
+		}
+	}
+}
+
+func TestConditionalChange(t *testing.T) {
+	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
+	models := testModelSet{
+		company: test.TestCompany{
+			CL: []*model.Company{
+				{ID: 10, Name: "test1"},
+				{ID: 11, Name: "test2"},
+			},
+		},
+		contract: test.TestContract{
+			CL: []*model.Contract{
+				{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 2, nil},
+			},
+		},
+	}
+	update := `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2002-01-01T00:00:00Z","amount":10}`
+	mismatch := func(method string) string {
+		return testProblem(http.StatusPreconditionFailed, "version_mismatch", model.ErrVersionMismatch.Error(), map[string]string{
+			"PUT": "/contract", "PATCH": "/contract/1", "DELETE": "/contract/1",
+		}[method])
+	}
+
+	cases := []struct {
+		Num      string
+		Method   string
+		IfMatch  string
+		Require  bool
+		Request  string
+		Response string
+		Status   int
+		ETag     string
+	}{
+		// update of current version
+		{"1", "PUT", `"2"`, false, update, update, http.StatusOK, `"3"`},
+		// update of outdated version
+		{"2", "PUT", `"1"`, false, update, mismatch("PUT"), http.StatusPreconditionFailed, ""},
+		// update of any version
+		{"3", "PUT", `*`, false, update, update, http.StatusOK, `"3"`},
+		// malformed tag never matches
+		{"4", "PUT", `W/"2"`, false, update, mismatch("PUT"), http.StatusPreconditionFailed, ""},
+		// condition is required
+		{"5", "PUT", ``, true, update, testProblem(http.StatusPreconditionRequired, "if_match_required", ErrIfMatchRequired.Error(), "/contract"), http.StatusPreconditionRequired, ""},
+		// patch of current version
+		{"6", "PATCH", `"2"`, true, `{"validTo":"2002-01-01T00:00:00Z"}`, update, http.StatusOK, `"3"`},
+		// patch of outdated version
+		{"7", "PATCH", `"1"`, false, `{"validTo":"2002-01-01T00:00:00Z"}`, mismatch("PATCH"), http.StatusPreconditionFailed, ""},
+		// delete of outdated version
+		{"8", "DELETE", `"1"`, false, ``, mismatch("DELETE"), http.StatusPreconditionFailed, ""},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(models.company, models.contract, models.purchase)
+		h.SetRequireIfMatch(c.Require)
+
+		url, path, handler := "/contract/1", "/contract/{id:[0-9]+}", h.PatchContract
+		switch c.Method {
+		case "PUT":
+			url, path, handler = "/contract", "/contract", h.UpdateContract
+		case "DELETE":
+			handler = h.DeleteContract
+		}
+		req := httptest.NewRequest(c.Method, url, bytes.NewBufferString(c.Request))
+		if c.IfMatch != "" {
+			req.Header.Set("If-Match", c.IfMatch)
+		}
+		w := httptest.NewRecorder()
+
+		testHandle(path, w, req, handler)
+		testCheckResponse("ConditionalChange:"+c.Num, t, w, c.Status, c.Response)
+
+		if tag := w.Header().Get("ETag"); tag != c.ETag {
+			t.Errorf("[ConditionalChange:%s]:\twrong ETag: got %s, expected %s", c.Num, tag, c.ETag)
+		}
+	}
+}
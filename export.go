@@ -0,0 +1,207 @@
+package gontracts
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/service"
+)
+
+// Export formats
+const (
+	ExportCSV       = "csv"
+	ExportJSONLines = "jsonl"
+)
+
+var (
+	// ErrExportKind exported items are neither companies, contracts nor purchases
+	ErrExportKind = errors.New("export kind must be " + KindCompany + ", " + KindContract + " or " + KindPurchase)
+	// ErrExportFormat export format isn't supported
+	ErrExportFormat = errors.New("export format must be " + ExportCSV + " or " + ExportJSONLines)
+	// ErrDateRange date range ends before it starts
+	ErrDateRange = service.ErrDateRange
+)
+
+// exportFormats maps export formats to media types
+var exportFormats = map[string]string{
+	ExportCSV:       CSVContentType,
+	ExportJSONLines: JSONLinesContentType,
+}
+
+// CSV columns of exported items, the same as their JSON field names
+var (
+	companyColumns  = []string{"ID", "name", "regcode"}
+	contractColumns = []string{"ID", "sellerID", "clientID", "validFrom", "validTo", "amount"}
+	purchaseColumns = []string{"ID", "contractID", "datetime", "amount"}
+)
+
+func companyRecord(c *model.Company) []string {
+	var regCode string
+	if c.RegCode != nil {
+		regCode = *c.RegCode
+	}
+	return []string{strconv.Itoa(c.ID), c.Name, regCode}
+}
+
+func contractRecord(c *model.Contract) []string {
+	return []string{
+		strconv.Itoa(c.ID),
+		strconv.Itoa(c.SellerID),
+		strconv.Itoa(c.ClientID),
+		c.ValidFrom.Format(time.RFC3339),
+		c.ValidTo.Format(time.RFC3339),
+		strconv.Itoa(c.CreditAmount),
+	}
+}
+
+func purchaseRecord(p *model.Purchase) []string {
+	return []string{
+		strconv.Itoa(p.ID),
+		strconv.Itoa(p.ContractID),
+		p.PurchaseDateTime.Format(time.RFC3339),
+		strconv.Itoa(p.CreditSpent),
+	}
+}
+
+// exportWriter encodes items as CSV records with a header or as JSON lines
+type exportWriter[T any] struct {
+	csv    *csv.Writer
+	json   *json.Encoder
+	record func(*T) []string
+}
+
+func newExportWriter[T any](w io.Writer, format string, columns []string, record func(*T) []string) (*exportWriter[T], error) {
+	if format == ExportJSONLines {
+		return &exportWriter[T]{json: json.NewEncoder(w)}, nil
+	}
+	ew := &exportWriter[T]{csv: csv.NewWriter(w), record: record}
+	return ew, ew.csv.Write(columns)
+}
+
+func (ew *exportWriter[T]) write(item *T) error {
+	if ew.csv != nil {
+		return ew.csv.Write(ew.record(item))
+	}
+	return ew.json.Encode(item)
+}
+
+func (ew *exportWriter[T]) flush() error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		return ew.csv.Error()
+	}
+	return nil
+}
+
+// exportItems writes items of export function as soon as they are read
+func exportItems[T any](
+	ctx context.Context,
+	w io.Writer,
+	format string,
+	q model.ExportQuery,
+	columns []string,
+	record func(*T) []string,
+	export func(context.Context, model.ExportQuery, func(*T) error) error,
+) error {
+	ew, err := newExportWriter(w, format, columns, record)
+	if err != nil {
+		return err
+	}
+	if err = export(ctx, q, ew.write); err != nil {
+		return err
+	}
+	return ew.flush()
+}
+
+// ExportItems streams companies, contracts or purchases matching the query to w in CSV or JSON Lines format.
+// Items are written as they are read from the DB, without loading the whole list into memory
+func (h *Handler) ExportItems(ctx context.Context, kind, format string, q model.ExportQuery, w io.Writer) error {
+	if _, ok := exportFormats[format]; !ok {
+		return badRequest(ErrExportFormat)
+	}
+	switch kind {
+	case KindCompany:
+		return exportItems(ctx, w, format, q, companyColumns, companyRecord, h.svc.ExportCompanies)
+	case KindContract:
+		return exportItems(ctx, w, format, q, contractColumns, contractRecord, h.svc.ExportContracts)
+	case KindPurchase:
+		return exportItems(ctx, w, format, q, purchaseColumns, purchaseRecord, h.svc.ExportPurchases)
+	}
+	return badRequest(ErrExportKind)
+}
+
+// parseExportQuery reads export filters from URL query
+func parseExportQuery(v url.Values) (q model.ExportQuery, err error) {
+	if q.CompanyID, err = parseInt(v, "company"); err != nil {
+		return q, err
+	}
+	if q.From, err = parseTime(v, "from"); err != nil {
+		return q, err
+	}
+	q.To, err = parseTime(v, "to")
+	return q, err
+}
+
+// exportResponse starts response with the first written bytes,
+// so that export failing before any data is written gets an error response
+type exportResponse struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *exportResponse) Write(b []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.Header().Set("Content-Type", w.contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Export streams companies, contracts or purchases in CSV or JSON Lines format
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	kind := mux.Vars(r)["kind"]
+
+	// get export params from request query
+	v := r.URL.Query()
+	format := v.Get("format")
+	if format == "" {
+		format = ExportCSV
+	}
+	q, err := parseExportQuery(v)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// stream items from DB
+	ew := &exportResponse{
+		ResponseWriter: w,
+		contentType:    exportFormats[format],
+		filename:       kind + "." + format,
+	}
+	err = h.ExportItems(r.Context(), kind, format, q, ew)
+	if err == nil {
+		return
+	}
+	h.logError(r, err, "export", kind)
+	if !ew.started {
+		writeProblem(w, r, err)
+		return
+	}
+	// status is already sent, so the only way to report failure is to break the response
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,121 @@
+package gontracts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func TestExport(t *testing.T) {
+	deleted := time.Date(2000, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		Num         string
+		URL         string
+		Models      *testModelSet
+		ContentType string
+		Response    string
+		Status      int
+	}{
+		{
+			Num:         "1",
+			URL:         "/export/company",
+			ContentType: CSVContentType,
+			Response:    "ID,name,regcode\n1,Megacom,MGC111\n2,Supercom,\n3,Hypercom,HPC333\n",
+			Status:      http.StatusOK,
+		},
+		{
+			Num:         "2",
+			URL:         "/export/company?format=jsonl&company=2",
+			ContentType: JSONLinesContentType,
+			Response:    `{"ID":2,"name":"Supercom","regcode":null}` + "\n",
+			Status:      http.StatusOK,
+		},
+		{
+			Num:         "3",
+			URL:         "/export/contract?company=2",
+			ContentType: CSVContentType,
+			Response:    "ID,sellerID,clientID,validFrom,validTo,amount\n1,1,2,2000-01-01T00:00:00Z,2001-01-01T00:00:00Z,100\n",
+			Status:      http.StatusOK,
+		},
+		// contract isn't valid within the date range
+		{
+			Num:         "4",
+			URL:         "/export/contract?from=2001-06-01T00:00:00Z",
+			ContentType: CSVContentType,
+			Response:    "ID,sellerID,clientID,validFrom,validTo,amount\n",
+			Status:      http.StatusOK,
+		},
+		{
+			Num:         "5",
+			URL:         "/export/purchase?format=jsonl&from=2000-02-15T00:00:00Z&to=2000-12-31T00:00:00Z",
+			ContentType: JSONLinesContentType,
+			Response:    `{"ID":2,"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":60}` + "\n",
+			Status:      http.StatusOK,
+		},
+		// request errors
+		{
+			Num:      "6",
+			URL:      "/export/company?format=xml",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, ErrExportFormat.Error(), "/export/company"),
+			Status:   http.StatusBadRequest,
+		},
+		{
+			Num:      "7",
+			URL:      "/export/purchase?from=2001-01-01T00:00:00Z&to=2000-01-01T00:00:00Z",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, ErrDateRange.Error(), "/export/purchase"),
+			Status:   http.StatusBadRequest,
+		},
+		{
+			Num:      "8",
+			URL:      "/export/contract?company=x",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, `company: "x" is not an integer`, "/export/contract"),
+			Status:   http.StatusBadRequest,
+		},
+		// failure before anything is written
+		{
+			Num:      "9",
+			URL:      "/export/contract",
+			Models:   &testModelSet{contract: test.TestContractErr{}},
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/export/contract"),
+			Status:   http.StatusInternalServerError,
+		},
+		// purchases of deleted contracts aren't exported
+		{
+			Num: "10",
+			URL: "/export/purchase",
+			Models: &testModelSet{purchase: test.TestPurchase{
+				CL: []*model.Purchase{
+					{1, 1, time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC), 30},
+					{2, 2, time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC), 60},
+				},
+				Contracts: []*model.Contract{{ID: 1}, {ID: 2, DeletedAt: &deleted}},
+			}},
+			ContentType: CSVContentType,
+			Response:    "ID,contractID,datetime,amount\n1,1,2000-02-01T00:00:00Z,30\n",
+			Status:      http.StatusOK,
+		},
+	}
+
+	for _, c := range cases {
+		ms := testClientModels()
+		if c.Models != nil {
+			ms = *c.Models
+		}
+		h := NewHandler(ms.company, ms.contract, ms.purchase)
+
+		req := httptest.NewRequest("GET", c.URL, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/export/{kind:company|contract|purchase}", w, req, h.Export)
+		testCheckResponse("Export:"+c.Num, t, w, c.Status, c.Response)
+		if c.ContentType != "" && w.Header().Get("Content-Type") != c.ContentType {
+			t.Errorf("[Export:%s]:\twrong Content-Type: got %s, expected %s",
+				c.Num, w.Header().Get("Content-Type"), c.ContentType)
+		}
+	}
+}
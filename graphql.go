@@ -0,0 +1,453 @@
+package gontracts
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/service"
+)
+
+// graphQLMaxDepth limits nesting of GraphQL queries
+const graphQLMaxDepth = 10
+
+// graphQLSchema is a schema of GraphQL queries
+//
+//go:embed schema.graphql
+var graphQLSchema string
+
+// ErrGraphQLQueryEmpty GraphQL request has no query
+var ErrGraphQLQueryEmpty = errors.New("query is empty")
+
+// GraphQL serves read-only GraphQL queries over companies, contracts and purchases.
+// Relations of sibling objects are loaded with a single DAC call per field, so that
+// nested queries don't issue a call per object
+type GraphQL struct {
+	h      *Handler
+	schema *graphql.Schema
+}
+
+// NewGraphQL creates GraphQL endpoint over data of request handler
+func NewGraphQL(h *Handler) *GraphQL {
+	return &GraphQL{
+		h:      h,
+		schema: graphql.MustParseSchema(graphQLSchema, &graphQLQuery{h.svc}, graphql.MaxDepth(graphQLMaxDepth)),
+	}
+}
+
+// graphQLRequest is a GraphQL query sent as JSON body or URL query
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// ServeHTTP executes GraphQL query of GET or POST request
+func (g *GraphQL) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+
+	// read query from request
+	if r.Method == http.MethodGet {
+		v := r.URL.Query()
+		req.Query = v.Get("query")
+		req.OperationName = v.Get("operationName")
+		if vars := v.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				g.h.logError(r, err)
+				writeProblem(w, r, badRequest(err))
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		g.h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+	if req.Query == "" {
+		g.h.logError(r, ErrGraphQLQueryEmpty)
+		writeProblem(w, r, badRequest(ErrGraphQLQueryEmpty))
+		return
+	}
+
+	// execute query, errors are a part of the response
+	res := g.schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+	for _, qe := range res.Errors {
+		var ge *graphQLError
+		if errors.As(qe.ResolverError, &ge) {
+			g.h.logError(r, ge.err, "path", qe.Path)
+			continue
+		}
+		g.h.logError(r, qe)
+	}
+
+	// fill response json
+	resp, err := json.Marshal(res)
+	if err != nil {
+		g.h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// graphQLError is a resolver error with problem code in extensions.
+// Unknown errors are hidden behind a generic internal error
+type graphQLError struct {
+	err error
+	ae  *APIError
+}
+
+func newGraphQLError(err error) *graphQLError {
+	return &graphQLError{err, toAPIError(err)}
+}
+
+func (e *graphQLError) Error() string { return e.ae.Error() }
+
+// Extensions returns problem code of the error
+func (e *graphQLError) Extensions() map[string]any {
+	return map[string]any{"code": e.ae.Code}
+}
+
+// batch loads data of all sibling objects with a single call on the first access
+type batch[V any] struct {
+	once  sync.Once
+	fetch func(ctx context.Context) (V, error)
+	val   V
+	err   error
+}
+
+func (b *batch[V]) load(ctx context.Context) (V, error) {
+	b.once.Do(func() {
+		b.val, b.err = b.fetch(ctx)
+	})
+	return b.val, b.err
+}
+
+// graphQLQuery resolves root query fields
+type graphQLQuery struct {
+	svc *service.Service
+}
+
+// graphQLPage are list arguments
+type graphQLPage struct {
+	Sort   *string
+	Limit  *int32
+	Cursor *string
+}
+
+// values encodes list arguments as URL query, so that they are parsed the same way as REST ones
+func (p graphQLPage) values() url.Values {
+	v := make(url.Values)
+	if p.Sort != nil {
+		v.Set("sort", *p.Sort)
+	}
+	if p.Limit != nil {
+		v.Set("limit", strconv.Itoa(int(*p.Limit)))
+	}
+	if p.Cursor != nil {
+		v.Set("cursor", *p.Cursor)
+	}
+	return v
+}
+
+func (q *graphQLQuery) Company(ctx context.Context, args struct{ ID graphql.ID }) (*companyResolver, error) {
+	id, err := strconv.Atoi(string(args.ID))
+	if err != nil {
+		return nil, newGraphQLError(badRequest(err))
+	}
+	c, err := q.svc.GetCompany(ctx, id)
+	if errors.Is(err, ErrCompanyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	return newCompanyResolvers(q.svc, []*model.Company{c})[0], nil
+}
+
+func (q *graphQLQuery) Companies(ctx context.Context, args graphQLPage) (*companyPageResolver, error) {
+	query, err := parseCompanyQuery(args.values())
+	if err != nil {
+		return nil, newGraphQLError(badRequest(err))
+	}
+	list, next, err := q.svc.GetCompanyList(ctx, query)
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	return &companyPageResolver{newCompanyResolvers(q.svc, list), next}, nil
+}
+
+func (q *graphQLQuery) Contract(ctx context.Context, args struct{ ID graphql.ID }) (*contractResolver, error) {
+	id, err := strconv.Atoi(string(args.ID))
+	if err != nil {
+		return nil, newGraphQLError(badRequest(err))
+	}
+	c, err := q.svc.GetContract(ctx, id)
+	if errors.Is(err, ErrContractNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	return newContractResolvers(q.svc, []*model.Contract{c})[0], nil
+}
+
+func (q *graphQLQuery) Contracts(ctx context.Context, args struct {
+	SellerID   *graphql.ID
+	ClientID   *graphql.ID
+	ActiveFrom *graphql.Time
+	ActiveTo   *graphql.Time
+	Sort       *string
+	Limit      *int32
+	Cursor     *string
+}) (*contractPageResolver, error) {
+	v := graphQLPage{args.Sort, args.Limit, args.Cursor}.values()
+	if args.SellerID != nil {
+		v.Set("seller", string(*args.SellerID))
+	}
+	if args.ClientID != nil {
+		v.Set("client", string(*args.ClientID))
+	}
+	if args.ActiveFrom != nil {
+		v.Set("activeFrom", args.ActiveFrom.Format(time.RFC3339Nano))
+	}
+	if args.ActiveTo != nil {
+		v.Set("activeTo", args.ActiveTo.Format(time.RFC3339Nano))
+	}
+	query, err := parseContractQuery(v)
+	if err != nil {
+		return nil, newGraphQLError(badRequest(err))
+	}
+	list, next, err := q.svc.GetContractList(ctx, query)
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	return &contractPageResolver{newContractResolvers(q.svc, list), next}, nil
+}
+
+type companyPageResolver struct {
+	items []*companyResolver
+	next  string
+}
+
+func (r *companyPageResolver) Items() []*companyResolver { return r.items }
+func (r *companyPageResolver) NextCursor() *string       { return nextCursor(r.next) }
+
+type contractPageResolver struct {
+	items []*contractResolver
+	next  string
+}
+
+func (r *contractPageResolver) Items() []*contractResolver { return r.items }
+func (r *contractPageResolver) NextCursor() *string        { return nextCursor(r.next) }
+
+// nextCursor returns nil on the last page
+func nextCursor(next string) *string {
+	if next == "" {
+		return nil
+	}
+	return &next
+}
+
+// companyContracts are contracts of sibling companies by company id
+type companyContracts struct {
+	asSeller map[int][]*contractResolver
+	asClient map[int][]*contractResolver
+}
+
+// companyGroup is a set of sibling companies
+type companyGroup struct {
+	contracts batch[*companyContracts]
+}
+
+type companyResolver struct {
+	c *model.Company
+	g *companyGroup
+}
+
+// newCompanyResolvers returns resolvers of sibling companies sharing batch loads
+func newCompanyResolvers(svc *service.Service, list []*model.Company) []*companyResolver {
+	g := &companyGroup{}
+	res := make([]*companyResolver, len(list))
+	ids := make([]int, len(list))
+	for i, c := range list {
+		res[i] = &companyResolver{c, g}
+		ids[i] = c.ID
+	}
+
+	g.contracts.fetch = func(ctx context.Context) (*companyContracts, error) {
+		list, err := svc.GetCompaniesContracts(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		cc := &companyContracts{
+			asSeller: make(map[int][]*contractResolver),
+			asClient: make(map[int][]*contractResolver),
+		}
+		for _, c := range newContractResolvers(svc, list) {
+			cc.asSeller[c.c.SellerID] = append(cc.asSeller[c.c.SellerID], c)
+			cc.asClient[c.c.ClientID] = append(cc.asClient[c.c.ClientID], c)
+		}
+		return cc, nil
+	}
+	return res
+}
+
+func (r *companyResolver) ID() graphql.ID   { return graphql.ID(strconv.Itoa(r.c.ID)) }
+func (r *companyResolver) Name() string     { return r.c.Name }
+func (r *companyResolver) Regcode() *string { return r.c.RegCode }
+func (r *companyResolver) Version() int32   { return int32(r.c.Version) }
+
+func (r *companyResolver) ContractsAsSeller(ctx context.Context) ([]*contractResolver, error) {
+	cc, err := r.g.contracts.load(ctx)
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	return append([]*contractResolver{}, cc.asSeller[r.c.ID]...), nil
+}
+
+func (r *companyResolver) ContractsAsClient(ctx context.Context) ([]*contractResolver, error) {
+	cc, err := r.g.contracts.load(ctx)
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	return append([]*contractResolver{}, cc.asClient[r.c.ID]...), nil
+}
+
+// contractGroup is a set of sibling contracts
+type contractGroup struct {
+	companies batch[map[int]*companyResolver]
+	purchases batch[map[int][]*purchaseResolver]
+	sums      batch[map[int]int]
+}
+
+type contractResolver struct {
+	c *model.Contract
+	g *contractGroup
+}
+
+// newContractResolvers returns resolvers of sibling contracts sharing batch loads
+func newContractResolvers(svc *service.Service, list []*model.Contract) []*contractResolver {
+	g := &contractGroup{}
+	res := make([]*contractResolver, len(list))
+	ids := make([]int, len(list))
+	var companyIDs []int
+	seen := make(map[int]bool)
+	for i, c := range list {
+		res[i] = &contractResolver{c, g}
+		ids[i] = c.ID
+		for _, id := range []int{c.SellerID, c.ClientID} {
+			if !seen[id] {
+				seen[id] = true
+				companyIDs = append(companyIDs, id)
+			}
+		}
+	}
+
+	g.companies.fetch = func(ctx context.Context) (map[int]*companyResolver, error) {
+		list, err := svc.GetCompanies(ctx, companyIDs)
+		if err != nil {
+			return nil, err
+		}
+		companies := make(map[int]*companyResolver, len(list))
+		for _, c := range newCompanyResolvers(svc, list) {
+			companies[c.c.ID] = c
+		}
+		return companies, nil
+	}
+	g.purchases.fetch = func(ctx context.Context) (map[int][]*purchaseResolver, error) {
+		list, err := svc.GetContractsPurchases(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		contracts := make(map[int]*contractResolver, len(res))
+		for _, c := range res {
+			contracts[c.c.ID] = c
+		}
+		purchases := make(map[int][]*purchaseResolver)
+		for _, p := range list {
+			purchases[p.ContractID] = append(purchases[p.ContractID], &purchaseResolver{p, contracts[p.ContractID]})
+		}
+		return purchases, nil
+	}
+	g.sums.fetch = func(ctx context.Context) (map[int]int, error) {
+		return svc.GetContractsPurchaseSums(ctx, ids)
+	}
+	return res
+}
+
+func (r *contractResolver) ID() graphql.ID          { return graphql.ID(strconv.Itoa(r.c.ID)) }
+func (r *contractResolver) SellerID() graphql.ID    { return graphql.ID(strconv.Itoa(r.c.SellerID)) }
+func (r *contractResolver) ClientID() graphql.ID    { return graphql.ID(strconv.Itoa(r.c.ClientID)) }
+func (r *contractResolver) ValidFrom() graphql.Time { return graphql.Time{Time: r.c.ValidFrom} }
+func (r *contractResolver) ValidTo() graphql.Time   { return graphql.Time{Time: r.c.ValidTo} }
+func (r *contractResolver) Amount() int32           { return int32(r.c.CreditAmount) }
+func (r *contractResolver) Version() int32          { return int32(r.c.Version) }
+
+func (r *contractResolver) Seller(ctx context.Context) (*companyResolver, error) {
+	return r.company(ctx, r.c.SellerID, ErrSellerNotExist)
+}
+
+func (r *contractResolver) Client(ctx context.Context) (*companyResolver, error) {
+	return r.company(ctx, r.c.ClientID, ErrClientNotExist)
+}
+
+func (r *contractResolver) company(ctx context.Context, id int, errMissing error) (*companyResolver, error) {
+	companies, err := r.g.companies.load(ctx)
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	c, ok := companies[id]
+	if !ok {
+		return nil, newGraphQLError(errMissing)
+	}
+	return c, nil
+}
+
+func (r *contractResolver) Purchases(ctx context.Context) ([]*purchaseResolver, error) {
+	purchases, err := r.g.purchases.load(ctx)
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	return append([]*purchaseResolver{}, purchases[r.c.ID]...), nil
+}
+
+func (r *contractResolver) Balance(ctx context.Context) (*balanceResolver, error) {
+	sums, err := r.g.sums.load(ctx)
+	if err != nil {
+		return nil, newGraphQLError(err)
+	}
+	return &balanceResolver{r.c.CreditAmount, sums[r.c.ID]}, nil
+}
+
+type balanceResolver struct {
+	amount int
+	spent  int
+}
+
+func (r *balanceResolver) Amount() int32    { return int32(r.amount) }
+func (r *balanceResolver) Spent() int32     { return int32(r.spent) }
+func (r *balanceResolver) Remaining() int32 { return int32(r.amount - r.spent) }
+
+type purchaseResolver struct {
+	p        *model.Purchase
+	contract *contractResolver
+}
+
+func (r *purchaseResolver) ID() graphql.ID              { return graphql.ID(strconv.Itoa(r.p.ID)) }
+func (r *purchaseResolver) ContractID() graphql.ID      { return graphql.ID(strconv.Itoa(r.p.ContractID)) }
+func (r *purchaseResolver) Contract() *contractResolver { return r.contract }
+func (r *purchaseResolver) Datetime() graphql.Time      { return graphql.Time{Time: r.p.PurchaseDateTime} }
+func (r *purchaseResolver) Amount() int32               { return int32(r.p.CreditSpent) }
@@ -0,0 +1,221 @@
+package gontracts
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+// testCalls counts model calls by method
+type testCalls struct {
+	mx sync.Mutex
+	m  map[string]int
+}
+
+func (c *testCalls) add(method string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.m[method]++
+}
+
+type testCountingCompany struct {
+	test.TestCompany
+	calls *testCalls
+}
+
+func (t testCountingCompany) GetItem(ctx context.Context, id int) (*model.Company, error) {
+	t.calls.add("company.GetItem")
+	return t.TestCompany.GetItem(ctx, id)
+}
+
+func (t testCountingCompany) GetItems(ctx context.Context, ids []int) ([]*model.Company, error) {
+	t.calls.add("company.GetItems")
+	return t.TestCompany.GetItems(ctx, ids)
+}
+
+type testCountingContract struct {
+	test.TestContract
+	calls *testCalls
+}
+
+func (t testCountingContract) GetCompanyItems(ctx context.Context, ids []int) ([]*model.Contract, error) {
+	t.calls.add("contract.GetCompanyItems")
+	return t.TestContract.GetCompanyItems(ctx, ids)
+}
+
+type testCountingHistory struct {
+	test.TestPurchase
+	calls *testCalls
+}
+
+func (t testCountingHistory) GetContractItems(ctx context.Context, ids []int) ([]*model.Purchase, error) {
+	t.calls.add("purchase.GetContractItems")
+	return t.TestPurchase.GetContractItems(ctx, ids)
+}
+
+func (t testCountingHistory) GetContractSums(ctx context.Context, ids []int) (map[int]int, error) {
+	t.calls.add("purchase.GetContractSums")
+	return t.TestPurchase.GetContractSums(ctx, ids)
+}
+
+func testGraphQLModels(calls *testCalls) testModelSet {
+	return testModelSet{
+		company: testCountingCompany{test.TestCompany{
+			CL: []*model.Company{
+				{1, "Megacom", testStrPtr("MGC111"), 3, nil},
+				{2, "Supercom", nil, 1, nil},
+				{3, "Hypercom", testStrPtr("HPC333"), 1, nil},
+			},
+		}, calls},
+		contract: testCountingContract{test.TestContract{
+			CL: []*model.Contract{
+				{1, 1, 2, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), 100, 2, nil},
+				{2, 1, 3, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), 50, 1, nil},
+				{3, 2, 3, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), 10, 1, nil},
+			},
+		}, calls},
+		purchase: testCountingHistory{test.TestPurchase{
+			CL: []*model.Purchase{
+				{1, 1, time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC), 30},
+				{2, 1, time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC), 60},
+				{3, 2, time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC), 5},
+			},
+		}, calls},
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	ms := testGraphQLModels(&testCalls{m: make(map[string]int)})
+	h := NewGraphQL(NewHandler(ms.company, ms.contract, ms.purchase))
+
+	cases := []struct {
+		Num      string
+		Method   string
+		Body     string
+		Query    string
+		Response string
+		Status   int
+	}{
+		// nested relations
+		{
+			Num:      "1",
+			Method:   "POST",
+			Body:     `{"query":"{ company(ID: 1) { name regcode version contractsAsSeller { ID client { name } balance { amount spent remaining } } contractsAsClient { ID } } }"}`,
+			Response: `{"data":{"company":{"name":"Megacom","regcode":"MGC111","version":3,"contractsAsSeller":[{"ID":"1","client":{"name":"Supercom"},"balance":{"amount":100,"spent":90,"remaining":10}},{"ID":"2","client":{"name":"Hypercom"},"balance":{"amount":50,"spent":5,"remaining":45}}],"contractsAsClient":[]}}}`,
+			Status:   http.StatusOK,
+		},
+		{
+			Num:      "2",
+			Method:   "GET",
+			Query:    `query($id: ID!) { contract(ID: $id) { seller { ID } purchases { ID datetime amount contract { ID } } } }`,
+			Response: `{"data":{"contract":{"seller":{"ID":"1"},"purchases":[{"ID":"1","datetime":"2000-02-01T00:00:00Z","amount":30,"contract":{"ID":"1"}},{"ID":"2","datetime":"2000-03-01T00:00:00Z","amount":60,"contract":{"ID":"1"}}]}}}`,
+			Status:   http.StatusOK,
+		},
+		// missing item is null
+		{
+			Num:      "3",
+			Method:   "POST",
+			Body:     `{"query":"{ company(ID: 9) { name } }"}`,
+			Response: `{"data":{"company":null}}`,
+			Status:   http.StatusOK,
+		},
+		// list arguments are parsed as REST ones
+		{
+			Num:      "4",
+			Method:   "POST",
+			Body:     `{"query":"{ companies(limit: 2) { items { ID } nextCursor } }"}`,
+			Response: `{"data":{"companies":{"items":[{"ID":"1"},{"ID":"2"}],"nextCursor":"2"}}}`,
+			Status:   http.StatusOK,
+		},
+		{
+			Num:      "5",
+			Method:   "POST",
+			Body:     `{"query":"{ companies(sort: \"regcode\") { items { ID } } }"}`,
+			Response: `{"errors":[{"message":"unknown sort field \"regcode\", expected one of ID, name","path":["companies"],"extensions":{"code":"invalid_request"}}],"data":null}`,
+			Status:   http.StatusOK,
+		},
+		{
+			Num:      "6",
+			Method:   "POST",
+			Body:     `{"query":"{ contracts(clientID: 3) { items { ID seller { name } } } }"}`,
+			Response: `{"data":{"contracts":{"items":[{"ID":"2","seller":{"name":"Megacom"}},{"ID":"3","seller":{"name":"Supercom"}}]}}}`,
+			Status:   http.StatusOK,
+		},
+		// query errors
+		{
+			Num:      "7",
+			Method:   "POST",
+			Body:     `{"query":"{ company(ID: 1) { unknown } }"}`,
+			Response: `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Company\".","locations":[{"line":1,"column":20}]}]}`,
+			Status:   http.StatusOK,
+		},
+		{
+			Num:      "8",
+			Method:   "POST",
+			Body:     `{}`,
+			Response: testProblem(http.StatusBadRequest, "invalid_request", ErrGraphQLQueryEmpty.Error(), "/graphql"),
+			Status:   http.StatusBadRequest,
+		},
+		{
+			Num:      "9",
+			Method:   "POST",
+			Body:     `{"query":`,
+			Response: testProblem(http.StatusBadRequest, "invalid_request", "unexpected EOF", "/graphql"),
+			Status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		path := "/graphql"
+		if c.Query != "" {
+			path += "?" + url.Values{"query": {c.Query}, "variables": {`{"id":"1"}`}}.Encode()
+		}
+		req, _ := http.NewRequest(c.Method, path, bytes.NewBufferString(c.Body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		testCheckResponse("GraphQL:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
+// TestGraphQLBatch checks that relations are loaded with a single model call per field
+func TestGraphQLBatch(t *testing.T) {
+	calls := &testCalls{m: make(map[string]int)}
+	ms := testGraphQLModels(calls)
+	h := NewGraphQL(NewHandler(ms.company, ms.contract, ms.purchase))
+
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBufferString(`{"query":"{ companies { items { name `+
+		`contractsAsSeller { seller { name } client { name contractsAsClient { ID } } purchases { amount } balance { spent } } `+
+		`contractsAsClient { seller { name } } } } }"}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(`"errors"`)) {
+		t.Fatalf("[GraphQLBatch]:\tgot %d %s", w.Code, w.Body.String())
+	}
+
+	expected := map[string]int{
+		// contracts of listed companies
+		"contract.GetCompanyItems": 2,
+		// sellers and clients of all contracts
+		"company.GetItems":          1,
+		"purchase.GetContractItems": 1,
+		"purchase.GetContractSums":  1,
+	}
+	for method, n := range expected {
+		if calls.m[method] != n {
+			t.Errorf("[GraphQLBatch]:\t%s: got %d calls, expected %d", method, calls.m[method], n)
+		}
+	}
+	if calls.m["company.GetItem"] != 0 {
+		t.Errorf("[GraphQLBatch]:\tgot %d single company calls, expected 0", calls.m["company.GetItem"])
+	}
+}
@@ -0,0 +1,526 @@
+package gontracts
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcErrorDomain is a domain of gRPC error details
+const grpcErrorDomain = "gontracts"
+
+// GRPCService is a gRPC API sharing business logic with request handler
+type GRPCService struct {
+	pb.UnimplementedGontractsServer
+	h *Handler
+}
+
+// NewGRPCService returns gRPC API of request handler
+func NewGRPCService(h *Handler) *GRPCService {
+	return &GRPCService{h: h}
+}
+
+// NewGRPCServer creates gRPC server of the service.
+// Requests are authenticated by auth handler, failures are logged with logger
+func NewGRPCServer(svc *GRPCService, a *AuthHandler, logger *slog.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(grpcErrors(logger), a.grpcAuth))
+	s := grpc.NewServer(opts...)
+	pb.RegisterGontractsServer(s, svc)
+	return s
+}
+
+// grpcErrors logs failed requests and converts errors to gRPC statuses
+func grpcErrors(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		requestID := newRequestID()
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ids := md.Get(RequestIDHeader); len(ids) > 0 && validRequestID(ids[0]) {
+				requestID = ids[0]
+			}
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+		ri := &requestInfo{id: requestID}
+
+		resp, err := handler(context.WithValue(ctx, requestInfoKey{}, ri), req)
+		if err != nil {
+			args := []any{"method", info.FullMethod, "request_id", requestID, "error", err}
+			if ri.subject != "" {
+				args = append(args, "subject", ri.subject)
+			}
+			logger.Error("request failed", args...)
+			return nil, grpcStatus(err)
+		}
+		return resp, nil
+	}
+}
+
+// grpcAuth authenticates request with api key, client certificate or bearer token,
+// and checks the scope required by the method
+func (a *AuthHandler) grpcAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id, err := a.grpcIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ri, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		ri.subject = id.Subject
+	}
+	if scope := grpcMethodScope(info.FullMethod); !id.HasScope(scope) {
+		return nil, ErrScopeNotAllowed
+	}
+	return handler(context.WithValue(ctx, identityKey{}, id), req)
+}
+
+// grpcIdentity returns authenticated principal of gRPC request
+func (a *AuthHandler) grpcIdentity(ctx context.Context) (*Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if keys := md.Get(strings.ToLower(APIKeyHeader)); len(keys) > 0 && keys[0] != "" && a.keys != nil {
+		return a.checkAPIKey(ctx, a.log, keys[0])
+	}
+
+	// known client certificate replaces bearer token
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if id := certIdentity(&tlsInfo.State, a.subjects); id != nil {
+				return id, nil
+			}
+		}
+	}
+
+	auth := md.Get("authorization")
+	if len(auth) == 0 {
+		return nil, ErrTokenInvalid
+	}
+	token, ok := strings.CutPrefix(auth[0], "Bearer ")
+	if !ok {
+		return nil, ErrTokenInvalid
+	}
+	return a.checkToken(token)
+}
+
+// grpcMethodScope returns scope required by gRPC method
+func grpcMethodScope(fullMethod string) string {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	for _, prefix := range []string{"Get", "List", "Search"} {
+		if strings.HasPrefix(name, prefix) {
+			return ScopeRead
+		}
+	}
+	return ScopeWrite
+}
+
+// grpcStatus converts error to gRPC status with problem code in error details.
+// Unknown errors are hidden behind a generic internal error
+func grpcStatus(err error) error {
+	ae := toAPIError(err)
+	st := status.New(grpcCode(ae.Status), ae.Error())
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: ae.Code, Domain: grpcErrorDomain}}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		br := &errdetails.BadRequest{}
+		for _, f := range ve.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Reason,
+			})
+		}
+		details = append(details, br)
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcCode maps HTTP status of API error to gRPC code
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict, http.StatusUnprocessableEntity, http.StatusPreconditionRequired:
+		return codes.FailedPrecondition
+	case http.StatusPreconditionFailed:
+		return codes.Aborted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+// GetCompany returns company by id
+func (s *GRPCService) GetCompany(ctx context.Context, req *pb.GetCompanyRequest) (*pb.Company, error) {
+	c, err := s.h.svc.GetCompany(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return companyToPB(c), nil
+}
+
+// ListCompanies returns page of companies
+func (s *GRPCService) ListCompanies(ctx context.Context, req *pb.ListCompaniesRequest) (*pb.ListCompaniesResponse, error) {
+	q, err := parseCompanyQuery(grpcListValues(req.Sort, req.Page))
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	list, next, err := s.h.svc.GetCompanyList(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListCompaniesResponse{NextCursor: next}
+	for _, c := range list {
+		resp.Companies = append(resp.Companies, companyToPB(c))
+	}
+	return resp, nil
+}
+
+// SearchCompanies returns companies matching the query, the most relevant first
+func (s *GRPCService) SearchCompanies(ctx context.Context, req *pb.SearchCompaniesRequest) (*pb.SearchCompaniesResponse, error) {
+	q := model.CompanySearch{Query: req.Query}
+	p, err := parsePage(grpcListValues(nil, &pb.Page{Limit: req.Limit}))
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	q.Limit = p.Limit
+
+	list, err := s.h.svc.SearchCompanies(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.SearchCompaniesResponse{}
+	for _, m := range list {
+		resp.Matches = append(resp.Matches, &pb.CompanyMatch{
+			Company:   companyToPB(&m.Company),
+			Score:     m.Score,
+			Highlight: m.Highlight,
+		})
+	}
+	return resp, nil
+}
+
+// CreateCompany creates new company
+func (s *GRPCService) CreateCompany(ctx context.Context, req *pb.CreateCompanyRequest) (*pb.CreateResponse, error) {
+	company := companyFromPB(req.Company)
+	company.ID = 0
+	if err := validateItem(company, "CompanyRequest"); err != nil {
+		return nil, err
+	}
+	idx, err := s.h.svc.CreateCompany(ctx, company)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateResponse{Id: int64(idx)}, nil
+}
+
+// UpdateCompany creates company without id or updates existing company of expected version
+func (s *GRPCService) UpdateCompany(ctx context.Context, req *pb.UpdateCompanyRequest) (*pb.Company, error) {
+	company := companyFromPB(req.Company)
+	if err := validateItem(company, "CompanyRequest"); err != nil {
+		return nil, err
+	}
+	if company.ID == 0 {
+		idx, err := s.h.svc.CreateCompany(ctx, company)
+		if err != nil {
+			return nil, err
+		}
+		company.ID = idx
+		return companyToPB(company), nil
+	}
+	if s.h.requireIfMatch && company.Version == 0 {
+		return nil, ErrIfMatchRequired
+	}
+	if err := s.h.svc.UpdateCompany(ctx, company); err != nil {
+		return nil, err
+	}
+	return companyToPB(company), nil
+}
+
+// DeleteCompany soft deletes company of expected version, company with contracts isn't deleted
+func (s *GRPCService) DeleteCompany(ctx context.Context, req *pb.DeleteRequest) (*emptypb.Empty, error) {
+	if s.h.requireIfMatch && req.Version == 0 {
+		return nil, ErrIfMatchRequired
+	}
+	if err := s.h.svc.DeleteCompany(ctx, int(req.Id), int(req.Version), false); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetContract returns contract by id
+func (s *GRPCService) GetContract(ctx context.Context, req *pb.GetContractRequest) (*pb.Contract, error) {
+	c, err := s.h.svc.GetContract(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return contractToPB(c), nil
+}
+
+// ListContracts returns page of contracts
+func (s *GRPCService) ListContracts(ctx context.Context, req *pb.ListContractsRequest) (*pb.ListContractsResponse, error) {
+	v := grpcListValues(req.Sort, req.Page)
+	setInt(v, "seller", req.SellerId)
+	setInt(v, "client", req.ClientId)
+	setTimestamp(v, "activeFrom", req.ActiveFrom)
+	setTimestamp(v, "activeTo", req.ActiveTo)
+	q, err := parseContractQuery(v)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	list, next, err := s.h.svc.GetContractList(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListContractsResponse{NextCursor: next}
+	for _, c := range list {
+		resp.Contracts = append(resp.Contracts, contractToPB(c))
+	}
+	return resp, nil
+}
+
+// CreateContract creates new contract between existing companies
+func (s *GRPCService) CreateContract(ctx context.Context, req *pb.CreateContractRequest) (*pb.CreateResponse, error) {
+	contract, err := contractFromPB(req.Contract)
+	if err != nil {
+		return nil, err
+	}
+	contract.ID = 0
+	if err := validateItem(contract, "ContractRequest"); err != nil {
+		return nil, err
+	}
+	idx, err := s.h.svc.CreateContract(ctx, contract)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateResponse{Id: int64(idx)}, nil
+}
+
+// UpdateContract creates contract without id or updates existing contract of expected version
+func (s *GRPCService) UpdateContract(ctx context.Context, req *pb.UpdateContractRequest) (*pb.Contract, error) {
+	contract, err := contractFromPB(req.Contract)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateItem(contract, "ContractRequest"); err != nil {
+		return nil, err
+	}
+	if contract.ID == 0 {
+		idx, err := s.h.svc.CreateContract(ctx, contract)
+		if err != nil {
+			return nil, err
+		}
+		contract.ID = idx
+		return contractToPB(contract), nil
+	}
+	if s.h.requireIfMatch && contract.Version == 0 {
+		return nil, ErrIfMatchRequired
+	}
+	if err := s.h.svc.UpdateContract(ctx, contract); err != nil {
+		return nil, err
+	}
+	return contractToPB(contract), nil
+}
+
+// DeleteContract removes contract of expected version
+func (s *GRPCService) DeleteContract(ctx context.Context, req *pb.DeleteRequest) (*emptypb.Empty, error) {
+	if s.h.requireIfMatch && req.Version == 0 {
+		return nil, ErrIfMatchRequired
+	}
+	if err := s.h.svc.DeleteContract(ctx, int(req.Id), int(req.Version)); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetContractBalance returns credit amount of contract, spent and remaining credits
+func (s *GRPCService) GetContractBalance(ctx context.Context, req *pb.GetContractBalanceRequest) (*pb.ContractBalance, error) {
+	b, err := s.h.svc.GetContractBalance(ctx, int(req.ContractId))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ContractBalance{
+		ContractId: int64(b.ContractID),
+		Amount:     int64(b.Amount),
+		Spent:      int64(b.Spent),
+		Remaining:  int64(b.Remaining),
+	}, nil
+}
+
+// CreatePurchase creates new purchase document
+func (s *GRPCService) CreatePurchase(ctx context.Context, req *pb.CreatePurchaseRequest) (*pb.CreateResponse, error) {
+	purchase, err := purchaseFromPB(req.Purchase)
+	if err != nil {
+		return nil, err
+	}
+	purchase.ID = 0
+	if err := validateItem(purchase, "Purchase"); err != nil {
+		return nil, err
+	}
+	idx, err := s.h.svc.CreatePurchase(ctx, purchase)
+	if err != nil {
+		return nil, purchaseError(err)
+	}
+	return &pb.CreateResponse{Id: int64(idx)}, nil
+}
+
+// GetPurchaseHistory returns page of purchase history of contract
+func (s *GRPCService) GetPurchaseHistory(ctx context.Context, req *pb.GetPurchaseHistoryRequest) (*pb.GetPurchaseHistoryResponse, error) {
+	v := grpcListValues(req.Sort, req.Page)
+	setTimestamp(v, "from", req.From)
+	setTimestamp(v, "to", req.To)
+	if req.MinAmount != nil {
+		setInt(v, "minAmount", *req.MinAmount)
+	}
+	if req.MaxAmount != nil {
+		setInt(v, "maxAmount", *req.MaxAmount)
+	}
+	q, err := parsePurchaseQuery(v)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	q.ContractID = int(req.ContractId)
+
+	list, next, err := s.h.svc.GetPurchaseHistory(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.GetPurchaseHistoryResponse{NextCursor: next}
+	for _, p := range list {
+		resp.Purchases = append(resp.Purchases, purchaseToPB(p))
+	}
+	return resp, nil
+}
+
+// grpcListValues encodes list params as URL query, so that they are parsed the same way as REST ones
+func grpcListValues(sort *pb.Sort, page *pb.Page) url.Values {
+	v := make(url.Values)
+	if sort.GetField() != "" {
+		field := sort.GetField()
+		if sort.GetDesc() {
+			field = "-" + field
+		}
+		v.Set("sort", field)
+	}
+	if page.GetLimit() != 0 {
+		v.Set("limit", strconv.Itoa(int(page.GetLimit())))
+	}
+	if page.GetCursor() != "" {
+		v.Set("cursor", page.GetCursor())
+	}
+	return v
+}
+
+func setInt(v url.Values, name string, i int64) {
+	if i != 0 {
+		v.Set(name, strconv.FormatInt(i, 10))
+	}
+}
+
+func setTimestamp(v url.Values, name string, t *timestamppb.Timestamp) {
+	if t != nil {
+		v.Set(name, t.AsTime().Format(time.RFC3339Nano))
+	}
+}
+
+func companyToPB(c *model.Company) *pb.Company {
+	return &pb.Company{
+		Id:      int64(c.ID),
+		Name:    c.Name,
+		Regcode: c.RegCode,
+		Version: int64(c.Version),
+	}
+}
+
+func companyFromPB(c *pb.Company) *model.Company {
+	return &model.Company{
+		ID:      int(c.GetId()),
+		Name:    c.GetName(),
+		RegCode: c.Regcode,
+		Version: int(c.GetVersion()),
+	}
+}
+
+func contractToPB(c *model.Contract) *pb.Contract {
+	return &pb.Contract{
+		Id:        int64(c.ID),
+		SellerId:  int64(c.SellerID),
+		ClientId:  int64(c.ClientID),
+		ValidFrom: timestamppb.New(c.ValidFrom),
+		ValidTo:   timestamppb.New(c.ValidTo),
+		Amount:    int64(c.CreditAmount),
+		Version:   int64(c.Version),
+	}
+}
+
+func contractFromPB(c *pb.Contract) (*model.Contract, error) {
+	var fields []FieldError
+	contract := &model.Contract{
+		ID:           int(c.GetId()),
+		SellerID:     int(c.GetSellerId()),
+		ClientID:     int(c.GetClientId()),
+		ValidFrom:    timeFromPB(c.GetValidFrom(), "validFrom", &fields),
+		ValidTo:      timeFromPB(c.GetValidTo(), "validTo", &fields),
+		CreditAmount: int(c.GetAmount()),
+		Version:      int(c.GetVersion()),
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{fields}
+	}
+	return contract, nil
+}
+
+func purchaseToPB(p *model.Purchase) *pb.Purchase {
+	return &pb.Purchase{
+		Id:         int64(p.ID),
+		ContractId: int64(p.ContractID),
+		Datetime:   timestamppb.New(p.PurchaseDateTime),
+		Amount:     int64(p.CreditSpent),
+	}
+}
+
+func purchaseFromPB(p *pb.Purchase) (*model.Purchase, error) {
+	var fields []FieldError
+	purchase := &model.Purchase{
+		ID:               int(p.GetId()),
+		ContractID:       int(p.GetContractId()),
+		PurchaseDateTime: timeFromPB(p.GetDatetime(), "datetime", &fields),
+		CreditSpent:      int(p.GetAmount()),
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{fields}
+	}
+	return purchase, nil
+}
+
+// timeFromPB converts required timestamp of request item.
+// Missing timestamp is a field error, otherwise it would be read as Unix epoch
+func timeFromPB(t *timestamppb.Timestamp, field string, fields *[]FieldError) time.Time {
+	if t == nil {
+		*fields = append(*fields, FieldError{field, "is required"})
+		return time.Time{}
+	}
+	return t.AsTime()
+}
@@ -0,0 +1,238 @@
+package gontracts
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/pb"
+	"github.com/ilyakaznacheev/gontracts/test"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testGRPCClient serves gRPC API of the models on local port
+func testGRPCClient(t *testing.T, ms testModelSet, a *AuthHandler) pb.GontractsClient {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewGRPCServer(NewGRPCService(NewHandler(ms.company, ms.contract, ms.purchase)), a, slog.Default())
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGontractsClient(conn)
+}
+
+// testGRPCContext returns context with bearer token signed by the key
+func testGRPCContext(key []byte) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testBearerToken(key))
+}
+
+// testCheckGRPCError checks status code and problem code of gRPC error
+func testCheckGRPCError(loc string, t *testing.T, err error, code codes.Code, reason string) {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != code {
+		t.Errorf("[%s]:\twrong status code: got %s, expected %s", loc, st.Code(), code)
+	}
+	var got string
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			got = info.Reason
+		}
+	}
+	if got != reason {
+		t.Errorf("[%s]:\twrong error reason: got %q, expected %q", loc, got, reason)
+	}
+}
+
+// testGRPCFields returns fields of validation error violations
+func testGRPCFields(err error) []string {
+	var fields []string
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, f := range br.FieldViolations {
+				fields = append(fields, f.Field)
+			}
+		}
+	}
+	return fields
+}
+
+func TestGRPCAuth(t *testing.T) {
+	key := []byte("test")
+	keys := &test.TestAPIKey{
+		KL: []*model.APIKey{
+			{ID: 1, Prefix: "gk_read", Hash: hashAPIKey("gk_read"), Scopes: []string{ScopeRead}},
+		},
+	}
+	c := testGRPCClient(t, testClientModels(), NewAuthHandler(key, keys))
+	req := &pb.GetCompanyRequest{Id: 1}
+
+	cases := []struct {
+		Num    string
+		Ctx    context.Context
+		Code   codes.Code
+		Reason string
+	}{
+		{"1", testGRPCContext(key), codes.OK, ""},
+		{"2", context.Background(), codes.Unauthenticated, "token_invalid"},
+		{"3", testGRPCContext([]byte("other")), codes.Unauthenticated, "token_invalid"},
+		{"4", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic dGVzdA=="), codes.Unauthenticated, "token_invalid"},
+		{"5", metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "gk_read"), codes.OK, ""},
+		{"6", metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "gk_unknown"), codes.Unauthenticated, "api_key_invalid"},
+	}
+
+	for _, cs := range cases {
+		_, err := c.GetCompany(cs.Ctx, req)
+		testCheckGRPCError("GRPCAuth:"+cs.Num, t, err, cs.Code, cs.Reason)
+	}
+
+	// read key can't change data
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "gk_read")
+	_, err := c.CreateCompany(ctx, &pb.CreateCompanyRequest{Company: &pb.Company{Name: "Newcom"}})
+	testCheckGRPCError("GRPCAuth:7", t, err, codes.PermissionDenied, "scope_not_allowed")
+}
+
+func TestGRPCCompany(t *testing.T) {
+	key := []byte("test")
+	c := testGRPCClient(t, testClientModels(), NewAuthHandler(key, nil))
+	ctx := testGRPCContext(key)
+
+	comp, err := c.GetCompany(ctx, &pb.GetCompanyRequest{Id: 1})
+	if err != nil || comp.Name != "Megacom" || comp.GetRegcode() != "MGC111" || comp.Version != 3 {
+		t.Errorf("[GRPCCompany]:\tGetCompany: got %v, error %v", comp, err)
+	}
+
+	_, err = c.GetCompany(ctx, &pb.GetCompanyRequest{Id: 9})
+	testCheckGRPCError("GRPCCompany:GetCompany", t, err, codes.NotFound, "company_not_found")
+
+	list, err := c.ListCompanies(ctx, &pb.ListCompaniesRequest{Page: &pb.Page{Limit: 2}})
+	if err != nil || len(list.Companies) != 2 || list.NextCursor == "" {
+		t.Fatalf("[GRPCCompany]:\tListCompanies: got %v, error %v", list, err)
+	}
+	list, err = c.ListCompanies(ctx, &pb.ListCompaniesRequest{Page: &pb.Page{Limit: 2, Cursor: list.NextCursor}})
+	if err != nil || len(list.Companies) != 1 || list.Companies[0].Id != 3 || list.NextCursor != "" {
+		t.Errorf("[GRPCCompany]:\tListCompanies: got %v, error %v", list, err)
+	}
+
+	_, err = c.ListCompanies(ctx, &pb.ListCompaniesRequest{Sort: &pb.Sort{Field: "regcode"}})
+	testCheckGRPCError("GRPCCompany:ListCompanies", t, err, codes.InvalidArgument, codeInvalidRequest)
+
+	found, err := c.SearchCompanies(ctx, &pb.SearchCompaniesRequest{Query: "hpc"})
+	if err != nil || len(found.Matches) != 1 || found.Matches[0].Highlight["regcode"] != "<em>HPC</em>333" {
+		t.Errorf("[GRPCCompany]:\tSearchCompanies: got %v, error %v", found, err)
+	}
+
+	created, err := c.CreateCompany(ctx, &pb.CreateCompanyRequest{Company: &pb.Company{Name: "Newcom"}})
+	if err != nil || created.Id != 4 {
+		t.Errorf("[GRPCCompany]:\tCreateCompany: got %v, error %v", created, err)
+	}
+
+	// validation failures are described with field violations
+	_, err = c.CreateCompany(ctx, &pb.CreateCompanyRequest{Company: &pb.Company{Name: " "}})
+	testCheckGRPCError("GRPCCompany:CreateCompany", t, err, codes.InvalidArgument, "validation_failed")
+	if fields := testGRPCFields(err); len(fields) != 1 || fields[0] != "name" {
+		t.Errorf("[GRPCCompany]:\tCreateCompany: got field violations %q, expected name", fields)
+	}
+
+	comp.Name = "Megacom Ltd"
+	updated, err := c.UpdateCompany(ctx, &pb.UpdateCompanyRequest{Company: comp})
+	if err != nil || updated.Name != "Megacom Ltd" || updated.Version != 4 {
+		t.Errorf("[GRPCCompany]:\tUpdateCompany: got %v, error %v", updated, err)
+	}
+
+	comp.Version = 2
+	_, err = c.UpdateCompany(ctx, &pb.UpdateCompanyRequest{Company: comp})
+	testCheckGRPCError("GRPCCompany:UpdateCompany", t, err, codes.Aborted, "version_mismatch")
+
+	if _, err = c.DeleteCompany(ctx, &pb.DeleteRequest{Id: 3}); err != nil {
+		t.Errorf("[GRPCCompany]:\tDeleteCompany: %v", err)
+	}
+}
+
+func TestGRPCContract(t *testing.T) {
+	key := []byte("test")
+	c := testGRPCClient(t, testClientModels(), NewAuthHandler(key, nil))
+	ctx := testGRPCContext(key)
+
+	contr, err := c.GetContract(ctx, &pb.GetContractRequest{Id: 1})
+	if err != nil || contr.Amount != 100 || !contr.ValidFrom.AsTime().Equal(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("[GRPCContract]:\tGetContract: got %v, error %v", contr, err)
+	}
+
+	list, err := c.ListContracts(ctx, &pb.ListContractsRequest{SellerId: 1})
+	if err != nil || len(list.Contracts) != 1 {
+		t.Errorf("[GRPCContract]:\tListContracts: got %v, error %v", list, err)
+	}
+
+	_, err = c.CreateContract(ctx, &pb.CreateContractRequest{Contract: &pb.Contract{
+		SellerId:  1,
+		ClientId:  9,
+		ValidFrom: timestamppb.New(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)),
+		ValidTo:   timestamppb.New(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}})
+	testCheckGRPCError("GRPCContract:CreateContract", t, err, codes.FailedPrecondition, "client_not_found")
+
+	// missing timestamps aren't read as Unix epoch
+	_, err = c.CreateContract(ctx, &pb.CreateContractRequest{Contract: &pb.Contract{SellerId: 1, ClientId: 2, Amount: 100}})
+	testCheckGRPCError("GRPCContract:CreateContract", t, err, codes.InvalidArgument, "validation_failed")
+	if fields := testGRPCFields(err); len(fields) != 2 || fields[0] != "validFrom" || fields[1] != "validTo" {
+		t.Errorf("[GRPCContract]:\tCreateContract: got field violations %q, expected validFrom and validTo", fields)
+	}
+
+	balance, err := c.GetContractBalance(ctx, &pb.GetContractBalanceRequest{ContractId: 1})
+	if err != nil || balance.Amount != 100 || balance.Spent != 90 || balance.Remaining != 10 {
+		t.Errorf("[GRPCContract]:\tGetContractBalance: got %v, error %v", balance, err)
+	}
+
+	_, err = c.GetContractBalance(ctx, &pb.GetContractBalanceRequest{ContractId: 9})
+	testCheckGRPCError("GRPCContract:GetContractBalance", t, err, codes.NotFound, "contract_not_found")
+
+	min := int64(50)
+	hist, err := c.GetPurchaseHistory(ctx, &pb.GetPurchaseHistoryRequest{ContractId: 1, MinAmount: &min})
+	if err != nil || len(hist.Purchases) != 1 || hist.Purchases[0].Id != 2 {
+		t.Errorf("[GRPCContract]:\tGetPurchaseHistory: got %v, error %v", hist, err)
+	}
+
+	_, err = c.GetPurchaseHistory(ctx, &pb.GetPurchaseHistoryRequest{ContractId: 9})
+	testCheckGRPCError("GRPCContract:GetPurchaseHistory", t, err, codes.NotFound, "contract_not_found")
+
+	purchase := &pb.Purchase{
+		ContractId: 1,
+		Datetime:   timestamppb.New(time.Date(2000, 4, 1, 0, 0, 0, 0, time.UTC)),
+		Amount:     20,
+	}
+	_, err = c.CreatePurchase(ctx, &pb.CreatePurchaseRequest{Purchase: purchase})
+	testCheckGRPCError("GRPCContract:CreatePurchase", t, err, codes.FailedPrecondition, "not_enough_money")
+
+	_, err = c.CreatePurchase(ctx, &pb.CreatePurchaseRequest{Purchase: &pb.Purchase{ContractId: 1, Amount: 10}})
+	testCheckGRPCError("GRPCContract:CreatePurchase", t, err, codes.InvalidArgument, "validation_failed")
+	if fields := testGRPCFields(err); len(fields) != 1 || fields[0] != "datetime" {
+		t.Errorf("[GRPCContract]:\tCreatePurchase: got field violations %q, expected datetime", fields)
+	}
+
+	purchase.Amount = 10
+	created, err := c.CreatePurchase(ctx, &pb.CreatePurchaseRequest{Purchase: purchase})
+	if err != nil || created.Id != 3 {
+		t.Errorf("[GRPCContract]:\tCreatePurchase: got %v, error %v", created, err)
+	}
+
+	purchase.Datetime = timestamppb.New(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err = c.CreatePurchase(ctx, &pb.CreatePurchaseRequest{Purchase: purchase})
+	testCheckGRPCError("GRPCContract:CreatePurchase", t, err, codes.FailedPrecondition, "purchase_date_not_valid")
+}
@@ -3,26 +3,33 @@ package gontracts
 import (
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
-	"sync"
 
 	"github.com/gorilla/mux"
 	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/service"
 )
 
+// Domain errors of the service layer
 var (
+	// ErrCompanyNotFound company doesn't exist in DB
+	ErrCompanyNotFound = service.ErrCompanyNotFound
 	// ErrContractNotFound contract doesn't exist in DB
-	ErrContractNotFound = errors.New("contract doesn't exist")
+	ErrContractNotFound = service.ErrContractNotFound
 	// ErrSellerNotExist seller company doesn't exist in DB
-	ErrSellerNotExist = errors.New("seller company doesn't exist")
+	ErrSellerNotExist = service.ErrSellerNotExist
 	// ErrClientNotExist client company doesn't exist in DB
-	ErrClientNotExist = errors.New("client company doesn't exist")
+	ErrClientNotExist = service.ErrClientNotExist
 	// ErrDateNotValid purchase date is outside the contract date range
-	ErrDateNotValid = errors.New("purchase date is outside the contract date range")
+	ErrDateNotValid = service.ErrDateNotValid
 	// ErrNotEnoughMoney not enough money for the purchase
-	ErrNotEnoughMoney = errors.New("not enough money for the purchase")
+	ErrNotEnoughMoney = service.ErrNotEnoughMoney
+	// ErrSearchQueryEmpty search query isn't set
+	ErrSearchQueryEmpty = service.ErrSearchQueryEmpty
+	// ErrCompanyHasContracts company can't be deleted while it has contracts
+	ErrCompanyHasContracts = service.ErrCompanyHasContracts
 )
 
 // ResponseID represents id in response
@@ -32,42 +39,89 @@ type ResponseID struct {
 
 // Handler is a request handler
 type Handler struct {
-	mh         *model.ModelHandler
-	purchaseMX *sync.Mutex
+	svc *service.Service
+	log *slog.Logger
+	// requireIfMatch rejects changes without expected item version
+	requireIfMatch bool
+	audit          model.AuditModel
 }
 
 // NewHandler returns new request handler
 func NewHandler(company model.CompanyModel, contract model.ContractModel, purchase model.PurchaseModel) *Handler {
 	return &Handler{
-		mh:         model.GetModelHandler(company, contract, purchase),
-		purchaseMX: &sync.Mutex{},
+		svc: service.New(model.NewModelHandler(company, contract, purchase)),
+		log: slog.Default(),
 	}
 }
 
+// SetLogger sets request logger
+func (h *Handler) SetLogger(logger *slog.Logger) {
+	h.log = logger
+}
+
+// SetRequireIfMatch makes If-Match header mandatory for changes of existing items
+func (h *Handler) SetRequireIfMatch(require bool) {
+	h.requireIfMatch = require
+}
+
+// SetMetrics enables business event metrics
+func (h *Handler) SetMetrics(m *Metrics) {
+	h.svc.SetObserver(m)
+}
+
+// logError logs request failure with request correlation fields
+func (h *Handler) logError(r *http.Request, err error, args ...any) {
+	requestLogger(h.log, r).Error("request failed", append([]any{"error", err}, args...)...)
+}
+
+// notFound replaces model not found error with resource specific one
+func notFound(err, target error) error {
+	if errors.Is(err, model.ErrNotFound) {
+		return target
+	}
+	return err
+}
+
+// purchaseError reports purchase of missing contract as unprocessable request
+// rather than missing resource, since contract is referenced from request body
+func purchaseError(err error) error {
+	if errors.Is(err, ErrContractNotFound) {
+		return withStatus(err, http.StatusUnprocessableEntity)
+	}
+	return err
+}
+
 // GetCompany returns company info
 func (h *Handler) GetCompany(w http.ResponseWriter, r *http.Request) {
 	// get id from request params
 	rvars := mux.Vars(r)
 	id, err := strconv.Atoi(rvars["id"])
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
 		return
 	}
 
 	// read data from DB
-	c, err := h.mh.GetCompany(id)
+	c, err := h.svc.GetCompany(r.Context(), id)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// client has current version already
+	setETag(w, c.Version)
+	if notModified(r, c.Version) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	// fill response json
 	resp, err := json.Marshal(*c)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -77,13 +131,21 @@ func (h *Handler) GetCompany(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
-// GetCompanyList returns list of companies
+// GetCompanyList returns page of companies
 func (h *Handler) GetCompanyList(w http.ResponseWriter, r *http.Request) {
+	// get list params from request query
+	q, err := parseCompanyQuery(r.URL.Query())
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
 	// read data from DB
-	c, err := h.mh.GetCompanyList()
+	c, next, err := h.svc.GetCompanyList(r.Context(), q)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -95,8 +157,44 @@ func (h *Handler) GetCompanyList(w http.ResponseWriter, r *http.Request) {
 	// fill response json
 	resp, err := json.Marshal(compList)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	setNextPage(w, r, next)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// SearchCompanies returns companies matching the query, the most relevant first
+func (h *Handler) SearchCompanies(w http.ResponseWriter, r *http.Request) {
+	// get search params from request query
+	v := r.URL.Query()
+	q := model.CompanySearch{Query: v.Get("q")}
+	p, err := parsePage(v)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+	q.Limit = p.Limit
+
+	// search in DB
+	c, err := h.svc.SearchCompanies(r.Context(), q)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// fill response json
+	resp, err := json.Marshal(c)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -110,28 +208,27 @@ func (h *Handler) GetCompanyList(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CreateCompany(w http.ResponseWriter, r *http.Request) {
 	var company model.Company
 
-	// read request body
-	dc := json.NewDecoder(r.Body)
-	err := dc.Decode(&company)
+	// read and validate request body
+	err := decodeBody(r, "CompanyRequest", &company)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// create new company in DB
-	idx, err := h.mh.CreateCompany(&company)
+	idx, err := h.svc.CreateCompany(r.Context(), &company)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// fill response json
 	resp, err := json.Marshal(&ResponseID{idx})
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -146,31 +243,36 @@ func (h *Handler) UpdateCompany(w http.ResponseWriter, r *http.Request) {
 	var company model.Company
 	var okStatus int
 
-	// read request body
-	dc := json.NewDecoder(r.Body)
-	err := dc.Decode(&company)
+	// read and validate request body
+	err := decodeBody(r, "CompanyRequest", &company)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	if company.ID == 0 {
 		// if id is empty, create new company
-		idx, err := h.mh.CreateCompany(&company)
+		idx, err := h.svc.CreateCompany(r.Context(), &company)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.logError(r, err)
+			writeProblem(w, r, err)
 			return
 		}
 		company.ID = idx
 		okStatus = http.StatusCreated
 	} else {
-		// if id is set, updete existing company
-		err := h.mh.UpdateCompany(&company)
+		// if id is set, updete existing company of expected version
+		company.Version, err = h.ifMatch(r)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.logError(r, err, "company", company.ID)
+			writeProblem(w, r, err)
+			return
+		}
+		err = h.svc.UpdateCompany(r.Context(), &company)
+		if err != nil {
+			h.logError(r, err, "company", company.ID)
+			writeProblem(w, r, err)
 			return
 		}
 
@@ -180,33 +282,121 @@ func (h *Handler) UpdateCompany(w http.ResponseWriter, r *http.Request) {
 	// fill response json
 	resp, err := json.Marshal(&company)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// setup response
+	setETag(w, company.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(okStatus)
 	w.Write(resp)
 }
 
-// DeleteCompany removes company
+// PatchCompany applies JSON merge patch to company
+func (h *Handler) PatchCompany(w http.ResponseWriter, r *http.Request) {
+	// get id from request params
+	rvars := mux.Vars(r)
+	id, err := strconv.Atoi(rvars["id"])
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// get expected version from request headers
+	version, err := h.ifMatch(r)
+	if err != nil {
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// read current company
+	c, err := h.svc.GetCompany(r.Context(), id)
+	if err != nil {
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
+		return
+	}
+	if version != 0 && version != c.Version {
+		h.logError(r, model.ErrVersionMismatch, "company", id, "version", c.Version)
+		writeProblem(w, r, model.ErrVersionMismatch)
+		return
+	}
+
+	// apply patch from request body
+	company, err := readPatch(r, c, "Company")
+	if err != nil {
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
+		return
+	}
+	if company.ID != id {
+		h.logError(r, ErrPatchID, "company", id)
+		writeProblem(w, r, badRequest(ErrPatchID))
+		return
+	}
+
+	// patched version must not change until update
+	company.Version = c.Version
+
+	// update company in DB
+	err = h.svc.UpdateCompany(r.Context(), company)
+	if err != nil {
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// fill response json
+	resp, err := json.Marshal(company)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	setETag(w, company.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// DeleteCompany soft deletes company, with its contracts if cascade is requested
 func (h *Handler) DeleteCompany(w http.ResponseWriter, r *http.Request) {
 	// get id from request params
 	rvars := mux.Vars(r)
 	id, err := strconv.Atoi(rvars["id"])
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// get cascade mode from request query
+	cascade, err := parseBool(r.URL.Query(), "cascade")
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// get expected version from request headers
+	version, err := h.ifMatch(r)
+	if err != nil {
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// delete company from DB
-	err = h.mh.DeleteCompany(id)
+	err = h.svc.DeleteCompany(r.Context(), id, version, cascade)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -222,24 +412,31 @@ func (h *Handler) GetContract(w http.ResponseWriter, r *http.Request) {
 	rvars := mux.Vars(r)
 	id, err := strconv.Atoi(rvars["id"])
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
 		return
 	}
 
 	// read data from DB
-	c, err := h.mh.GetContract(id)
+	c, err := h.svc.GetContract(r.Context(), id)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// client has current version already
+	setETag(w, c.Version)
+	if notModified(r, c.Version) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	// fill response json
 	resp, err := json.Marshal(*c)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -249,13 +446,21 @@ func (h *Handler) GetContract(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
-// GetContractList returns contract list
+// GetContractList returns page of contracts
 func (h *Handler) GetContractList(w http.ResponseWriter, r *http.Request) {
+	// get list params from request query
+	q, err := parseContractQuery(r.URL.Query())
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
 	// read data from DB
-	c, err := h.mh.GetContractList()
+	c, next, err := h.svc.GetContractList(r.Context(), q)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -267,12 +472,13 @@ func (h *Handler) GetContractList(w http.ResponseWriter, r *http.Request) {
 	// fill response json
 	resp, err := json.Marshal(contrList)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// setup response
+	setNextPage(w, r, next)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(resp)
@@ -282,44 +488,27 @@ func (h *Handler) GetContractList(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CreateContract(w http.ResponseWriter, r *http.Request) {
 	var contract model.Contract
 
-	// read request body
-	dc := json.NewDecoder(r.Body)
-	err := dc.Decode(&contract)
+	// read and validate request body
+	err := decodeBody(r, "ContractRequest", &contract)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// validity checks
-
-	// chech seller company exists in DB
-	if !h.mh.CheckCompanyExist(contract.SellerID) {
-		log.Println(ErrSellerNotExist)
-		http.Error(w, ErrSellerNotExist.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// chech client company exists in DB
-	if !h.mh.CheckCompanyExist(contract.ClientID) {
-		log.Println(ErrClientNotExist)
-		http.Error(w, ErrClientNotExist.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// create new contract in DB
-	idx, err := h.mh.CreateContract(&contract)
+	idx, err := h.svc.CreateContract(r.Context(), &contract)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// fill response json
 	resp, err := json.Marshal(&ResponseID{idx})
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -334,47 +523,36 @@ func (h *Handler) UpdateContract(w http.ResponseWriter, r *http.Request) {
 	var contract model.Contract
 	var okStatus int
 
-	// read request body
-	dc := json.NewDecoder(r.Body)
-	err := dc.Decode(&contract)
+	// read and validate request body
+	err := decodeBody(r, "ContractRequest", &contract)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// validity checks
-
-	// chech seller company exists in DB
-	if !h.mh.CheckCompanyExist(contract.SellerID) {
-		log.Println(ErrSellerNotExist)
-		http.Error(w, ErrSellerNotExist.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// chech client company exists in DB
-	if !h.mh.CheckCompanyExist(contract.ClientID) {
-		log.Println(ErrClientNotExist)
-		http.Error(w, ErrClientNotExist.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	if contract.ID == 0 {
 		// if id is empty create new contract
-		idx, err := h.mh.CreateContract(&contract)
+		idx, err := h.svc.CreateContract(r.Context(), &contract)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.logError(r, err)
+			writeProblem(w, r, err)
 			return
 		}
 		contract.ID = idx
 		okStatus = http.StatusCreated
 	} else {
-		// if id is set update existing contract
-		err := h.mh.UpdateContract(&contract)
+		// if id is set update existing contract of expected version
+		contract.Version, err = h.ifMatch(r)
+		if err != nil {
+			h.logError(r, err, "contract", contract.ID)
+			writeProblem(w, r, err)
+			return
+		}
+		err = h.svc.UpdateContract(r.Context(), &contract)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.logError(r, err, "contract", contract.ID)
+			writeProblem(w, r, err)
 			return
 		}
 
@@ -384,33 +562,155 @@ func (h *Handler) UpdateContract(w http.ResponseWriter, r *http.Request) {
 	// fill response json
 	resp, err := json.Marshal(&contract)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// setup response
+	setETag(w, contract.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(okStatus)
 	w.Write(resp)
 }
 
-// DeleteContract removes contract
+// PatchContract applies JSON merge patch to contract
+func (h *Handler) PatchContract(w http.ResponseWriter, r *http.Request) {
+	// get id from request params
+	rvars := mux.Vars(r)
+	id, err := strconv.Atoi(rvars["id"])
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// get expected version from request headers
+	version, err := h.ifMatch(r)
+	if err != nil {
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// read current contract
+	c, err := h.svc.GetContract(r.Context(), id)
+	if err != nil {
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
+		return
+	}
+	if version != 0 && version != c.Version {
+		h.logError(r, model.ErrVersionMismatch, "contract", id, "version", c.Version)
+		writeProblem(w, r, model.ErrVersionMismatch)
+		return
+	}
+
+	// apply patch from request body
+	contract, err := readPatch(r, c, "Contract")
+	if err != nil {
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
+		return
+	}
+	if contract.ID != id {
+		h.logError(r, ErrPatchID, "contract", id)
+		writeProblem(w, r, badRequest(ErrPatchID))
+		return
+	}
+
+	// patched version must not change until update
+	contract.Version = c.Version
+
+	// update contract in DB
+	err = h.svc.UpdateContract(r.Context(), contract)
+	if err != nil {
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// fill response json
+	resp, err := json.Marshal(contract)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	setETag(w, contract.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// RestoreCompany restores soft deleted company
+func (h *Handler) RestoreCompany(w http.ResponseWriter, r *http.Request) {
+	// get id from request params
+	rvars := mux.Vars(r)
+	id, err := strconv.Atoi(rvars["id"])
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// get expected version from request headers
+	version, err := h.ifMatch(r)
+	if err != nil {
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// restore company in DB
+	company, err := h.svc.RestoreCompany(r.Context(), id, version)
+	if err != nil {
+		h.logError(r, err, "company", id)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// fill response json
+	resp, err := json.Marshal(company)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	setETag(w, company.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// DeleteContract soft deletes contract, its purchases are kept
 func (h *Handler) DeleteContract(w http.ResponseWriter, r *http.Request) {
 	// get id from request params
 	rvars := mux.Vars(r)
 	id, err := strconv.Atoi(rvars["id"])
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	// get expected version from request headers
+	version, err := h.ifMatch(r)
+	if err != nil {
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// delete contract from DB
-	err = h.mh.DeleteContract(id)
+	err = h.svc.DeleteContract(r.Context(), id, version)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -420,61 +720,73 @@ func (h *Handler) DeleteContract(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(http.StatusText(http.StatusOK)))
 }
 
-// Purchase creates new purchase document
-func (h *Handler) Purchase(w http.ResponseWriter, r *http.Request) {
-	var purchase model.Purchase
+// RestoreContract restores soft deleted contract
+func (h *Handler) RestoreContract(w http.ResponseWriter, r *http.Request) {
+	// get id from request params
+	rvars := mux.Vars(r)
+	id, err := strconv.Atoi(rvars["id"])
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
 
-	// read request body
-	dc := json.NewDecoder(r.Body)
-	err := dc.Decode(&purchase)
+	// get expected version from request headers
+	version, err := h.ifMatch(r)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
 		return
 	}
 
-	// read contract data from DB
-	contract, err := h.mh.GetContract(purchase.ContractID)
+	// restore contract in DB
+	contract, err := h.svc.RestoreContract(r.Context(), id, version)
 	if err != nil {
-		log.Println(ErrContractNotFound)
-		http.Error(w, ErrContractNotFound.Error(), http.StatusBadRequest)
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
 		return
 	}
 
-	// check if purchase document in valud date range of contract
-	if purchase.PurchaseDateTime.Before(contract.ValidFrom) || purchase.PurchaseDateTime.After(contract.ValidTo) {
-		log.Println(ErrDateNotValid)
-		http.Error(w, ErrDateNotValid.Error(), http.StatusBadRequest)
+	// fill response json
+	resp, err := json.Marshal(contract)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
-	h.purchaseMX.Lock()
-	defer h.purchaseMX.Unlock()
-	// read sum of existing purchase documents
-	sum := h.mh.GetContractPurchaseSum(purchase.ContractID)
+	// setup response
+	setETag(w, contract.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// Purchase creates new purchase document
+func (h *Handler) Purchase(w http.ResponseWriter, r *http.Request) {
+	var purchase model.Purchase
 
-	// calculate remain credits and check
-	// if there is enough money to process new payment
-	remain := contract.CreditAmount - sum
-	if remain < purchase.CreditSpent {
-		log.Println(ErrNotEnoughMoney)
-		http.Error(w, ErrNotEnoughMoney.Error(), http.StatusInternalServerError)
+	// read and validate request body
+	err := decodeBody(r, "Purchase", &purchase)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
-	// create new payment document in DB
-	idx, err := h.mh.CreatePurchase(&purchase)
+	// check contract and create purchase document
+	idx, err := h.svc.CreatePurchase(r.Context(), &purchase)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err, "contract", purchase.ContractID)
+		writeProblem(w, r, purchaseError(err))
 		return
 	}
 
 	// fill response json
 	resp, err := json.Marshal(&ResponseID{idx})
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
@@ -484,26 +796,32 @@ func (h *Handler) Purchase(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
-// GetPurchaseHistory returns purcase history of contract
+// GetPurchaseHistory returns page of purcase history of contract
 func (h *Handler) GetPurchaseHistory(w http.ResponseWriter, r *http.Request) {
 	// get id from request params
 	rvars := mux.Vars(r)
 	id, err := strconv.Atoi(rvars["id"])
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
 		return
 	}
 
-	// read purchase history of contract
-	p, _ := h.mh.GetContractPurchaseHistory(id)
+	// get list params from request query
+	q, err := parsePurchaseQuery(r.URL.Query())
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+	q.ContractID = id
 
-	if len(p) == 0 {
-		if !h.mh.CheckContractsExist(id) {
-			log.Println(ErrContractNotFound)
-			http.Error(w, ErrContractNotFound.Error(), http.StatusNotFound)
-			return
-		}
+	// read purchase history of contract
+	p, next, err := h.svc.GetPurchaseHistory(r.Context(), q)
+	if err != nil {
+		h.logError(r, err, "contract", id)
+		writeProblem(w, r, err)
+		return
 	}
 
 	purList := make([]model.Purchase, 0, len(p))
@@ -514,12 +832,13 @@ func (h *Handler) GetPurchaseHistory(w http.ResponseWriter, r *http.Request) {
 	// fill response json
 	resp, err := json.Marshal(purList)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logError(r, err)
+		writeProblem(w, r, err)
 		return
 	}
 
 	// setup response
+	setNextPage(w, r, next)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(resp)
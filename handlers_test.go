@@ -2,11 +2,11 @@ package gontracts
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
-	"sync"
 	"testing"
 	"time"
 
@@ -31,11 +31,29 @@ func testHandle(path string, w http.ResponseWriter, req *http.Request, h func(ht
 	r.ServeHTTP(w, req)
 }
 
-func testNewHandler(company model.CompanyModel, contract model.ContractModel, purchase model.PurchaseModel) *Handler {
-	return &Handler{
-		mh:         model.NewModelHandler(company, contract, purchase),
-		purchaseMX: &sync.Mutex{},
-	}
+func testProblem(status int, code, detail, instance string) string {
+	resp, _ := json.Marshal(&Problem{
+		Type:     problemTypePrefix + code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: instance,
+		Code:     code,
+	})
+	return string(resp)
+}
+
+func testValidationProblem(instance string, fields ...FieldError) string {
+	resp, _ := json.Marshal(&Problem{
+		Type:     problemTypePrefix + "validation_failed",
+		Title:    http.StatusText(http.StatusBadRequest),
+		Status:   http.StatusBadRequest,
+		Detail:   ErrValidation.Error(),
+		Instance: instance,
+		Code:     "validation_failed",
+		Errors:   fields,
+	})
+	return string(resp)
 }
 
 func testCheckResponse(loc string, t *testing.T, w *httptest.ResponseRecorder, respStatus int, respBody string) {
@@ -54,8 +72,8 @@ func testCheckResponse(loc string, t *testing.T, w *httptest.ResponseRecorder, r
 func TestGetCompany(t *testing.T) {
 	company := test.TestCompany{
 		CL: []*model.Company{
-			{1, "test1", testStrPtr("123")},
-			{2, "test2", nil},
+			{1, "test1", testStrPtr("123"), 1, nil},
+			{2, "test2", nil, 1, nil},
 		},
 	}
 
@@ -87,7 +105,7 @@ func TestGetCompany(t *testing.T) {
 		{
 			Num:      "3",
 			ID:       3,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusNotFound, "company_not_found", ErrCompanyNotFound.Error(), "/company/3"),
 			Status:   http.StatusNotFound,
 			Models: testModelSet{
 				company: company,
@@ -96,7 +114,7 @@ func TestGetCompany(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := fmt.Sprintf("/company/%d", c.ID)
 		req := httptest.NewRequest("GET", url, nil)
@@ -107,11 +125,33 @@ func TestGetCompany(t *testing.T) {
 	}
 }
 
+// TestNewHandlerIsolation checks that handlers don't share models
+func TestNewHandlerIsolation(t *testing.T) {
+	h1 := NewHandler(test.TestCompany{CL: []*model.Company{{1, "test1", nil, 1, nil}}}, nil, nil)
+	h2 := NewHandler(test.TestCompany{CL: []*model.Company{{1, "test2", nil, 1, nil}}}, nil, nil)
+
+	for _, c := range []struct {
+		Num      string
+		H        *Handler
+		Response string
+	}{
+		{"1", h1, `{"ID":1,"name":"test1","regcode":null}`},
+		{"2", h2, `{"ID":1,"name":"test2","regcode":null}`},
+		{"3", h1, `{"ID":1,"name":"test1","regcode":null}`},
+	} {
+		req := httptest.NewRequest("GET", "/company/1", nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/company/{id:[0-9]+}", w, req, c.H.GetCompany)
+		testCheckResponse("NewHandlerIsolation:"+c.Num, t, w, http.StatusOK, c.Response)
+	}
+}
+
 func TestGetCompanyList(t *testing.T) {
 	company := test.TestCompany{
 		CL: []*model.Company{
-			{1, "test1", testStrPtr("123")},
-			{2, "test2", nil},
+			{1, "test1", testStrPtr("123"), 1, nil},
+			{2, "test2", nil, 1, nil},
 		},
 	}
 	companyError := test.TestCompanyErr{}
@@ -132,8 +172,8 @@ func TestGetCompanyList(t *testing.T) {
 		},
 		{
 			Num:      "2",
-			Response: test.ErrTest.Error() + "\n",
-			Status:   http.StatusNotFound,
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/company"),
+			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				company: companyError,
 			},
@@ -141,7 +181,7 @@ func TestGetCompanyList(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := "/company"
 		req := httptest.NewRequest("GET", url, nil)
@@ -152,6 +192,86 @@ func TestGetCompanyList(t *testing.T) {
 	}
 }
 
+func TestSearchCompanies(t *testing.T) {
+	company := test.TestCompany{
+		CL: []*model.Company{
+			{1, "Acme Corp", testStrPtr("EE123"), 1, nil},
+			{2, "Globex & Acme", nil, 1, nil},
+			{3, "Initech", testStrPtr("LV456"), 1, nil},
+		},
+	}
+	companyError := test.TestCompanyErr{}
+
+	cases := []struct {
+		Num      string
+		Query    string
+		Response string
+		Status   int
+		Models   testModelSet
+	}{
+		// match by name, the most relevant first
+		{
+			Num:      "1",
+			Query:    "q=acme+ee1",
+			Response: `[{"ID":1,"name":"Acme Corp","regcode":"EE123","score":2,"highlight":{"name":"\u003cem\u003eAcme\u003c/em\u003e Corp","regcode":"\u003cem\u003eEE1\u003c/em\u003e23"}},{"ID":2,"name":"Globex \u0026 Acme","regcode":null,"score":1,"highlight":{"name":"Globex \u0026amp; \u003cem\u003eAcme\u003c/em\u003e"}}]`,
+			Status:   http.StatusOK,
+			Models: testModelSet{
+				company: company,
+			},
+		},
+		// match by registration code
+		{
+			Num:      "2",
+			Query:    "q=lv4",
+			Response: `[{"ID":3,"name":"Initech","regcode":"LV456","score":1,"highlight":{"regcode":"\u003cem\u003eLV4\u003c/em\u003e56"}}]`,
+			Status:   http.StatusOK,
+			Models: testModelSet{
+				company: company,
+			},
+		},
+		// nothing found
+		{
+			Num:      "3",
+			Query:    "q=umbrella",
+			Response: `[]`,
+			Status:   http.StatusOK,
+			Models: testModelSet{
+				company: company,
+			},
+		},
+		// empty query
+		{
+			Num:      "4",
+			Query:    "q=+",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, ErrSearchQueryEmpty.Error(), "/company/search"),
+			Status:   http.StatusBadRequest,
+			Models: testModelSet{
+				company: company,
+			},
+		},
+		// handle error
+		{
+			Num:      "5",
+			Query:    "q=acme",
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/company/search"),
+			Status:   http.StatusInternalServerError,
+			Models: testModelSet{
+				company: companyError,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+
+		req := httptest.NewRequest("GET", "/company/search?"+c.Query, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/company/search", w, req, h.SearchCompanies)
+		testCheckResponse("SearchCompanies:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
 func TestCreateCompany(t *testing.T) {
 	cases := []struct {
 		Num      string
@@ -214,7 +334,7 @@ func TestCreateCompany(t *testing.T) {
 		{
 			Num:      "5",
 			Request:  `{"name":"test2","regcode":null}`,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/company"),
 			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				company: test.TestCompanyErr{},
@@ -223,7 +343,7 @@ func TestCreateCompany(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := "/company"
 		req := httptest.NewRequest("POST", url, bytes.NewBuffer([]byte(c.Request)))
@@ -272,8 +392,8 @@ func TestUpdateCompany(t *testing.T) {
 		{
 			Num:      "3",
 			Request:  `{"ID":1,"name":"test2","regcode":null}`,
-			Response: test.ErrTest.Error() + "\n",
-			Status:   http.StatusInternalServerError,
+			Response: testProblem(http.StatusNotFound, "company_not_found", ErrCompanyNotFound.Error(), "/company"),
+			Status:   http.StatusNotFound,
 			Models: testModelSet{
 				company: test.TestCompany{
 					CL: []*model.Company{},
@@ -284,8 +404,8 @@ func TestUpdateCompany(t *testing.T) {
 		{
 			Num:      "4",
 			Request:  `{"ID":2,"name":"test2","regcode":null}`,
-			Response: test.ErrTest.Error() + "\n",
-			Status:   http.StatusInternalServerError,
+			Response: testProblem(http.StatusNotFound, "company_not_found", ErrCompanyNotFound.Error(), "/company"),
+			Status:   http.StatusNotFound,
 			Models: testModelSet{
 				company: test.TestCompany{
 					CL: []*model.Company{
@@ -298,7 +418,7 @@ func TestUpdateCompany(t *testing.T) {
 		{
 			Num:      "5",
 			Request:  `{"name":"test2","regcode":null}`,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/company"),
 			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				company: test.TestCompanyErr{},
@@ -307,7 +427,7 @@ func TestUpdateCompany(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := "/company"
 		req := httptest.NewRequest("PUT", url, bytes.NewBuffer([]byte(c.Request)))
@@ -319,9 +439,12 @@ func TestUpdateCompany(t *testing.T) {
 }
 
 func TestDeleteCompany(t *testing.T) {
+	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
+
 	cases := []struct {
 		Num      string
 		ID       int
+		Query    string
 		Response string
 		Status   int
 		Models   testModelSet
@@ -335,7 +458,7 @@ func TestDeleteCompany(t *testing.T) {
 			Models: testModelSet{
 				company: test.TestCompany{
 					CL: []*model.Company{
-						{1, "test", testStrPtr("123")},
+						{1, "test", testStrPtr("123"), 1, nil},
 					},
 				},
 			},
@@ -344,12 +467,12 @@ func TestDeleteCompany(t *testing.T) {
 		{
 			Num:      "2",
 			ID:       2,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusNotFound, "company_not_found", ErrCompanyNotFound.Error(), "/company/2"),
 			Status:   http.StatusNotFound,
 			Models: testModelSet{
 				company: test.TestCompany{
 					CL: []*model.Company{
-						{1, "test", testStrPtr("123")},
+						{1, "test", testStrPtr("123"), 1, nil},
 					},
 				},
 			},
@@ -358,7 +481,7 @@ func TestDeleteCompany(t *testing.T) {
 		{
 			Num:      "3",
 			ID:       2,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusNotFound, "company_not_found", ErrCompanyNotFound.Error(), "/company/2"),
 			Status:   http.StatusNotFound,
 			Models: testModelSet{
 				company: test.TestCompany{
@@ -370,18 +493,95 @@ func TestDeleteCompany(t *testing.T) {
 		{
 			Num:      "4",
 			ID:       3,
-			Response: test.ErrTest.Error() + "\n",
-			Status:   http.StatusNotFound,
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/company/3"),
+			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				company: test.TestCompanyErr{},
 			},
 		},
+		// company has contracts
+		{
+			Num:      "5",
+			ID:       1,
+			Response: testProblem(http.StatusConflict, "company_has_contracts", ErrCompanyHasContracts.Error(), "/company/1"),
+			Status:   http.StatusConflict,
+			Models: testModelSet{
+				company: test.TestCompany{
+					CL: []*model.Company{
+						{1, "test", testStrPtr("123"), 1, nil},
+					},
+					Contracts: []*model.Contract{
+						{1, 2, 1, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
+					},
+				},
+			},
+		},
+		// cascade to contracts
+		{
+			Num:      "6",
+			ID:       1,
+			Query:    "?cascade=true",
+			Response: http.StatusText(http.StatusOK),
+			Status:   http.StatusOK,
+			Models: testModelSet{
+				company: test.TestCompany{
+					CL: []*model.Company{
+						{1, "test", testStrPtr("123"), 1, nil},
+					},
+					Contracts: []*model.Contract{
+						{1, 2, 1, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
+					},
+				},
+			},
+		},
+		// deleted contracts don't reference company
+		{
+			Num:      "7",
+			ID:       1,
+			Response: http.StatusText(http.StatusOK),
+			Status:   http.StatusOK,
+			Models: testModelSet{
+				company: test.TestCompany{
+					CL: []*model.Company{
+						{1, "test", testStrPtr("123"), 1, nil},
+					},
+					Contracts: []*model.Contract{
+						{1, 2, 1, time1, time1.AddDate(1, 0, 0), 10, 1, &time1},
+					},
+				},
+			},
+		},
+		// already deleted
+		{
+			Num:      "8",
+			ID:       1,
+			Response: testProblem(http.StatusNotFound, "company_not_found", ErrCompanyNotFound.Error(), "/company/1"),
+			Status:   http.StatusNotFound,
+			Models: testModelSet{
+				company: test.TestCompany{
+					CL: []*model.Company{
+						{1, "test", testStrPtr("123"), 1, &time1},
+					},
+				},
+			},
+		},
+		// malformed cascade flag
+		{
+			Num:      "9",
+			ID:       1,
+			Query:    "?cascade=all",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, `cascade: "all" is not a boolean`, "/company/1"),
+			Status:   http.StatusBadRequest,
+			Models: testModelSet{
+				company: test.TestCompany{},
+			},
+		},
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
-		url := fmt.Sprintf("/company/%d", c.ID)
+		url := fmt.Sprintf("/company/%d%s", c.ID, c.Query)
 		req := httptest.NewRequest("DELETE", url, nil)
 		w := httptest.NewRecorder()
 
@@ -390,12 +590,74 @@ func TestDeleteCompany(t *testing.T) {
 	}
 }
 
+func TestRestoreCompany(t *testing.T) {
+	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
+
+	cases := []struct {
+		Num      string
+		ID       int
+		Response string
+		Status   int
+		Models   testModelSet
+	}{
+		// restore deleted item
+		{
+			Num:      "1",
+			ID:       1,
+			Response: `{"ID":1,"name":"test","regcode":"123"}`,
+			Status:   http.StatusOK,
+			Models: testModelSet{
+				company: test.TestCompany{
+					CL: []*model.Company{
+						{1, "test", testStrPtr("123"), 1, &time1},
+					},
+				},
+			},
+		},
+		// item isn't deleted
+		{
+			Num:      "2",
+			ID:       1,
+			Response: testProblem(http.StatusNotFound, "company_not_found", ErrCompanyNotFound.Error(), "/company/1/restore"),
+			Status:   http.StatusNotFound,
+			Models: testModelSet{
+				company: test.TestCompany{
+					CL: []*model.Company{
+						{1, "test", testStrPtr("123"), 1, nil},
+					},
+				},
+			},
+		},
+		// error handling
+		{
+			Num:      "3",
+			ID:       1,
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/company/1/restore"),
+			Status:   http.StatusInternalServerError,
+			Models: testModelSet{
+				company: test.TestCompanyErr{},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+
+		url := fmt.Sprintf("/company/%d/restore", c.ID)
+		req := httptest.NewRequest("POST", url, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/company/{id:[0-9]+}/restore", w, req, h.RestoreCompany)
+		testCheckResponse("RestoreCompany:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
 func TestGetContract(t *testing.T) {
 	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
 	contract := test.TestContract{
 		CL: []*model.Contract{
-			{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10},
-			{2, 20, 21, time1, time1.AddDate(1, 0, 0), 100},
+			{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
+			{2, 20, 21, time1, time1.AddDate(1, 0, 0), 100, 1, nil},
 		},
 	}
 
@@ -427,7 +689,7 @@ func TestGetContract(t *testing.T) {
 		{
 			Num:      "3",
 			ID:       3,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusNotFound, "contract_not_found", ErrContractNotFound.Error(), "/contract/3"),
 			Status:   http.StatusNotFound,
 			Models: testModelSet{
 				contract: contract,
@@ -436,8 +698,8 @@ func TestGetContract(t *testing.T) {
 		{
 			Num:      "4",
 			ID:       1,
-			Response: test.ErrTest.Error() + "\n",
-			Status:   http.StatusNotFound,
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/contract/1"),
+			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				contract: test.TestContractErr{},
 			},
@@ -445,7 +707,7 @@ func TestGetContract(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := fmt.Sprintf("/contract/%d", c.ID)
 		req := httptest.NewRequest("GET", url, nil)
@@ -460,8 +722,8 @@ func TestGetContractList(t *testing.T) {
 	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
 	contract := test.TestContract{
 		CL: []*model.Contract{
-			{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10},
-			{2, 20, 21, time1, time1.AddDate(1, 0, 0), 100},
+			{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
+			{2, 20, 21, time1, time1.AddDate(1, 0, 0), 100, 1, nil},
 		},
 	}
 
@@ -481,8 +743,8 @@ func TestGetContractList(t *testing.T) {
 		},
 		{
 			Num:      "2",
-			Response: test.ErrTest.Error() + "\n",
-			Status:   http.StatusNotFound,
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/contract"),
+			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				contract: test.TestContractErr{},
 			},
@@ -490,7 +752,7 @@ func TestGetContractList(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := "/contract"
 		req := httptest.NewRequest("GET", url, nil)
@@ -531,8 +793,8 @@ func TestCreateContract(t *testing.T) {
 		{
 			Num:      "2",
 			Request:  `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}`,
-			Response: ErrSellerNotExist.Error() + "\n",
-			Status:   http.StatusBadRequest,
+			Response: testProblem(http.StatusUnprocessableEntity, "seller_not_found", ErrSellerNotExist.Error(), "/contract"),
+			Status:   http.StatusUnprocessableEntity,
 			Models: testModelSet{
 				company: test.TestCompany{
 					CL: []*model.Company{
@@ -548,8 +810,8 @@ func TestCreateContract(t *testing.T) {
 		{
 			Num:      "3",
 			Request:  `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}`,
-			Response: ErrClientNotExist.Error() + "\n",
-			Status:   http.StatusBadRequest,
+			Response: testProblem(http.StatusUnprocessableEntity, "client_not_found", ErrClientNotExist.Error(), "/contract"),
+			Status:   http.StatusUnprocessableEntity,
 			Models: testModelSet{
 				company: test.TestCompany{
 					CL: []*model.Company{
@@ -565,7 +827,7 @@ func TestCreateContract(t *testing.T) {
 		{
 			Num:      "4",
 			Request:  `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}`,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/contract"),
 			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				company: test.TestCompany{
@@ -580,7 +842,7 @@ func TestCreateContract(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := "/contract"
 		req := httptest.NewRequest("POST", url, bytes.NewBuffer([]byte(c.Request)))
@@ -634,7 +896,7 @@ func TestUpdateContract(t *testing.T) {
 				},
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10},
+						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
 					},
 				},
 			},
@@ -643,8 +905,8 @@ func TestUpdateContract(t *testing.T) {
 		{
 			Num:      "3",
 			Request:  `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}`,
-			Response: ErrSellerNotExist.Error() + "\n",
-			Status:   http.StatusBadRequest,
+			Response: testProblem(http.StatusUnprocessableEntity, "seller_not_found", ErrSellerNotExist.Error(), "/contract"),
+			Status:   http.StatusUnprocessableEntity,
 			Models: testModelSet{
 				company: test.TestCompany{
 					CL: []*model.Company{
@@ -660,8 +922,8 @@ func TestUpdateContract(t *testing.T) {
 		{
 			Num:      "4",
 			Request:  `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}`,
-			Response: ErrClientNotExist.Error() + "\n",
-			Status:   http.StatusBadRequest,
+			Response: testProblem(http.StatusUnprocessableEntity, "client_not_found", ErrClientNotExist.Error(), "/contract"),
+			Status:   http.StatusUnprocessableEntity,
 			Models: testModelSet{
 				company: test.TestCompany{
 					CL: []*model.Company{
@@ -677,7 +939,7 @@ func TestUpdateContract(t *testing.T) {
 		{
 			Num:      "5",
 			Request:  `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}`,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/contract"),
 			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				company: test.TestCompany{
@@ -692,7 +954,7 @@ func TestUpdateContract(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := "/contract"
 		req := httptest.NewRequest("PUT", url, bytes.NewBuffer([]byte(c.Request)))
@@ -722,7 +984,7 @@ func TestDeleteContract(t *testing.T) {
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10},
+						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
 					},
 				},
 			},
@@ -731,7 +993,7 @@ func TestDeleteContract(t *testing.T) {
 		{
 			Num:      "2",
 			ID:       1,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusNotFound, "contract_not_found", ErrContractNotFound.Error(), "/contract/1"),
 			Status:   http.StatusNotFound,
 			Models: testModelSet{
 				contract: test.TestContract{
@@ -743,8 +1005,8 @@ func TestDeleteContract(t *testing.T) {
 		{
 			Num:      "3",
 			ID:       1,
-			Response: test.ErrTest.Error() + "\n",
-			Status:   http.StatusNotFound,
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/contract/1"),
+			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				contract: test.TestContractErr{},
 			},
@@ -752,7 +1014,7 @@ func TestDeleteContract(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := fmt.Sprintf("/contract/%d", c.ID)
 		req := httptest.NewRequest("DELETE", url, nil)
@@ -763,6 +1025,90 @@ func TestDeleteContract(t *testing.T) {
 	}
 }
 
+func TestRestoreContract(t *testing.T) {
+	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
+
+	cases := []struct {
+		Num      string
+		ID       int
+		Response string
+		Status   int
+		Models   testModelSet
+	}{
+		// restore deleted item
+		{
+			Num:      "1",
+			ID:       1,
+			Response: `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}`,
+			Status:   http.StatusOK,
+			Models: testModelSet{
+				contract: test.TestContract{
+					CL: []*model.Contract{
+						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, &time1},
+					},
+					Companies: []*model.Company{
+						{10, "Seller", nil, 1, nil},
+						{11, "Client", nil, 1, nil},
+					},
+				},
+			},
+		},
+		// client company is deleted
+		{
+			Num:      "2",
+			ID:       1,
+			Response: testProblem(http.StatusUnprocessableEntity, "client_not_found", ErrClientNotExist.Error(), "/contract/1/restore"),
+			Status:   http.StatusUnprocessableEntity,
+			Models: testModelSet{
+				contract: test.TestContract{
+					CL: []*model.Contract{
+						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, &time1},
+					},
+					Companies: []*model.Company{
+						{10, "Seller", nil, 1, nil},
+						{11, "Client", nil, 1, &time1},
+					},
+				},
+			},
+		},
+		// item isn't deleted
+		{
+			Num:      "3",
+			ID:       1,
+			Response: testProblem(http.StatusNotFound, "contract_not_found", ErrContractNotFound.Error(), "/contract/1/restore"),
+			Status:   http.StatusNotFound,
+			Models: testModelSet{
+				contract: test.TestContract{
+					CL: []*model.Contract{
+						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
+					},
+				},
+			},
+		},
+		// error handling
+		{
+			Num:      "4",
+			ID:       1,
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/contract/1/restore"),
+			Status:   http.StatusInternalServerError,
+			Models: testModelSet{
+				contract: test.TestContractErr{},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+
+		url := fmt.Sprintf("/contract/%d/restore", c.ID)
+		req := httptest.NewRequest("POST", url, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/contract/{id:[0-9]+}/restore", w, req, h.RestoreContract)
+		testCheckResponse("RestoreContract:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
 func TestPurchase(t *testing.T) {
 	time1 := time.Date(2000, 02, 01, 00, 00, 00, 0, time.UTC)
 	time2 := time.Date(2000, 04, 01, 00, 00, 00, 0, time.UTC)
@@ -784,7 +1130,7 @@ func TestPurchase(t *testing.T) {
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time2, 10},
+						{1, 10, 11, time1, time2, 10, 1, nil},
 					},
 				},
 				purchase: test.TestPurchase{
@@ -796,8 +1142,8 @@ func TestPurchase(t *testing.T) {
 		{
 			Num:      "2",
 			Request:  `{"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":10}`,
-			Response: ErrContractNotFound.Error() + "\n",
-			Status:   http.StatusBadRequest,
+			Response: testProblem(http.StatusUnprocessableEntity, "contract_not_found", ErrContractNotFound.Error(), "/purchase"),
+			Status:   http.StatusUnprocessableEntity,
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{},
@@ -811,12 +1157,12 @@ func TestPurchase(t *testing.T) {
 		{
 			Num:      "3",
 			Request:  `{"contractID":1,"datetime":"2000-01-01T00:00:00Z","amount":10}`,
-			Response: ErrDateNotValid.Error() + "\n",
-			Status:   http.StatusBadRequest,
+			Response: testProblem(http.StatusUnprocessableEntity, "purchase_date_not_valid", ErrDateNotValid.Error(), "/purchase"),
+			Status:   http.StatusUnprocessableEntity,
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time2, 10},
+						{1, 10, 11, time1, time2, 10, 1, nil},
 					},
 				},
 				purchase: test.TestPurchase{
@@ -828,12 +1174,12 @@ func TestPurchase(t *testing.T) {
 		{
 			Num:      "4",
 			Request:  `{"contractID":1,"datetime":"2000-05-01T00:00:00Z","amount":10}`,
-			Response: ErrDateNotValid.Error() + "\n",
-			Status:   http.StatusBadRequest,
+			Response: testProblem(http.StatusUnprocessableEntity, "purchase_date_not_valid", ErrDateNotValid.Error(), "/purchase"),
+			Status:   http.StatusUnprocessableEntity,
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time2, 10},
+						{1, 10, 11, time1, time2, 10, 1, nil},
 					},
 				},
 				purchase: test.TestPurchase{
@@ -845,12 +1191,12 @@ func TestPurchase(t *testing.T) {
 		{
 			Num:      "5",
 			Request:  `{"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":5}`,
-			Response: ErrNotEnoughMoney.Error() + "\n",
-			Status:   http.StatusInternalServerError,
+			Response: testProblem(http.StatusConflict, "not_enough_money", ErrNotEnoughMoney.Error(), "/purchase"),
+			Status:   http.StatusConflict,
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time2, 10},
+						{1, 10, 11, time1, time2, 10, 1, nil},
 					},
 				},
 				purchase: test.TestPurchase{
@@ -864,12 +1210,12 @@ func TestPurchase(t *testing.T) {
 		{
 			Num:      "6",
 			Request:  `{"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":5}`,
-			Response: ErrNotEnoughMoney.Error() + "\n",
-			Status:   http.StatusInternalServerError,
+			Response: testProblem(http.StatusConflict, "not_enough_money", ErrNotEnoughMoney.Error(), "/purchase"),
+			Status:   http.StatusConflict,
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time2, 10},
+						{1, 10, 11, time1, time2, 10, 1, nil},
 					},
 				},
 				purchase: test.TestPurchase{
@@ -884,12 +1230,12 @@ func TestPurchase(t *testing.T) {
 		{
 			Num:      "7",
 			Request:  `{"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":5}`,
-			Response: ErrNotEnoughMoney.Error() + "\n",
-			Status:   http.StatusInternalServerError,
+			Response: testProblem(http.StatusConflict, "not_enough_money", ErrNotEnoughMoney.Error(), "/purchase"),
+			Status:   http.StatusConflict,
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time2, 1},
+						{1, 10, 11, time1, time2, 1, 1, nil},
 					},
 				},
 				purchase: test.TestPurchase{
@@ -901,12 +1247,12 @@ func TestPurchase(t *testing.T) {
 		{
 			Num:      "8",
 			Request:  `{"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":5}`,
-			Response: test.ErrTest.Error() + "\n",
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/purchase"),
 			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time2, 10},
+						{1, 10, 11, time1, time2, 10, 1, nil},
 					},
 				},
 				purchase: test.TestPurchaseErr{},
@@ -915,7 +1261,7 @@ func TestPurchase(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := "/purchase"
 		req := httptest.NewRequest("POST", url, bytes.NewBuffer([]byte(c.Request)))
@@ -945,7 +1291,7 @@ func TestPurchaseHistory(t *testing.T) {
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10},
+						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
 					},
 				},
 				purchase: test.TestPurchase{
@@ -965,7 +1311,7 @@ func TestPurchaseHistory(t *testing.T) {
 			Models: testModelSet{
 				contract: test.TestContract{
 					CL: []*model.Contract{
-						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10},
+						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
 					},
 				},
 				purchase: test.TestPurchase{
@@ -977,17 +1323,32 @@ func TestPurchaseHistory(t *testing.T) {
 		{
 			Num:      "3",
 			ID:       1,
-			Response: ErrContractNotFound.Error() + "\n",
-			Status:   http.StatusNotFound,
+			Response: testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/contract/1/purchase"),
+			Status:   http.StatusInternalServerError,
 			Models: testModelSet{
 				contract: test.TestContractErr{},
 				purchase: test.TestPurchaseErr{},
 			},
 		},
+		// contract doesn't exist
+		{
+			Num:      "4",
+			ID:       2,
+			Response: testProblem(http.StatusNotFound, "contract_not_found", ErrContractNotFound.Error(), "/contract/2/purchase"),
+			Status:   http.StatusNotFound,
+			Models: testModelSet{
+				contract: test.TestContract{
+					CL: []*model.Contract{
+						{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
+					},
+				},
+				purchase: test.TestPurchase{},
+			},
+		},
 	}
 
 	for _, c := range cases {
-		h := testNewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
 
 		url := fmt.Sprintf("/contract/%d/purchase", c.ID)
 		req := httptest.NewRequest("GET", url, nil)
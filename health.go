@@ -0,0 +1,106 @@
+package gontracts
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// Readiness check results
+const (
+	checkOK   = "ok"
+	checkFail = "fail"
+)
+
+// ErrShuttingDown server is shutting down
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// ReadinessCheck is a named readiness probe.
+// Check returns nil if the dependency is ready
+type ReadinessCheck struct {
+	Name  string
+	Check func() error
+}
+
+// HealthResponse represents health probe response
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// HealthHandler handles liveness and readiness probes
+type HealthHandler struct {
+	checks       []ReadinessCheck
+	shuttingDown int32
+	log          *slog.Logger
+}
+
+// NewHealthHandler creates new health probe handler
+func NewHealthHandler(checks ...ReadinessCheck) *HealthHandler {
+	return &HealthHandler{
+		checks: checks,
+		log:    slog.Default(),
+	}
+}
+
+// SetLogger sets probe logger
+func (hh *HealthHandler) SetLogger(logger *slog.Logger) {
+	hh.log = logger
+}
+
+// SetShuttingDown makes readiness probe fail permanently
+func (hh *HealthHandler) SetShuttingDown() {
+	atomic.StoreInt32(&hh.shuttingDown, 1)
+}
+
+// Liveness reports that the process is alive
+func (hh *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	hh.write(w, r, http.StatusOK, &HealthResponse{Status: checkOK})
+}
+
+// Readiness reports whether the server is able to handle requests
+func (hh *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	resp := &HealthResponse{
+		Status: checkOK,
+		Checks: make(map[string]string, len(hh.checks)+1),
+	}
+
+	if atomic.LoadInt32(&hh.shuttingDown) != 0 {
+		resp.Status = checkFail
+		resp.Checks["shutdown"] = ErrShuttingDown.Error()
+	}
+
+	for _, c := range hh.checks {
+		if err := c.Check(); err != nil {
+			requestLogger(hh.log, r).Warn("readiness check failed", "check", c.Name, "error", err)
+			resp.Status = checkFail
+			resp.Checks[c.Name] = err.Error()
+			continue
+		}
+		resp.Checks[c.Name] = checkOK
+	}
+
+	status := http.StatusOK
+	if resp.Status != checkOK {
+		status = http.StatusServiceUnavailable
+	}
+	hh.write(w, r, status, resp)
+}
+
+func (hh *HealthHandler) write(w http.ResponseWriter, r *http.Request, status int, hr *HealthResponse) {
+	// fill response json
+	resp, err := json.Marshal(hr)
+	if err != nil {
+		requestLogger(hh.log, r).Error("request failed", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// setup response
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
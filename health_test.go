@@ -0,0 +1,116 @@
+package gontracts
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLiveness(t *testing.T) {
+	hh := NewHealthHandler(ReadinessCheck{"db", func() error { return errors.New("db is down") }})
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+
+	testHandle("/healthz", w, req, hh.Liveness)
+	testCheckResponse("Liveness", t, w, http.StatusOK, `{"status":"ok"}`)
+}
+
+func TestReadiness(t *testing.T) {
+	okCheck := func() error { return nil }
+	failCheck := func() error { return errors.New("db is down") }
+
+	cases := []struct {
+		Num          string
+		Checks       []ReadinessCheck
+		ShuttingDown bool
+		Response     string
+		Status       int
+	}{
+		// all checks passed
+		{
+			Num:      "1",
+			Checks:   []ReadinessCheck{{"db", okCheck}, {"signing-keys", okCheck}},
+			Response: `{"status":"ok","checks":{"db":"ok","signing-keys":"ok"}}`,
+			Status:   http.StatusOK,
+		},
+		// dependency failed
+		{
+			Num:      "2",
+			Checks:   []ReadinessCheck{{"db", failCheck}, {"signing-keys", okCheck}},
+			Response: `{"status":"fail","checks":{"db":"db is down","signing-keys":"ok"}}`,
+			Status:   http.StatusServiceUnavailable,
+		},
+		// shutdown in progress
+		{
+			Num:          "3",
+			Checks:       []ReadinessCheck{{"db", okCheck}},
+			ShuttingDown: true,
+			Response:     `{"status":"fail","checks":{"db":"ok","shutdown":"server is shutting down"}}`,
+			Status:       http.StatusServiceUnavailable,
+		},
+		// signing key isn't loaded
+		{
+			Num:      "4",
+			Checks:   []ReadinessCheck{{"signing-keys", NewAuthHandler(nil, nil).CheckSigningKey}},
+			Response: `{"status":"fail","checks":{"signing-keys":"token signing key is not loaded"}}`,
+			Status:   http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, c := range cases {
+		hh := NewHealthHandler(c.Checks...)
+		if c.ShuttingDown {
+			hh.SetShuttingDown()
+		}
+
+		req := httptest.NewRequest("GET", "/readyz", nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/readyz", w, req, hh.Readiness)
+		testCheckResponse("Readiness:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
+func TestReadinessDuringShutdown(t *testing.T) {
+	hh := NewHealthHandler()
+	s := &Server{
+		Addr:          "127.0.0.1:0",
+		ShutdownDelay: 500 * time.Millisecond,
+		health:        hh,
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.serve(http.HandlerFunc(hh.Readiness), func() error { return nil }) }()
+	<-s.Ready()
+	url := "http://" + s.ListenAddr().String()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("[ReadinessDuringShutdown]:\twrong StatusCode before shutdown: got %d, expected %d",
+			resp.StatusCode, http.StatusOK)
+	}
+
+	go s.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	// listener is still open during shutdown delay, but the server isn't ready anymore
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("[ReadinessDuringShutdown]:\twrong StatusCode during shutdown: got %d, expected %d",
+			resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("[ReadinessDuringShutdown]:\tserve returned error: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package gontracts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader is a request header making POST requests safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks response replayed for a repeated idempotency key
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// DefaultIdempotencyTTL is a time responses are kept for repeated requests
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+const (
+	// maxIdempotentBody limits size of request body buffered to fingerprint the request
+	maxIdempotentBody = maxImportSize
+	// maxIdempotentEntries limits number of stored and in-flight keys
+	maxIdempotentEntries = 10000
+	// maxIdempotentSize limits total size of stored response bodies
+	maxIdempotentSize = 64 << 20
+	// maxIdempotentResponse limits size of a single stored response body, larger responses aren't stored
+	maxIdempotentResponse = 4 << 20
+)
+
+var (
+	// ErrIdempotencyKeyReused idempotency key was used for a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key is already used for a different request")
+	// ErrIdempotencyKeyInUse request with the same idempotency key is in progress
+	ErrIdempotencyKeyInUse = errors.New("request with the same idempotency key is in progress")
+	// ErrIdempotencyStoreFull too many requests with idempotency keys are in progress
+	ErrIdempotencyStoreFull = errors.New("too many requests with idempotency keys are in progress")
+	// ErrRequestTooLarge request body exceeds size limit
+	ErrRequestTooLarge = errors.New("request body is too large")
+)
+
+// Idempotency replays responses of POST requests repeated with the same Idempotency-Key header.
+// Keys are scoped by authenticated subject, method and path.
+// The store is bounded by number of keys and size of responses, the oldest responses are evicted first
+type Idempotency struct {
+	mx         sync.Mutex
+	ttl        time.Duration
+	entries    map[string]*idempotentResponse
+	stored     []storedKey
+	size       int
+	maxEntries int
+	maxSize    int
+}
+
+// storedKey is a completed key in order of storing
+type storedKey struct {
+	key   string
+	entry *idempotentResponse
+}
+
+// idempotentResponse is a response of the first request with the key
+type idempotentResponse struct {
+	fingerprint string
+	done        bool
+	expires     time.Time
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// NewIdempotency creates in-memory idempotency key store
+func NewIdempotency(ttl time.Duration) *Idempotency {
+	return &Idempotency{
+		ttl:        ttl,
+		entries:    make(map[string]*idempotentResponse),
+		maxEntries: maxIdempotentEntries,
+		maxSize:    maxIdempotentSize,
+	}
+}
+
+// Middleware replays stored response if request is repeated.
+// It runs after authentication, see AuthHandler.SetIdempotency
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			writeProblem(w, r, ErrRequestTooLarge)
+			return
+		}
+		if err != nil {
+			writeProblem(w, r, badRequest(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key = idempotencyScope(r, key)
+		fingerprint := hashOf(body)
+
+		entry, err := i.begin(key, fingerprint)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		if entry != nil {
+			for k, v := range entry.header {
+				if k != RequestIDHeader {
+					w.Header()[k] = v
+				}
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(entry.status)
+			w.Write(entry.body)
+			return
+		}
+
+		rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// panicked request releases the key
+			if !completed {
+				rw.status = http.StatusInternalServerError
+			}
+			i.finish(key, fingerprint, rw)
+		}()
+		next.ServeHTTP(rw, r)
+		completed = true
+	})
+}
+
+// begin returns stored response of the key or reserves the key for a new request
+func (i *Idempotency) begin(key, fingerprint string) (*idempotentResponse, error) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	// responses are stored in order of expiration
+	now := time.Now()
+	for len(i.stored) > 0 && now.After(i.stored[0].entry.expires) {
+		i.evict()
+	}
+
+	entry, ok := i.entries[key]
+	switch {
+	case !ok:
+		for len(i.entries) >= i.maxEntries && len(i.stored) > 0 {
+			i.evict()
+		}
+		if len(i.entries) >= i.maxEntries {
+			return nil, ErrIdempotencyStoreFull
+		}
+		i.entries[key] = &idempotentResponse{fingerprint: fingerprint}
+		return nil, nil
+	case entry.fingerprint != fingerprint:
+		return nil, ErrIdempotencyKeyReused
+	case !entry.done:
+		return nil, ErrIdempotencyKeyInUse
+	}
+	return entry, nil
+}
+
+// evict removes the oldest stored response
+func (i *Idempotency) evict() {
+	s := i.stored[0]
+	i.stored = i.stored[1:]
+	if i.entries[s.key] == s.entry {
+		delete(i.entries, s.key)
+	}
+	i.size -= len(s.entry.body)
+}
+
+// finish stores response of the request.
+// Failures that may succeed on retry and too large responses release the key instead
+func (i *Idempotency) finish(key, fingerprint string, rw *recordingWriter) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	switch {
+	case rw.status >= http.StatusInternalServerError,
+		rw.status == http.StatusUnauthorized,
+		rw.status == http.StatusForbidden,
+		rw.body.Len() > maxIdempotentResponse:
+		delete(i.entries, key)
+		return
+	}
+	entry := &idempotentResponse{
+		fingerprint: fingerprint,
+		done:        true,
+		expires:     time.Now().Add(i.ttl),
+		status:      rw.status,
+		header:      rw.Header().Clone(),
+		body:        rw.body.Bytes(),
+	}
+	i.entries[key] = entry
+	i.stored = append(i.stored, storedKey{key, entry})
+	i.size += len(entry.body)
+	for i.size > i.maxSize {
+		i.evict()
+	}
+}
+
+// idempotencyScope binds idempotency key to authenticated subject and target.
+// Subject doesn't change when client renews its token, so a retry with the new token is still a repeat
+func idempotencyScope(r *http.Request, key string) string {
+	var subject string
+	if id := IdentityFromContext(r.Context()); id != nil {
+		subject = id.Subject
+	}
+	return hashOf([]byte(subject)) + " " + r.Method + " " + r.URL.Path + " " + key
+}
+func hashOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordingWriter remembers response status code and body
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
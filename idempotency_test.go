@@ -0,0 +1,130 @@
+package gontracts
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls int
+	status := http.StatusCreated
+	h := NewIdempotency(time.Hour).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"ID":` + strconv.Itoa(calls) + `}`))
+	}))
+
+	type testCase struct {
+		Num      string
+		Method   string
+		Key      string
+		Subject  string
+		Body     string
+		Status   int
+		Response string
+		Replayed bool
+		Calls    int
+	}
+
+	cases := []testCase{
+		{"1", "POST", "k1", "a", `{"amount":1}`, http.StatusCreated, `{"ID":1}`, false, 1},
+		// repeated request is replayed
+		{"2", "POST", "k1", "a", `{"amount":1}`, http.StatusCreated, `{"ID":1}`, true, 1},
+		// the same key of another subject is another request
+		{"3", "POST", "k1", "b", `{"amount":1}`, http.StatusCreated, `{"ID":2}`, false, 2},
+		{"4", "POST", "k1", "a", `{"amount":2}`, http.StatusUnprocessableEntity,
+			testProblem(http.StatusUnprocessableEntity, "idempotency_key_reused", ErrIdempotencyKeyReused.Error(), "/purchase"), false, 2},
+		// requests without key or other methods aren't stored
+		{"5", "POST", "", "a", `{"amount":1}`, http.StatusCreated, `{"ID":3}`, false, 3},
+		{"6", "PUT", "k1", "a", `{"amount":1}`, http.StatusCreated, `{"ID":4}`, false, 4},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.Method, "/purchase", bytes.NewBufferString(c.Body))
+		req = req.WithContext(WithIdentity(req.Context(), &Identity{Subject: c.Subject}))
+		if c.Key != "" {
+			req.Header.Set(IdempotencyKeyHeader, c.Key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		testCheckResponse("Idempotency:"+c.Num, t, w, c.Status, c.Response)
+		if replayed := w.Header().Get(IdempotentReplayedHeader) == "true"; replayed != c.Replayed {
+			t.Errorf("[Idempotency:%s]:\twrong replay: got %t, expected %t", c.Num, replayed, c.Replayed)
+		}
+		if calls != c.Calls {
+			t.Errorf("[Idempotency:%s]:\twrong handler calls: got %d, expected %d", c.Num, calls, c.Calls)
+		}
+	}
+
+	// failed request may be retried with the same key
+	status = http.StatusServiceUnavailable
+	for i, exp := range []int{http.StatusServiceUnavailable, http.StatusCreated, http.StatusCreated} {
+		req, _ := http.NewRequest("POST", "/purchase", bytes.NewBufferString(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "k2")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != exp {
+			t.Errorf("[Idempotency:retry %d]:\twrong StatusCode: got %d, expected %d", i, w.Code, exp)
+		}
+		status = http.StatusCreated
+	}
+	if calls != 6 {
+		t.Errorf("[Idempotency:retry]:\twrong handler calls: got %d, expected 6", calls)
+	}
+}
+
+func TestIdempotencyLimits(t *testing.T) {
+	var calls int
+	i := NewIdempotency(time.Hour)
+	i.maxEntries = 3
+	i.maxSize = 20
+	h := i.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ID":` + strconv.Itoa(calls) + `}`))
+	}))
+	post := func(key string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/purchase", bytes.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// the oldest response is evicted when the store is over size
+	post("k1", []byte(`{}`))
+	post("k2", []byte(`{}`))
+	post("k3", []byte(`{}`))
+	if w := post("k2", []byte(`{}`)); w.Header().Get(IdempotentReplayedHeader) != "true" || calls != 3 {
+		t.Errorf("[IdempotencyLimits:size]:\tstored response isn't replayed, handler calls %d", calls)
+	}
+	if w := post("k1", []byte(`{}`)); w.Header().Get(IdempotentReplayedHeader) != "" || calls != 4 {
+		t.Errorf("[IdempotencyLimits:size]:\tevicted response is replayed, handler calls %d", calls)
+	}
+
+	// the oldest response is evicted when there are too many keys
+	i.maxSize = 100
+	post("k4", []byte(`{}`))
+	post("k5", []byte(`{}`))
+	if len(i.entries) != i.maxEntries {
+		t.Errorf("[IdempotencyLimits:entries]:\tgot %d entries, expected %d", len(i.entries), i.maxEntries)
+	}
+	if w := post("k3", []byte(`{}`)); w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("[IdempotencyLimits:entries]:\tevicted response is replayed")
+	}
+
+	// request body is limited before it's buffered
+	calls = 0
+	w := post("k6", make([]byte, maxIdempotentBody+1))
+	testCheckResponse("IdempotencyLimits:body", t, w, http.StatusRequestEntityTooLarge,
+		testProblem(http.StatusRequestEntityTooLarge, "request_too_large", ErrRequestTooLarge.Error(), "/purchase"))
+	if calls != 0 {
+		t.Errorf("[IdempotencyLimits:body]:\twrong handler calls: got %d, expected 0", calls)
+	}
+}
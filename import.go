@@ -0,0 +1,329 @@
+package gontracts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/service"
+)
+
+// Media types of bulk import and export
+const (
+	CSVContentType       = "text/csv"
+	JSONLinesContentType = "application/x-ndjson"
+)
+
+// Kinds of bulk imported and exported items
+const (
+	KindCompany  = "company"
+	KindContract = "contract"
+	KindPurchase = "purchase"
+)
+
+const (
+	// maxImportRows limits number of rows in a single import
+	maxImportRows = 10000
+	// maxImportSize limits size of import source
+	maxImportSize = 32 << 20
+)
+
+var (
+	// ErrImportKind imported items are neither companies nor contracts
+	ErrImportKind = errors.New("import kind must be " + KindCompany + " or " + KindContract)
+	// ErrImportContentType import source has unsupported media type
+	ErrImportContentType = errors.New("import content type must be " + CSVContentType + " or " + JSONLinesContentType)
+	// ErrImportEmpty import source has no rows
+	ErrImportEmpty = errors.New("import has no rows")
+	// ErrImportTooLarge import source has too many rows
+	ErrImportTooLarge = errors.New("import has more than " + strconv.Itoa(maxImportRows) + " rows")
+	// ErrImportFieldCount CSV row has different number of fields than the header
+	ErrImportFieldCount = errors.New("row has wrong number of fields")
+)
+
+// ImportOptions control bulk import
+type ImportOptions = service.ImportOptions
+
+// ImportResult is a report of bulk import
+type ImportResult struct {
+	DryRun  bool             `json:"dryRun"`
+	Atomic  bool             `json:"atomic"`
+	Total   int              `json:"total"`
+	Valid   int              `json:"valid"`
+	Created []ImportedRow    `json:"created"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// ImportedRow is an id of item created from import row
+type ImportedRow struct {
+	Row int `json:"row"`
+	ID  int
+}
+
+// ImportRowError describes failed import row
+type ImportRowError struct {
+	Row    int          `json:"row"`
+	Code   string       `json:"code"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// importDoc is a generic JSON document of import row
+type importDoc struct {
+	row int
+	doc any
+	err error
+}
+
+// ImportItems reads companies or contracts from source of media type and imports them.
+// Rows are validated with the same rules as single items
+func (h *Handler) ImportItems(ctx context.Context, kind, mediaType string, src io.Reader, opts ImportOptions) (*ImportResult, error) {
+	var def string
+	switch kind {
+	case KindCompany:
+		def = "CompanyRequest"
+	case KindContract:
+		def = "ContractRequest"
+	default:
+		return nil, badRequest(ErrImportKind)
+	}
+
+	// read generic rows of the source
+	var docs []importDoc
+	var err error
+	switch mediaType {
+	case CSVContentType:
+		docs, err = readCSVRows(src, def)
+	case JSONLinesContentType, "application/jsonl":
+		docs, err = readJSONLines(src)
+	default:
+		return nil, ErrImportContentType
+	}
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return nil, ErrImportTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	// decode rows and import them
+	var report *service.ImportReport
+	switch kind {
+	case KindCompany:
+		report, err = h.svc.ImportCompanies(ctx, decodeRows[model.Company](docs, def), opts)
+	case KindContract:
+		report, err = h.svc.ImportContracts(ctx, decodeRows[model.Contract](docs, def), opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImportResult{
+		DryRun:  opts.DryRun,
+		Atomic:  opts.Atomic,
+		Total:   len(docs),
+		Valid:   report.Valid,
+		Created: make([]ImportedRow, 0, len(report.Created)),
+		Errors:  make([]ImportRowError, 0, len(report.Errors)),
+	}
+	for _, c := range report.Created {
+		res.Created = append(res.Created, ImportedRow{c.Row, c.ID})
+	}
+	for _, e := range report.Errors {
+		ae := toAPIError(e.Err)
+		re := ImportRowError{Row: e.Row, Code: ae.Code, Detail: ae.Error()}
+		var ve *ValidationError
+		if errors.As(e.Err, &ve) {
+			re.Errors = ve.Fields
+		}
+		res.Errors = append(res.Errors, re)
+	}
+	return res, nil
+}
+
+// decodeRows validates documents against spec definition and decodes them into items
+func decodeRows[T any](docs []importDoc, def string) []service.ImportRow[T] {
+	rows := make([]service.ImportRow[T], len(docs))
+	for i, d := range docs {
+		rows[i] = service.ImportRow[T]{Row: d.row, Err: d.err}
+		if d.err != nil {
+			continue
+		}
+		item := new(T)
+		if err := decodeDocument(d.doc, def, item); err != nil {
+			rows[i].Err = err
+			continue
+		}
+		rows[i].Item = item
+	}
+	return rows
+}
+
+// readJSONLines reads a JSON document per non-empty line
+func readJSONLines(src io.Reader) ([]importDoc, error) {
+	var docs []importDoc
+	sc := bufio.NewScanner(src)
+	sc.Buffer(nil, maxImportSize)
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		if len(docs) == maxImportRows {
+			return nil, ErrImportTooLarge
+		}
+
+		d := importDoc{row: line}
+		dc := json.NewDecoder(bytes.NewReader(b))
+		dc.UseNumber()
+		if err := dc.Decode(&d.doc); err != nil {
+			d.err = badRequest(err)
+		}
+		docs = append(docs, d)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, badRequest(err)
+	}
+	return docs, nil
+}
+
+// readCSVRows reads CSV with a header of field names of spec definition
+func readCSVRows(src io.Reader, def string) ([]importDoc, error) {
+	defs, err := specDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(src)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, badRequest(err)
+	}
+
+	var docs []importDoc
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, badRequest(err)
+		}
+		if len(docs) == maxImportRows {
+			return nil, ErrImportTooLarge
+		}
+
+		line, _ := cr.FieldPos(0)
+		d := importDoc{row: line}
+		if len(rec) != len(header) {
+			d.err = badRequest(ErrImportFieldCount)
+		} else {
+			d.doc = csvDocument(header, rec, defs[def])
+		}
+		docs = append(docs, d)
+	}
+	return docs, nil
+}
+
+// csvDocument converts CSV record into JSON document.
+// Values of numeric properties become numbers, empty values are omitted
+func csvDocument(header, rec []string, s *schema) map[string]any {
+	doc := make(map[string]any, len(header))
+	for i, name := range header {
+		v := rec[i]
+		if v == "" {
+			continue
+		}
+		doc[name] = v
+		if p := s.Properties[name]; p != nil && (p.Type == "integer" || p.Type == "number") {
+			if _, err := strconv.ParseFloat(v, 64); err == nil && json.Valid([]byte(v)) {
+				doc[name] = json.Number(v)
+			}
+		}
+	}
+	return doc
+}
+
+// parseImportOptions reads import options from request query, import is atomic by default
+func parseImportOptions(v url.Values) (ImportOptions, error) {
+	opts := ImportOptions{Atomic: true}
+	for name, opt := range map[string]*bool{"dryRun": &opts.DryRun, "atomic": &opts.Atomic} {
+		if s := v.Get(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return opts, err
+			}
+			*opt = b
+		}
+	}
+	return opts, nil
+}
+
+// Import creates companies or contracts in bulk from CSV or JSON Lines request body
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	kind := mux.Vars(r)["kind"]
+
+	// get import options from request query
+	opts, err := parseImportOptions(r.URL.Query())
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, badRequest(err))
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, ErrImportContentType)
+		return
+	}
+
+	// validate and import rows of request body
+	res, err := h.ImportItems(r.Context(), kind, mediaType, http.MaxBytesReader(w, r.Body, maxImportSize), opts)
+	if err != nil {
+		h.logError(r, err, "import", kind)
+		writeProblem(w, r, err)
+		return
+	}
+
+	status := http.StatusCreated
+	switch {
+	case opts.DryRun:
+		status = http.StatusOK
+	case len(res.Created) == 0:
+		// nothing is imported because of invalid rows
+		status = http.StatusUnprocessableEntity
+	}
+
+	// fill response json
+	resp, err := json.Marshal(res)
+	if err != nil {
+		h.logError(r, err)
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
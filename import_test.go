@@ -0,0 +1,114 @@
+package gontracts
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	const companies = "name,regcode\nNewcom,NEW1\n\" \",\nOtherco,\n"
+
+	cases := []struct {
+		Num         string
+		URL         string
+		ContentType string
+		Body        string
+		Response    string
+		Status      int
+	}{
+		// atomic import of valid rows
+		{
+			Num:         "1",
+			URL:         "/import/company",
+			ContentType: "text/csv; charset=utf-8",
+			Body:        "name,regcode\nNewcom,NEW1\nOtherco,\n",
+			Response:    `{"dryRun":false,"atomic":true,"total":2,"valid":2,"created":[{"row":2,"ID":4},{"row":3,"ID":5}],"errors":[]}`,
+			Status:      http.StatusCreated,
+		},
+		// atomic import with invalid row creates nothing
+		{
+			Num:         "2",
+			URL:         "/import/company",
+			ContentType: CSVContentType,
+			Body:        companies,
+			Response:    `{"dryRun":false,"atomic":true,"total":3,"valid":2,"created":[],"errors":[{"row":3,"code":"validation_failed","detail":"request body is not valid","errors":[{"field":"name","reason":"must not be blank"}]}]}`,
+			Status:      http.StatusUnprocessableEntity,
+		},
+		// non-atomic import skips invalid row
+		{
+			Num:         "3",
+			URL:         "/import/company?atomic=false",
+			ContentType: CSVContentType,
+			Body:        companies,
+			Response:    `{"dryRun":false,"atomic":false,"total":3,"valid":2,"created":[{"row":2,"ID":4},{"row":4,"ID":4}],"errors":[{"row":3,"code":"validation_failed","detail":"request body is not valid","errors":[{"field":"name","reason":"must not be blank"}]}]}`,
+			Status:      http.StatusCreated,
+		},
+		// dry run only validates rows
+		{
+			Num:         "4",
+			URL:         "/import/company?dryRun=true",
+			ContentType: CSVContentType,
+			Body:        companies,
+			Response:    `{"dryRun":true,"atomic":true,"total":3,"valid":2,"created":[],"errors":[{"row":3,"code":"validation_failed","detail":"request body is not valid","errors":[{"field":"name","reason":"must not be blank"}]}]}`,
+			Status:      http.StatusOK,
+		},
+		// contracts are checked as on creation
+		{
+			Num:         "5",
+			URL:         "/import/contract",
+			ContentType: JSONLinesContentType,
+			Body: `{"sellerID":1,"clientID":2,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}` + "\n\n" +
+				`{"sellerID":1,"clientID":9,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10}` + "\n" +
+				`{"sellerID":` + "\n",
+			Response: `{"dryRun":false,"atomic":true,"total":3,"valid":1,"created":[],"errors":[{"row":3,"code":"client_not_found","detail":"client company doesn't exist"},{"row":4,"code":"invalid_request","detail":"unexpected EOF"}]}`,
+			Status:   http.StatusUnprocessableEntity,
+		},
+		{
+			Num:         "6",
+			URL:         "/import/contract?dryRun=1",
+			ContentType: CSVContentType,
+			Body:        "sellerID,clientID,validFrom,validTo,amount\n1,2,2000-01-01T00:00:00Z,2001-01-01T00:00:00Z,10\nx,2,2000-01-01T00:00:00Z,2001-01-01T00:00:00Z,10\n1,2\n",
+			Response:    `{"dryRun":true,"atomic":true,"total":3,"valid":1,"created":[],"errors":[{"row":3,"code":"validation_failed","detail":"request body is not valid","errors":[{"field":"sellerID","reason":"must be a number"}]},{"row":4,"code":"invalid_request","detail":"row has wrong number of fields"}]}`,
+			Status:      http.StatusOK,
+		},
+		// request errors
+		{
+			Num:         "7",
+			URL:         "/import/company",
+			ContentType: "application/json",
+			Body:        `{"name":"Newcom"}`,
+			Response:    testProblem(http.StatusUnsupportedMediaType, "unsupported_media_type", ErrImportContentType.Error(), "/import/company"),
+			Status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			Num:         "8",
+			URL:         "/import/company",
+			ContentType: CSVContentType,
+			Body:        "name,regcode\n",
+			Response:    testProblem(http.StatusBadRequest, "import_empty", ErrImportEmpty.Error(), "/import/company"),
+			Status:      http.StatusBadRequest,
+		},
+		{
+			Num:         "9",
+			URL:         "/import/company?dryRun=maybe",
+			ContentType: CSVContentType,
+			Body:        companies,
+			Response:    testProblem(http.StatusBadRequest, "invalid_request", `strconv.ParseBool: parsing "maybe": invalid syntax`, "/import/company"),
+			Status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		ms := testClientModels()
+		h := NewHandler(ms.company, ms.contract, ms.purchase)
+
+		req := httptest.NewRequest("POST", c.URL, bytes.NewBufferString(c.Body))
+		req.Header.Set("Content-Type", c.ContentType)
+		w := httptest.NewRecorder()
+
+		testHandle("/import/{kind:company|contract}", w, req, h.Import)
+		testCheckResponse("Import:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
@@ -0,0 +1,180 @@
+package gontracts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// NextCursorHeader contains cursor of the next list page
+const NextCursorHeader = "X-Next-Cursor"
+
+// ErrSortField sort field isn't supported by the list
+var ErrSortField = errors.New("unknown sort field")
+
+// Sort fields of lists
+var (
+	companySortFields  = []string{"ID", "name"}
+	contractSortFields = []string{"ID", "validFrom", "validTo", "amount"}
+	purchaseSortFields = []string{"ID", "datetime", "amount"}
+)
+
+// parseCompanyQuery reads company list request from URL query
+func parseCompanyQuery(v url.Values) (q model.CompanyQuery, err error) {
+	if q.IncludeDeleted, err = parseBool(v, "includeDeleted"); err != nil {
+		return q, err
+	}
+	if q.Sort, err = parseSort(v, companySortFields); err != nil {
+		return q, err
+	}
+	q.Page, err = parsePage(v)
+	return q, err
+}
+
+// parseContractQuery reads contract list request from URL query
+func parseContractQuery(v url.Values) (q model.ContractQuery, err error) {
+	if q.SellerID, err = parseInt(v, "seller"); err != nil {
+		return q, err
+	}
+	if q.ClientID, err = parseInt(v, "client"); err != nil {
+		return q, err
+	}
+	if q.ActiveFrom, err = parseTime(v, "activeFrom"); err != nil {
+		return q, err
+	}
+	if q.ActiveTo, err = parseTime(v, "activeTo"); err != nil {
+		return q, err
+	}
+	if q.IncludeDeleted, err = parseBool(v, "includeDeleted"); err != nil {
+		return q, err
+	}
+	if q.Sort, err = parseSort(v, contractSortFields); err != nil {
+		return q, err
+	}
+	q.Page, err = parsePage(v)
+	return q, err
+}
+
+// parsePurchaseQuery reads purchase history request from URL query
+func parsePurchaseQuery(v url.Values) (q model.PurchaseQuery, err error) {
+	if q.From, err = parseTime(v, "from"); err != nil {
+		return q, err
+	}
+	if q.To, err = parseTime(v, "to"); err != nil {
+		return q, err
+	}
+	if q.MinAmount, err = parseIntPtr(v, "minAmount"); err != nil {
+		return q, err
+	}
+	if q.MaxAmount, err = parseIntPtr(v, "maxAmount"); err != nil {
+		return q, err
+	}
+	if q.Sort, err = parseSort(v, purchaseSortFields); err != nil {
+		return q, err
+	}
+	q.Page, err = parsePage(v)
+	return q, err
+}
+
+// parsePage reads limit and cursor params.
+// Limit over model.MaxLimit is cut down
+func parsePage(v url.Values) (model.Page, error) {
+	p := model.Page{Cursor: v.Get("cursor")}
+	limit, err := parseInt(v, "limit")
+	if err != nil {
+		return p, err
+	}
+	if limit < 0 {
+		return p, fmt.Errorf("limit: must not be negative")
+	}
+	if limit > model.MaxLimit {
+		limit = model.MaxLimit
+	}
+	p.Limit = limit
+	return p, nil
+}
+
+// parseSort reads sort param, descending order is prefixed with "-"
+func parseSort(v url.Values, fields []string) (model.Sort, error) {
+	sort := v.Get("sort")
+	if sort == "" {
+		return model.Sort{}, nil
+	}
+	s := model.Sort{
+		Field: strings.TrimPrefix(sort, "-"),
+		Desc:  strings.HasPrefix(sort, "-"),
+	}
+	for _, f := range fields {
+		if f == s.Field {
+			return s, nil
+		}
+	}
+	return s, fmt.Errorf("%w %q, expected one of %s", ErrSortField, s.Field, strings.Join(fields, ", "))
+}
+
+// parseInt reads integer param, zero if it's not set
+func parseInt(v url.Values, name string) (int, error) {
+	p, err := parseIntPtr(v, name)
+	if p == nil {
+		return 0, err
+	}
+	return *p, nil
+}
+
+// parseIntPtr reads integer param, nil if it's not set
+func parseIntPtr(v url.Values, name string) (*int, error) {
+	s := v.Get(name)
+	if s == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is not an integer", name, s)
+	}
+	return &i, nil
+}
+
+// parseBool reads boolean param, false if it's not set
+func parseBool(v url.Values, name string) (bool, error) {
+	s := v.Get(name)
+	if s == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%s: %q is not a boolean", name, s)
+	}
+	return b, nil
+}
+
+// parseTime reads RFC 3339 time param, nil if it's not set
+func parseTime(v url.Values, name string) (*time.Time, error) {
+	s := v.Get(name)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is not an RFC 3339 time", name, s)
+	}
+	return &t, nil
+}
+
+// setNextPage adds next page cursor and Link header to the response
+func setNextPage(w http.ResponseWriter, r *http.Request, next string) {
+	if next == "" {
+		return
+	}
+	q := r.URL.Query()
+	q.Set("cursor", next)
+	u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+
+	w.Header().Set(NextCursorHeader, next)
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, u.String()))
+}
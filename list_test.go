@@ -0,0 +1,214 @@
+package gontracts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func TestListPage(t *testing.T) {
+	company := test.TestCompany{
+		CL: []*model.Company{
+			{1, "test1", nil, 1, nil},
+			{2, "test2", nil, 1, nil},
+			{3, "test3", nil, 1, nil},
+		},
+	}
+
+	cases := []struct {
+		Num      string
+		URL      string
+		Response string
+		Status   int
+		Link     string
+	}{
+		// first page
+		{
+			Num:      "1",
+			URL:      "/company?limit=2&sort=name",
+			Response: `[{"ID":1,"name":"test1","regcode":null},{"ID":2,"name":"test2","regcode":null}]`,
+			Status:   http.StatusOK,
+			Link:     `</company?cursor=2&limit=2&sort=name>; rel="next"`,
+		},
+		// last page
+		{
+			Num:      "2",
+			URL:      "/company?limit=2&sort=name&cursor=2",
+			Response: `[{"ID":3,"name":"test3","regcode":null}]`,
+			Status:   http.StatusOK,
+		},
+		// limit over maximum is cut down
+		{
+			Num:      "3",
+			URL:      "/company?limit=100000",
+			Response: `[{"ID":1,"name":"test1","regcode":null},{"ID":2,"name":"test2","regcode":null},{"ID":3,"name":"test3","regcode":null}]`,
+			Status:   http.StatusOK,
+		},
+		// malformed limit
+		{
+			Num:      "4",
+			URL:      "/company?limit=ten",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, `limit: "ten" is not an integer`, "/company"),
+			Status:   http.StatusBadRequest,
+		},
+		// negative limit
+		{
+			Num:      "5",
+			URL:      "/company?limit=-1",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, "limit: must not be negative", "/company"),
+			Status:   http.StatusBadRequest,
+		},
+		// unknown sort field
+		{
+			Num:      "6",
+			URL:      "/company?sort=-regcode",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, `unknown sort field "regcode", expected one of ID, name`, "/company"),
+			Status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(company, nil, nil)
+
+		req := httptest.NewRequest("GET", c.URL, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/company", w, req, h.GetCompanyList)
+		testCheckResponse("ListPage:"+c.Num, t, w, c.Status, c.Response)
+
+		if link := w.Header().Get("Link"); link != c.Link {
+			t.Errorf("[ListPage:%s]:\twrong Link: got %s, expected %s", c.Num, link, c.Link)
+		}
+	}
+}
+
+func TestContractListFilter(t *testing.T) {
+	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
+	contract := test.TestContract{
+		CL: []*model.Contract{
+			{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
+			{2, 10, 12, time1.AddDate(2, 0, 0), time1.AddDate(3, 0, 0), 20, 1, nil},
+			{3, 12, 11, time1, time1.AddDate(3, 0, 0), 30, 1, nil},
+			{4, 12, 10, time1, time1.AddDate(1, 0, 0), 40, 1, &time1},
+		},
+	}
+
+	cases := []struct {
+		Num      string
+		URL      string
+		Response string
+		Status   int
+	}{
+		// by seller
+		{
+			Num:      "1",
+			URL:      "/contract?seller=10",
+			Response: `[{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":10},{"ID":2,"sellerID":10,"clientID":12,"validFrom":"2002-01-01T00:00:00Z","validTo":"2003-01-01T00:00:00Z","amount":20}]`,
+			Status:   http.StatusOK,
+		},
+		// by client and validity window
+		{
+			Num:      "2",
+			URL:      "/contract?client=11&activeFrom=2002-06-01T00:00:00Z&activeTo=2002-07-01T00:00:00Z",
+			Response: `[{"ID":3,"sellerID":12,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2003-01-01T00:00:00Z","amount":30}]`,
+			Status:   http.StatusOK,
+		},
+		// malformed time
+		{
+			Num:      "3",
+			URL:      "/contract?activeFrom=yesterday",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, `activeFrom: "yesterday" is not an RFC 3339 time`, "/contract"),
+			Status:   http.StatusBadRequest,
+		},
+		// with deleted ones
+		{
+			Num:      "4",
+			URL:      "/contract?seller=12&includeDeleted=true",
+			Response: `[{"ID":3,"sellerID":12,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2003-01-01T00:00:00Z","amount":30},{"ID":4,"sellerID":12,"clientID":10,"validFrom":"2000-01-01T00:00:00Z","validTo":"2001-01-01T00:00:00Z","amount":40,"deletedAt":"2000-01-01T00:00:00Z"}]`,
+			Status:   http.StatusOK,
+		},
+		// malformed flag
+		{
+			Num:      "5",
+			URL:      "/contract?includeDeleted=maybe",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, `includeDeleted: "maybe" is not a boolean`, "/contract"),
+			Status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(nil, contract, nil)
+
+		req := httptest.NewRequest("GET", c.URL, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/contract", w, req, h.GetContractList)
+		testCheckResponse("ContractListFilter:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
+func TestPurchaseHistoryFilter(t *testing.T) {
+	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
+	contract := test.TestContract{
+		CL: []*model.Contract{
+			{1, 10, 11, time1, time1.AddDate(1, 0, 0), 100, 1, nil},
+		},
+	}
+	purchase := test.TestPurchase{
+		CL: []*model.Purchase{
+			{1, 1, time1, 3},
+			{2, 1, time1.AddDate(0, 1, 0), 5},
+			{3, 1, time1.AddDate(0, 2, 0), 7},
+		},
+	}
+
+	cases := []struct {
+		Num      string
+		URL      string
+		Response string
+		Status   int
+	}{
+		// by date range
+		{
+			Num:      "1",
+			URL:      "/contract/1/purchase?from=2000-01-15T00:00:00Z&to=2000-02-15T00:00:00Z",
+			Response: `[{"ID":2,"contractID":1,"datetime":"2000-02-01T00:00:00Z","amount":5}]`,
+			Status:   http.StatusOK,
+		},
+		// by amount
+		{
+			Num:      "2",
+			URL:      "/contract/1/purchase?minAmount=4&maxAmount=10",
+			Response: `[{"ID":2,"contractID":1,"datetime":"2000-02-01T00:00:00Z","amount":5},{"ID":3,"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":7}]`,
+			Status:   http.StatusOK,
+		},
+		// nothing matches filter of existing contract
+		{
+			Num:      "3",
+			URL:      "/contract/1/purchase?minAmount=100",
+			Response: `[]`,
+			Status:   http.StatusOK,
+		},
+		// malformed amount
+		{
+			Num:      "4",
+			URL:      "/contract/1/purchase?maxAmount=many",
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, `maxAmount: "many" is not an integer`, "/contract/1/purchase"),
+			Status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(nil, contract, purchase)
+
+		req := httptest.NewRequest("GET", c.URL, nil)
+		w := httptest.NewRecorder()
+
+		testHandle("/contract/{id:[0-9]+}/purchase", w, req, h.GetPurchaseHistory)
+		testCheckResponse("PurchaseHistoryFilter:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
@@ -0,0 +1,125 @@
+package gontracts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is a request correlation header
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen limits length of request ID accepted from client
+const maxRequestIDLen = 128
+
+type requestInfoKey struct{}
+
+// requestInfo is a mutable request state shared between middlewares
+type requestInfo struct {
+	id      string
+	subject string
+	traceID string
+}
+
+// NewLogger creates JSON logger writing records of level and above
+func NewLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+	}))
+}
+
+// RequestIDFromContext returns correlation ID of the request or empty string
+func RequestIDFromContext(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// RequestID propagates X-Request-ID header of the request or generates a new one
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestInfoKey{}, &requestInfo{id: id})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AccessLog logs every request with its status, latency and token subject
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(sw, r)
+
+			requestLogger(logger, r).Info("access",
+				"status", sw.status,
+				"latency", time.Since(start),
+				"remote", r.RemoteAddr,
+			)
+		})
+	}
+}
+
+// requestLogger returns logger with request correlation fields
+func requestLogger(logger *slog.Logger, r *http.Request) *slog.Logger {
+	args := []any{"method", r.Method, "path", r.URL.Path}
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		args = append(args, "request_id", info.id)
+		if info.subject != "" {
+			args = append(args, "subject", info.subject)
+		}
+		if info.traceID != "" {
+			args = append(args, "trace_id", info.traceID)
+		}
+	}
+	return logger.With(args...)
+}
+
+// setRequestSubject stores authenticated subject for access log
+func setRequestSubject(r *http.Request, subject string) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.subject = subject
+	}
+}
+
+// setRequestTrace stores trace ID of the request for access log
+func setRequestTrace(r *http.Request, sc trace.SpanContext) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok && sc.HasTraceID() {
+		info.traceID = sc.TraceID().String()
+	}
+}
+
+// validRequestID checks that request ID from client is safe to log and return
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
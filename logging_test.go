@@ -0,0 +1,131 @@
+package gontracts
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func TestRequestID(t *testing.T) {
+	cases := []struct {
+		Num       string
+		RequestID string
+		Keep      bool
+	}{
+		// propagated from client
+		{
+			Num:       "1",
+			RequestID: "3f2a-client_id.1",
+			Keep:      true,
+		},
+		// generated if missing
+		{
+			Num: "2",
+		},
+		// generated if unsafe
+		{
+			Num:       "3",
+			RequestID: "bad id\nwith newline",
+		},
+	}
+
+	for _, c := range cases {
+		var ctxID string
+		h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctxID = RequestIDFromContext(r.Context())
+		}))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		if c.RequestID != "" {
+			req.Header.Set(RequestIDHeader, c.RequestID)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		respID := w.Header().Get(RequestIDHeader)
+		if respID == "" || respID != ctxID {
+			t.Errorf("[RequestID:%s]:\twrong request ID: header %q, context %q", c.Num, respID, ctxID)
+		}
+		if (respID == c.RequestID) != c.Keep {
+			t.Errorf("[RequestID:%s]:\twrong request ID: got %q, client sent %q", c.Num, respID, c.RequestID)
+		}
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, slog.LevelInfo)
+
+	a := NewAuthHandler([]byte("test"), nil)
+	h := RequestID(AccessLog(logger)(a.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	req := httptest.NewRequest("POST", "/company", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("Authorization", "Bearer "+testBearerToken([]byte("test")))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("[AccessLog]:\tlog record isn't JSON: %v: %s", err, buf.String())
+	}
+	expected := map[string]interface{}{
+		"level":      "INFO",
+		"msg":        "access",
+		"method":     "POST",
+		"path":       "/company",
+		"request_id": "req-1",
+		"subject":    "test",
+		"status":     float64(http.StatusCreated),
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("[AccessLog]:\twrong %s: got %v, expected %v", k, record[k], v)
+		}
+	}
+	if _, ok := record["latency"]; !ok {
+		t.Error("[AccessLog]:\tlatency is missing")
+	}
+}
+
+func TestHandlerErrorLog(t *testing.T) {
+	time1 := time.Date(2000, 02, 01, 00, 00, 00, 0, time.UTC)
+
+	var buf bytes.Buffer
+	h := NewHandler(nil, test.TestContract{
+		CL: []*model.Contract{
+			{ID: 1, SellerID: 10, ClientID: 11, ValidFrom: time1, ValidTo: time1.AddDate(0, 1, 0), CreditAmount: 10},
+		},
+	}, test.TestPurchase{})
+	h.SetLogger(NewLogger(&buf, slog.LevelInfo))
+
+	req := httptest.NewRequest("POST", "/purchase", bytes.NewBufferString(`{"contractID":1,"datetime":"2000-02-02T00:00:00Z","amount":11}`))
+	req.Header.Set(RequestIDHeader, "req-2")
+	w := httptest.NewRecorder()
+	RequestID(http.HandlerFunc(h.Purchase)).ServeHTTP(w, req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("[HandlerErrorLog]:\tlog record isn't JSON: %v: %s", err, buf.String())
+	}
+	expected := map[string]interface{}{
+		"level":      "ERROR",
+		"error":      ErrNotEnoughMoney.Error(),
+		"request_id": "req-2",
+		"contract":   float64(1),
+		"path":       "/purchase",
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("[HandlerErrorLog]:\twrong %s: got %v, expected %v", k, record[k], v)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package gontracts
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ilyakaznacheev/gontracts/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes all service metrics
+const metricsNamespace = "gontracts"
+
+// Metrics is a set of service metrics exposed to Prometheus
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests      *prometheus.CounterVec
+	httpDuration      *prometheus.HistogramVec
+	purchasesAccepted prometheus.Counter
+	purchasesRejected *prometheus.CounterVec
+	creditSpent       prometheus.Counter
+}
+
+// NewMetrics creates service metrics in a separate registry.
+// DB pool stats are collected if dbConn isn't nil
+func NewMetrics(dbConn *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		purchasesAccepted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "purchases_accepted_total",
+			Help:      "Number of accepted purchases.",
+		}),
+		purchasesRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "purchases_rejected_total",
+			Help:      "Number of rejected purchases by reason.",
+		}, []string{"reason"}),
+		creditSpent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "credit_spent_total",
+			Help:      "Amount of credit spent by accepted purchases.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.purchasesAccepted,
+		m.purchasesRejected,
+		m.creditSpent,
+	)
+	if dbConn != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(dbConn, "gontracts"))
+	}
+
+	// expose all known rejection reasons from the start
+	for _, reason := range []string{service.ReasonContractNotFound, service.ReasonDateNotValid, service.ReasonNotEnoughMoney} {
+		m.purchasesRejected.WithLabelValues(reason)
+	}
+
+	return m
+}
+
+// Handler returns metrics scrape handler
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts requests and measures their latency per route
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if cr := mux.CurrentRoute(r); cr != nil {
+			if tmpl, err := cr.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(sw, r)
+
+		status := strconv.Itoa(sw.status)
+		m.httpRequests.WithLabelValues(route, r.Method, status).Inc()
+		m.httpDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// PurchaseAccepted counts accepted purchase
+func (m *Metrics) PurchaseAccepted(amount int) {
+	if m == nil {
+		return
+	}
+	m.purchasesAccepted.Inc()
+	m.creditSpent.Add(float64(amount))
+}
+
+// PurchaseRejected counts rejected purchase by reason
+func (m *Metrics) PurchaseRejected(reason string) {
+	if m == nil {
+		return
+	}
+	m.purchasesRejected.WithLabelValues(reason).Inc()
+}
+
+// statusWriter remembers response status code
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
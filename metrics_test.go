@@ -0,0 +1,95 @@
+package gontracts
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func testScrapeMetrics(m *Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	return string(body)
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	m := NewMetrics(nil)
+
+	r := mux.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("/company/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}).Methods("GET")
+	r.HandleFunc("/company", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}).Methods("GET")
+
+	for _, url := range []string{"/company/1", "/company/2", "/company"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil))
+	}
+
+	metrics := testScrapeMetrics(m)
+	for _, line := range []string{
+		`gontracts_http_requests_total{method="GET",route="/company/{id:[0-9]+}",status="404"} 2`,
+		`gontracts_http_requests_total{method="GET",route="/company",status="200"} 1`,
+		`gontracts_http_request_duration_seconds_count{method="GET",route="/company",status="200"} 1`,
+	} {
+		if !strings.Contains(metrics, line) {
+			t.Errorf("[MetricsMiddleware]:\tmetric not found: %s", line)
+		}
+	}
+}
+
+func TestPurchaseMetrics(t *testing.T) {
+	time1 := time.Date(2000, 02, 01, 00, 00, 00, 0, time.UTC)
+	time2 := time.Date(2000, 04, 01, 00, 00, 00, 0, time.UTC)
+
+	m := NewMetrics(nil)
+	h := NewHandler(
+		nil,
+		test.TestContract{
+			CL: []*model.Contract{
+				{ID: 1, SellerID: 10, ClientID: 11, ValidFrom: time1, ValidTo: time2, CreditAmount: 10},
+			},
+		},
+		test.TestPurchase{},
+	)
+	h.SetMetrics(m)
+
+	for _, body := range []string{
+		// accepted
+		`{"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":4}`,
+		`{"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":3}`,
+		// contract doesn't exist
+		`{"contractID":2,"datetime":"2000-03-01T00:00:00Z","amount":3}`,
+		// date is outside validity range
+		`{"contractID":1,"datetime":"2000-05-01T00:00:00Z","amount":3}`,
+		// not enough money
+		`{"contractID":1,"datetime":"2000-03-01T00:00:00Z","amount":11}`,
+	} {
+		req := httptest.NewRequest("POST", "/purchase", bytes.NewBuffer([]byte(body)))
+		testHandle("/purchase", httptest.NewRecorder(), req, h.Purchase)
+	}
+
+	metrics := testScrapeMetrics(m)
+	for _, line := range []string{
+		`gontracts_purchases_accepted_total 2`,
+		`gontracts_credit_spent_total 7`,
+		`gontracts_purchases_rejected_total{reason="contract_not_found"} 1`,
+		`gontracts_purchases_rejected_total{reason="date_not_valid"} 1`,
+		`gontracts_purchases_rejected_total{reason="not_enough_money"} 1`,
+	} {
+		if !strings.Contains(metrics, line) {
+			t.Errorf("[PurchaseMetrics]:\tmetric not found: %s", line)
+		}
+	}
+}
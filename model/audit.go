@@ -0,0 +1,50 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type auditActorKey struct{}
+
+// AuditActor is a principal and correlation ID of request making audited changes
+type AuditActor struct {
+	Actor     string
+	RequestID string
+}
+
+// WithAuditActor returns context of changes recorded in audit trail on behalf of actor.
+// Storage writes audit records in the transaction of the change, so that failed change isn't recorded
+// and failed record fails the change
+func WithAuditActor(ctx context.Context, a AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, a)
+}
+
+// NewAuditRecord returns record of item change made in context, or nil if the change isn't audited.
+// Nil before or after item means created or deleted item
+func NewAuditRecord[T any](ctx context.Context, entity string, id int, action string, before, after *T) *AuditRecord {
+	a, ok := ctx.Value(auditActorKey{}).(AuditActor)
+	if !ok {
+		return nil
+	}
+	return &AuditRecord{
+		Time:      time.Now().UTC(),
+		Actor:     a.Actor,
+		RequestID: a.RequestID,
+		Entity:    entity,
+		EntityID:  id,
+		Action:    action,
+		Before:    snapshot(before),
+		After:     snapshot(after),
+	}
+}
+
+// snapshot returns JSON of item, nil for missing item
+func snapshot[T any](item *T) json.RawMessage {
+	if item == nil {
+		return nil
+	}
+	b, _ := json.Marshal(item)
+	return b
+}
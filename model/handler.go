@@ -1,5 +1,9 @@
 package model
 
+import (
+	"context"
+)
+
 // ModelHandler is a persistent data interaction object
 type ModelHandler struct {
 	company  CompanyModel
@@ -20,91 +24,149 @@ func NewModelHandler(
 	}
 }
 
-var mh *ModelHandler
+// GetCompanies returns existing companies of ids
+func (m *ModelHandler) GetCompanies(ctx context.Context, ids []int) ([]*Company, error) {
+	return m.company.GetItems(ctx, ids)
+}
 
-// GetModelHandler returns model singleton
-func GetModelHandler(
-	company CompanyModel,
-	contract ContractModel,
-	purchase PurchaseModel,
-) *ModelHandler {
-	if mh == nil {
-		mh = NewModelHandler(company, contract, purchase)
-	}
-	return mh
+// GetCompanyList returns page of companies and next page cursor
+func (m *ModelHandler) GetCompanyList(ctx context.Context, q CompanyQuery) ([]*Company, string, error) {
+	return m.company.GetList(ctx, q)
 }
 
-// GetCompanyList returns list of all companies
-func (m *ModelHandler) GetCompanyList() ([]*Company, error) {
-	return m.company.GetList()
+// SearchCompanies returns ranked companies matching the query with highlighted matches
+func (m *ModelHandler) SearchCompanies(ctx context.Context, q CompanySearch) ([]*CompanyMatch, error) {
+	res, err := m.company.Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range res {
+		c.highlight(q.Query)
+	}
+	return res, nil
 }
 
 // GetCompany returns company by id
-func (m *ModelHandler) GetCompany(id int) (*Company, error) {
-	return m.company.GetItem(id)
+func (m *ModelHandler) GetCompany(ctx context.Context, id int) (*Company, error) {
+	return m.company.GetItem(ctx, id)
 }
 
 // CreateCompany creates new company
-func (m *ModelHandler) CreateCompany(c *Company) (int, error) {
-	return m.company.CreateItem(c)
+func (m *ModelHandler) CreateCompany(ctx context.Context, c *Company) (int, error) {
+	return m.company.CreateItem(ctx, c)
+}
+
+// CreateCompanies creates all companies or none of them
+func (m *ModelHandler) CreateCompanies(ctx context.Context, c []*Company) ([]int, error) {
+	return m.company.CreateItems(ctx, c)
 }
 
 // UpdateCompany updates company
-func (m *ModelHandler) UpdateCompany(c *Company) error {
-	return m.company.UpdateItem(c)
+func (m *ModelHandler) UpdateCompany(ctx context.Context, c *Company) error {
+	return m.company.UpdateItem(ctx, c)
+}
+
+// DeleteCompany soft deletes company, with its contracts if cascade is set
+func (m *ModelHandler) DeleteCompany(ctx context.Context, id, version int, cascade bool) error {
+	return m.company.DeleteItem(ctx, id, version, cascade)
 }
 
-// DeleteCompany removes company
-func (m *ModelHandler) DeleteCompany(id int) error {
-	return m.company.DeleteItem(id)
+// RestoreCompany restores soft deleted company
+func (m *ModelHandler) RestoreCompany(ctx context.Context, id, version int) error {
+	return m.company.RestoreItem(ctx, id, version)
 }
 
 // CheckCompanyExist checks are company with id  exists
-func (m *ModelHandler) CheckCompanyExist(id int) bool {
-	return m.company.CheckExist(id)
+func (m *ModelHandler) CheckCompanyExist(ctx context.Context, id int) (bool, error) {
+	return m.company.CheckExist(ctx, id)
+}
+
+// GetContractList returns page of contracts and next page cursor
+func (m *ModelHandler) GetContractList(ctx context.Context, q ContractQuery) ([]*Contract, string, error) {
+	return m.contract.GetList(ctx, q)
 }
 
-// GetContractList returns list of all contracts
-func (m *ModelHandler) GetContractList() ([]*Contract, error) {
-	return m.contract.GetList()
+// GetCompaniesContracts returns contracts of companies as sellers or clients
+func (m *ModelHandler) GetCompaniesContracts(ctx context.Context, ids []int) ([]*Contract, error) {
+	return m.contract.GetCompanyItems(ctx, ids)
 }
 
 // GetContract returns contract by id
-func (m *ModelHandler) GetContract(id int) (*Contract, error) {
-	return m.contract.GetItem(id)
+func (m *ModelHandler) GetContract(ctx context.Context, id int) (*Contract, error) {
+	return m.contract.GetItem(ctx, id)
 }
 
 // CreateContract creates new contract
-func (m *ModelHandler) CreateContract(c *Contract) (int, error) {
-	return m.contract.CreateItem(c)
+func (m *ModelHandler) CreateContract(ctx context.Context, c *Contract) (int, error) {
+	return m.contract.CreateItem(ctx, c)
+}
+
+// CreateContracts creates all contracts or none of them
+func (m *ModelHandler) CreateContracts(ctx context.Context, c []*Contract) ([]int, error) {
+	return m.contract.CreateItems(ctx, c)
 }
 
 // UpdateContract updates contract
-func (m *ModelHandler) UpdateContract(c *Contract) error {
-	return m.contract.UpdateItem(c)
+func (m *ModelHandler) UpdateContract(ctx context.Context, c *Contract) error {
+	return m.contract.UpdateItem(ctx, c)
+}
+
+// DeleteContract soft deletes contract
+func (m *ModelHandler) DeleteContract(ctx context.Context, id, version int) error {
+	return m.contract.DeleteItem(ctx, id, version)
 }
 
-// DeleteContract removes contract
-func (m *ModelHandler) DeleteContract(id int) error {
-	return m.contract.DeleteItem(id)
+// RestoreContract restores soft deleted contract
+func (m *ModelHandler) RestoreContract(ctx context.Context, id, version int) error {
+	return m.contract.RestoreItem(ctx, id, version)
 }
 
 // CheckContractsExist checks are company with id  exists
-func (m *ModelHandler) CheckContractsExist(id int) bool {
-	return m.contract.CheckExist(id)
+func (m *ModelHandler) CheckContractsExist(ctx context.Context, id int) (bool, error) {
+	return m.contract.CheckExist(ctx, id)
 }
 
 // CreatePurchase creates new purchase document
-func (m *ModelHandler) CreatePurchase(p *Purchase) (int, error) {
-	return m.purchase.AddItem(p)
+func (m *ModelHandler) CreatePurchase(ctx context.Context, p *Purchase) (int, error) {
+	return m.purchase.AddItem(ctx, p)
+}
+
+// CreatePurchases creates all purchase documents or none of them
+func (m *ModelHandler) CreatePurchases(ctx context.Context, p []*Purchase) ([]int, error) {
+	return m.purchase.CreateItems(ctx, p)
 }
 
 // GetContractPurchaseSum returns purchase sum of contract
-func (m *ModelHandler) GetContractPurchaseSum(id int) int {
-	return m.purchase.GetContractSum(id)
+func (m *ModelHandler) GetContractPurchaseSum(ctx context.Context, id int) (int, error) {
+	return m.purchase.GetContractSum(ctx, id)
+}
+
+// GetContractPurchaseHistory returns page of purchase history of contract and next page cursor
+func (m *ModelHandler) GetContractPurchaseHistory(ctx context.Context, q PurchaseQuery) ([]*Purchase, string, error) {
+	return m.purchase.GetContractHistory(ctx, q)
+}
+
+// GetContractsPurchases returns purchases of contracts
+func (m *ModelHandler) GetContractsPurchases(ctx context.Context, ids []int) ([]*Purchase, error) {
+	return m.purchase.GetContractItems(ctx, ids)
+}
+
+// GetContractsPurchaseSums returns purchase sums of contracts, contracts without purchases are omitted
+func (m *ModelHandler) GetContractsPurchaseSums(ctx context.Context, ids []int) (map[int]int, error) {
+	return m.purchase.GetContractSums(ctx, ids)
+}
+
+// ExportCompanies calls fn for every company matching the query
+func (m *ModelHandler) ExportCompanies(ctx context.Context, q ExportQuery, fn func(*Company) error) error {
+	return m.company.Export(ctx, q, fn)
+}
+
+// ExportContracts calls fn for every contract matching the query
+func (m *ModelHandler) ExportContracts(ctx context.Context, q ExportQuery, fn func(*Contract) error) error {
+	return m.contract.Export(ctx, q, fn)
 }
 
-// GetContractPurchaseHistory returns purchase history of contract
-func (m *ModelHandler) GetContractPurchaseHistory(id int) ([]*Purchase, error) {
-	return m.purchase.GetContractHistory(id)
+// ExportPurchases calls fn for every purchase matching the query
+func (m *ModelHandler) ExportPurchases(ctx context.Context, q ExportQuery, fn func(*Purchase) error) error {
+	return m.purchase.Export(ctx, q, fn)
 }
@@ -1,14 +1,38 @@
 package model
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrNotFound requested item doesn't exist
+	ErrNotFound = errors.New("item not found")
+	// ErrUnavailable storage is temporarily unavailable
+	ErrUnavailable = errors.New("storage is unavailable")
+	// ErrVersionMismatch item was changed since expected version
+	ErrVersionMismatch = errors.New("item version doesn't match")
+	// ErrReferenced item is referenced by other items
+	ErrReferenced = errors.New("item is referenced by other items")
+	// ErrSellerDeleted seller company of contract is deleted
+	ErrSellerDeleted = errors.New("seller company is deleted")
+	// ErrClientDeleted client company of contract is deleted
+	ErrClientDeleted = errors.New("client company is deleted")
+	// ErrContractDeleted contract of purchase is deleted
+	ErrContractDeleted = errors.New("contract is deleted")
+)
+
 // Company represent company DB table structure
 type Company struct {
 	ID      int     `json:"ID"`
 	Name    string  `json:"name"`
 	RegCode *string `json:"regcode"`
+	// Version is incremented on every change
+	Version int `json:"-"`
+	// DeletedAt is set for soft deleted company
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
 // Contract represent contract DB table structure
@@ -19,6 +43,10 @@ type Contract struct {
 	ValidFrom    time.Time `json:"validFrom"`
 	ValidTo      time.Time `json:"validTo"`
 	CreditAmount int       `json:"amount"`
+	// Version is incremented on every change
+	Version int `json:"-"`
+	// DeletedAt is set for soft deleted contract
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
 // Purchase represent purchase DB table structure
@@ -29,29 +57,133 @@ type Purchase struct {
 	CreditSpent      int       `json:"amount"`
 }
 
-// CompanyModel represents company interaction scheme
+// APIKey represent apikey DB table structure
+type APIKey struct {
+	ID         int        `json:"ID"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+// Audited entities
+const (
+	EntityCompany  = "company"
+	EntityContract = "contract"
+	EntityPurchase = "purchase"
+)
+
+// Audited actions
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// AuditRecord represent audit DB table structure.
+// Before and After are JSON snapshots of the item, Before is empty for created and restored items and After for deleted ones
+type AuditRecord struct {
+	ID        int             `json:"ID"`
+	Time      time.Time       `json:"time"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"requestID"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entityID"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+}
+
+// CompanyModel represents company interaction scheme.
+// Methods addressing missing item return ErrNotFound, soft deleted items are missing for all methods but lists and RestoreItem.
+// Zero expected version matches any version, otherwise mismatch returns ErrVersionMismatch.
+// Changes made in context of WithAuditActor are recorded in audit trail in the same transaction.
+// List methods return next page cursor, empty on the last page
 type CompanyModel interface {
-	GetList() ([]*Company, error)
-	GetItem(int) (*Company, error)
-	CreateItem(*Company) (int, error)
-	UpdateItem(*Company) error
-	DeleteItem(int) error
-	CheckExist(int) bool
+	GetList(context.Context, CompanyQuery) ([]*Company, string, error)
+	GetItem(context.Context, int) (*Company, error)
+	CreateItem(context.Context, *Company) (int, error)
+	// UpdateItem updates item of expected version and sets its new version
+	UpdateItem(context.Context, *Company) error
+	// DeleteItem soft deletes item of expected version.
+	// Company with contracts returns ErrReferenced unless cascade deletes the contracts as well
+	DeleteItem(ctx context.Context, id, version int, cascade bool) error
+	// RestoreItem restores soft deleted item of expected version
+	RestoreItem(ctx context.Context, id, version int) error
+	CheckExist(context.Context, int) (bool, error)
+	// Search returns companies matching the query, the most relevant first
+	Search(context.Context, CompanySearch) ([]*CompanyMatch, error)
+	// GetItems returns existing items of ids ordered by id
+	GetItems(ctx context.Context, ids []int) ([]*Company, error)
+	// CreateItems creates all items in a single transaction and returns their ids in the same order
+	CreateItems(context.Context, []*Company) ([]int, error)
+	// Export calls fn for every item matching the query ordered by id, items are read one by one
+	Export(ctx context.Context, q ExportQuery, fn func(*Company) error) error
 }
 
-// ContractModel represents contract interaction scheme
+// ContractModel represents contract interaction scheme.
+// Methods addressing missing item return ErrNotFound, soft deleted items are missing for all methods but lists and RestoreItem.
+// Zero expected version matches any version, otherwise mismatch returns ErrVersionMismatch.
+// Changes made in context of WithAuditActor are recorded in audit trail in the same transaction.
+// List methods return next page cursor, empty on the last page
 type ContractModel interface {
-	GetList() ([]*Contract, error)
-	GetItem(int) (*Contract, error)
-	CreateItem(*Contract) (int, error)
-	UpdateItem(*Contract) error
-	DeleteItem(int) error
-	CheckExist(int) bool
+	GetList(context.Context, ContractQuery) ([]*Contract, string, error)
+	GetItem(context.Context, int) (*Contract, error)
+	// CreateItem creates item, contract of deleted seller or client returns ErrSellerDeleted or ErrClientDeleted
+	CreateItem(context.Context, *Contract) (int, error)
+	// UpdateItem updates item of expected version and sets its new version.
+	// Contract of deleted seller or client returns ErrSellerDeleted or ErrClientDeleted
+	UpdateItem(context.Context, *Contract) error
+	// DeleteItem soft deletes item of expected version, its purchases are kept
+	DeleteItem(ctx context.Context, id, version int) error
+	// RestoreItem restores soft deleted item of expected version.
+	// Contract of deleted seller or client stays deleted and returns ErrSellerDeleted or ErrClientDeleted
+	RestoreItem(ctx context.Context, id, version int) error
+	CheckExist(context.Context, int) (bool, error)
+	// GetCompanyItems returns contracts of companies as sellers or clients ordered by id
+	GetCompanyItems(ctx context.Context, companyIDs []int) ([]*Contract, error)
+	// CreateItems creates all items in a single transaction and returns their ids in the same order
+	CreateItems(context.Context, []*Contract) ([]int, error)
+	// Export calls fn for every item matching the query ordered by id, items are read one by one
+	Export(ctx context.Context, q ExportQuery, fn func(*Contract) error) error
 }
 
-// PurchaseModel represents purchase interaction scheme
+// PurchaseModel represents purchase interaction scheme.
+// Purchases created in context of WithAuditActor are recorded in audit trail in the same transaction.
+// List methods return next page cursor, empty on the last page
 type PurchaseModel interface {
-	AddItem(*Purchase) (int, error)
-	GetContractHistory(int) ([]*Purchase, error)
-	GetContractSum(int) int
+	// AddItem creates item, purchase of missing or deleted contract returns ErrContractDeleted
+	AddItem(context.Context, *Purchase) (int, error)
+	GetContractHistory(context.Context, PurchaseQuery) ([]*Purchase, string, error)
+	GetContractSum(context.Context, int) (int, error)
+	// GetContractItems returns purchases of contracts ordered by date
+	GetContractItems(ctx context.Context, contractIDs []int) ([]*Purchase, error)
+	// GetContractSums returns purchase sums of contracts, contracts without purchases are omitted
+	GetContractSums(ctx context.Context, contractIDs []int) (map[int]int, error)
+	// CreateItems creates all items in a single transaction and returns their ids in the same order
+	CreateItems(context.Context, []*Purchase) ([]int, error)
+	// Export calls fn for every item matching the query ordered by id, items are read one by one
+	Export(ctx context.Context, q ExportQuery, fn func(*Purchase) error) error
+}
+
+// APIKeyModel represents api key interaction scheme.
+// Methods addressing missing item return ErrNotFound
+type APIKeyModel interface {
+	GetList(context.Context) ([]*APIKey, error)
+	GetByHash(context.Context, string) (*APIKey, error)
+	CreateItem(context.Context, *APIKey) (int, error)
+	RevokeItem(context.Context, int) error
+	UpdateLastUsed(context.Context, int, time.Time) error
+}
+
+// AuditModel represents append-only audit trail.
+// Records are appended by item models in transactions of the changes.
+// List methods return next page cursor, empty on the last page
+type AuditModel interface {
+	GetList(context.Context, AuditQuery) ([]*AuditRecord, string, error)
 }
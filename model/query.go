@@ -0,0 +1,87 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Page size limits
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ErrInvalidQuery list query has unknown sort field or malformed cursor
+var ErrInvalidQuery = errors.New("invalid list query")
+
+// Page is a cursor based page request
+type Page struct {
+	// Limit is a maximal number of items, DefaultLimit if zero
+	Limit int
+	// Cursor points after the last item of the previous page, empty for the first page
+	Cursor string
+}
+
+// Sort is a list order
+type Sort struct {
+	// Field is a JSON name of the sort field, model specific default if empty
+	Field string
+	Desc  bool
+}
+
+// CompanyQuery represents company list request
+type CompanyQuery struct {
+	// IncludeDeleted lists soft deleted companies as well
+	IncludeDeleted bool
+	Sort           Sort
+	Page           Page
+}
+
+// ContractQuery represents contract list request.
+// Zero values of filters match any contract
+type ContractQuery struct {
+	SellerID int
+	ClientID int
+	// ActiveFrom and ActiveTo select contracts valid at some moment of the window
+	ActiveFrom *time.Time
+	ActiveTo   *time.Time
+	// IncludeDeleted lists soft deleted contracts as well
+	IncludeDeleted bool
+	Sort           Sort
+	Page           Page
+}
+
+// ExportQuery represents bulk export request.
+// Zero values of filters match any item
+type ExportQuery struct {
+	// CompanyID selects the company, its contracts as seller or client and purchases of these contracts
+	CompanyID int
+	// From and To select contracts valid at some moment of the window, purchases made within it
+	// and companies having such contracts
+	From *time.Time
+	To   *time.Time
+}
+
+// PurchaseQuery represents purchase history request of contract.
+// Nil filters match any purchase
+type PurchaseQuery struct {
+	ContractID int
+	From       *time.Time
+	To         *time.Time
+	MinAmount  *int
+	MaxAmount  *int
+	Sort       Sort
+	Page       Page
+}
+
+// AuditQuery represents audit trail request.
+// Zero values of filters match any record
+type AuditQuery struct {
+	Entity   string
+	EntityID int
+	Actor    string
+	From     *time.Time
+	To       *time.Time
+	Sort     Sort
+	Page     Page
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Highlight marks around matched text
+const (
+	HighlightPre  = "<em>"
+	HighlightPost = "</em>"
+)
+
+// CompanySearch represents company search request
+type CompanySearch struct {
+	// Query is a part of company name or registration code
+	Query string
+	// Limit is a maximal number of results, DefaultLimit if zero
+	Limit int
+}
+
+// CompanyMatch is a company search result.
+// Highlight contains HTML escaped matched fields with matches wrapped in highlight marks
+type CompanyMatch struct {
+	Company
+	Score     float64           `json:"score"`
+	Highlight map[string]string `json:"highlight"`
+}
+
+// highlight fills highlights of matched company fields
+func (m *CompanyMatch) highlight(query string) {
+	m.Highlight = make(map[string]string)
+	if s, ok := Highlight(m.Name, query); ok {
+		m.Highlight["name"] = s
+	}
+	if m.RegCode != nil {
+		if s, ok := Highlight(*m.RegCode, query); ok {
+			m.Highlight["regcode"] = s
+		}
+	}
+}
+
+// Highlight wraps case-insensitive occurrences of query words in text with highlight marks.
+// Text is HTML escaped. It returns false if there are no occurrences
+func Highlight(text, query string) (string, bool) {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// mark matched runes
+	marked := make([]bool, len(runes))
+	found := false
+	for _, word := range strings.Fields(query) {
+		w := []rune(word)
+		for i := range w {
+			w[i] = unicode.ToLower(w[i])
+		}
+		for i := 0; i+len(w) <= len(lower); i++ {
+			if string(lower[i:i+len(w)]) == string(w) {
+				for j := i; j < i+len(w); j++ {
+					marked[j] = true
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	// wrap marked runs
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		part := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			part = HighlightPre + part + HighlightPost
+		}
+		b.WriteString(part)
+		i = j
+	}
+	return b.String(), true
+}
@@ -1,8 +1,14 @@
+-- Changes of the schema must be added to db/migrate.go to get them on existing databases
+
 CREATE TABLE `company` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
   `name` varchar(255) NOT NULL,
   `regcode` varchar(100) DEFAULT NULL,
-  PRIMARY KEY (`id`)
+  `version` int(11) NOT NULL DEFAULT 1,
+  `deletedat` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `company_name_IX` (`name`, `id`),
+  FULLTEXT KEY `company_search_FT` (`name`, `regcode`) WITH PARSER ngram
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
 CREATE TABLE `contract` (
@@ -12,6 +18,8 @@ CREATE TABLE `contract` (
   `validfrom` date NOT NULL,
   `validto` date NOT NULL,
   `creditamount` int(11) NOT NULL,
+  `version` int(11) NOT NULL DEFAULT 1,
+  `deletedat` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
   KEY `contract_seller_company_FK` (`sellerid`),
   KEY `contract_client_company_FK` (`clientid`),
@@ -26,5 +34,35 @@ CREATE TABLE `purchase` (
   `creditspent` int(11) NOT NULL,
   PRIMARY KEY (`id`),
   KEY `purchase_contract_FK` (`contractid`),
+  KEY `purchase_contract_date_IX` (`contractid`, `purchasedatetime`, `id`),
   CONSTRAINT `purchase_contract_FK` FOREIGN KEY (`contractid`) REFERENCES `contract` (`id`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `apikey` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `prefix` varchar(16) NOT NULL,
+  `hash` char(64) NOT NULL,
+  `scopes` varchar(255) NOT NULL,
+  `expiresat` datetime DEFAULT NULL,
+  `createdat` datetime NOT NULL,
+  `lastusedat` datetime DEFAULT NULL,
+  `revokedat` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `apikey_hash_UN` (`hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `audit` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `changedat` datetime(6) NOT NULL,
+  `actor` varchar(255) NOT NULL,
+  `requestid` varchar(64) NOT NULL,
+  `entity` varchar(16) NOT NULL,
+  `entityid` int(11) NOT NULL,
+  `action` varchar(16) NOT NULL,
+  `beforedata` json DEFAULT NULL,
+  `afterdata` json DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `audit_entity_IX` (`entity`, `entityid`, `changedat`, `id`),
+  KEY `audit_actor_IX` (`actor`, `changedat`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,290 @@
+package gontracts
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIVersion is a version of served API document
+const OpenAPIVersion = "3.0.3"
+
+// docsPage is an interactive API documentation page
+//
+//go:embed docs.html
+var docsPage []byte
+
+// Swagger 2.0 to OpenAPI 3 reference prefixes
+var refReplacer = strings.NewReplacer(
+	"#/definitions/", "#/components/schemas/",
+	"#/parameters/", "#/components/parameters/",
+)
+
+// openAPIDocument returns OpenAPI 3 document converted from swagger spec
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	var spec any
+	if err := yaml.Unmarshal(swaggerSpec, &spec); err != nil {
+		return nil, fmt.Errorf("swagger spec: %w", err)
+	}
+	doc, err := convertSpec(stringKeys(spec).(map[string]any))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+})
+
+// ServeOpenAPI responds with OpenAPI 3 document of the API
+func ServeOpenAPI(w http.ResponseWriter, r *http.Request) {
+	resp, err := openAPIDocument()
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	// setup response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// ServeDocs responds with interactive API documentation page
+func ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(docsPage)
+}
+
+// convertSpec converts Swagger 2.0 spec to OpenAPI 3 document.
+// Only the features used by swagger.yml are supported
+func convertSpec(spec map[string]any) (map[string]any, error) {
+	if v := spec["swagger"]; v != "2.0" {
+		return nil, fmt.Errorf("swagger spec: unsupported version %v", v)
+	}
+
+	basePath, _ := spec["basePath"].(string)
+	if basePath == "" {
+		basePath = "/"
+	}
+	doc := map[string]any{
+		"openapi": OpenAPIVersion,
+		"info":    spec["info"],
+		"servers": []any{map[string]any{"url": basePath}},
+		"tags":    spec["tags"],
+	}
+
+	components := map[string]any{
+		"securitySchemes": spec["securityDefinitions"],
+	}
+	schemas := make(map[string]any)
+	for name, s := range mapOf(spec["definitions"]) {
+		schemas[name] = convertSchema(s)
+	}
+	components["schemas"] = schemas
+	params := make(map[string]any)
+	for name, p := range mapOf(spec["parameters"]) {
+		params[name] = convertParameter(mapOf(p))
+	}
+	components["parameters"] = params
+	doc["components"] = components
+
+	paths := make(map[string]any)
+	for path, item := range mapOf(spec["paths"]) {
+		ops := make(map[string]any)
+		for method, op := range mapOf(item) {
+			ops[method] = convertOperation(mapOf(op))
+		}
+		paths[path] = ops
+	}
+	doc["paths"] = paths
+
+	return doc, nil
+}
+
+// convertOperation moves body parameter to request body and response schemas to contents
+func convertOperation(op map[string]any) map[string]any {
+	res := make(map[string]any)
+	for _, key := range []string{"tags", "summary", "description", "operationId", "security"} {
+		if v, ok := op[key]; ok {
+			res[key] = v
+		}
+	}
+
+	consumes := stringsOf(op["consumes"], "application/json")
+	produces := stringsOf(op["produces"], "application/json")
+
+	var params []any
+	for _, p := range sliceOf(op["parameters"]) {
+		p := mapOf(p)
+		if p["in"] != "body" {
+			params = append(params, convertParameter(p))
+			continue
+		}
+		body := map[string]any{
+			"required": p["required"] == true,
+			"content":  contents(consumes, convertSchema(p["schema"])),
+		}
+		if d, ok := p["description"]; ok {
+			body["description"] = d
+		}
+		res["requestBody"] = body
+	}
+	if len(params) > 0 {
+		res["parameters"] = params
+	}
+
+	responses := make(map[string]any)
+	for code, r := range mapOf(op["responses"]) {
+		r := mapOf(r)
+		resp := map[string]any{"description": r["description"]}
+		if s, ok := r["schema"]; ok {
+			resp["content"] = contents(responseTypes(code, produces), convertSchema(s))
+		}
+		if h, ok := r["headers"]; ok {
+			headers := make(map[string]any)
+			for name, hdr := range mapOf(h) {
+				hdr := mapOf(hdr)
+				headers[name] = map[string]any{
+					"description": hdr["description"],
+					"schema":      schemaOf(hdr),
+				}
+			}
+			resp["headers"] = headers
+		}
+		responses[code] = resp
+	}
+	res["responses"] = responses
+
+	return res
+}
+
+// convertParameter moves parameter type to its schema
+func convertParameter(p map[string]any) map[string]any {
+	if ref, ok := p["$ref"].(string); ok {
+		return map[string]any{"$ref": refReplacer.Replace(ref)}
+	}
+	res := map[string]any{
+		"name":   p["name"],
+		"in":     p["in"],
+		"schema": schemaOf(p),
+	}
+	if d, ok := p["description"]; ok {
+		res["description"] = d
+	}
+	if p["required"] == true || p["in"] == "path" {
+		res["required"] = true
+	}
+	return res
+}
+
+// convertSchema rewrites references and nullable extension of schema
+func convertSchema(s any) any {
+	switch s := s.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(s))
+		for k, v := range s {
+			switch k {
+			case "$ref":
+				res[k] = refReplacer.Replace(v.(string))
+			case "x-nullable":
+				res["nullable"] = v
+			default:
+				res[k] = convertSchema(v)
+			}
+		}
+		return res
+	case []any:
+		res := make([]any, len(s))
+		for i, v := range s {
+			res[i] = convertSchema(v)
+		}
+		return res
+	}
+	return s
+}
+
+// schemaOf returns schema of Swagger 2.0 parameter or header
+func schemaOf(p map[string]any) any {
+	s := make(map[string]any)
+	for _, key := range []string{"type", "format", "enum", "items", "minimum", "maximum", "default"} {
+		if v, ok := p[key]; ok {
+			s[key] = v
+		}
+	}
+	return convertSchema(s)
+}
+
+// responseTypes returns media types of response with status code.
+// Problem details are used for errors only
+func responseTypes(code string, produces []string) []string {
+	status, _ := strconv.Atoi(code)
+	var res []string
+	for _, t := range produces {
+		if (t == ProblemContentType) == (status >= http.StatusBadRequest) {
+			res = append(res, t)
+		}
+	}
+	if len(res) == 0 {
+		return produces
+	}
+	return res
+}
+
+func contents(types []string, schema any) map[string]any {
+	res := make(map[string]any, len(types))
+	for _, t := range types {
+		res[t] = map[string]any{"schema": schema}
+	}
+	return res
+}
+
+func mapOf(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func sliceOf(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+func stringsOf(v any, def string) []string {
+	var res []string
+	for _, s := range sliceOf(v) {
+		if s, ok := s.(string); ok {
+			res = append(res, s)
+		}
+	}
+	if len(res) == 0 {
+		return []string{def}
+	}
+	return res
+}
+
+// stringKeys converts YAML mappings with non-string keys, e.g. response codes
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = stringKeys(item)
+		}
+		return v
+	case map[any]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			res[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return res
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	}
+	return v
+}
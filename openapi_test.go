@@ -0,0 +1,172 @@
+package gontracts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ilyakaznacheev/gontracts/test"
+	"gopkg.in/yaml.v3"
+)
+
+// testUndocumentedRoutes aren't part of the API spec
+var testUndocumentedRoutes = map[string]bool{
+	"GET /metrics":      true,
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+	// GraphQL endpoint is described by its own schema
+	"GET /graphql":  true,
+	"POST /graphql": true,
+}
+
+var testPathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// testRouterRoutes returns "METHOD path" pairs registered in the router, path parameters are unnamed
+func testRouterRoutes(t *testing.T, r *mux.Router) map[string]bool {
+	routes := make(map[string]bool)
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, m := range methods {
+			routes[m+" "+testPathParam.ReplaceAllString(path, "{}")] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routes
+}
+
+// testSpecRoutes returns "METHOD path" pairs of swagger spec, path parameters are unnamed
+func testSpecRoutes(t *testing.T) map[string]bool {
+	var spec struct {
+		Paths map[string]map[string]any `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(swaggerSpec, &spec); err != nil {
+		t.Fatal(err)
+	}
+	routes := make(map[string]bool)
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			routes[strings.ToUpper(method)+" "+testPathParam.ReplaceAllString(path, "{}")] = true
+		}
+	}
+	return routes
+}
+
+func testMissing(routes, in map[string]bool) []string {
+	var res []string
+	for r := range routes {
+		if !in[r] && !testUndocumentedRoutes[r] {
+			res = append(res, r)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestSpecMatchesRouter(t *testing.T) {
+	h := NewHandler(test.TestCompany{}, test.TestContract{}, test.TestPurchase{})
+	r := newRouter(h, NewAuthHandler([]byte("test"), nil), NewHealthHandler(), NewMetrics(nil))
+
+	router := testRouterRoutes(t, r)
+	spec := testSpecRoutes(t)
+
+	for _, route := range testMissing(router, spec) {
+		t.Errorf("[SpecMatchesRouter]:\troute %s is not described in swagger.yml", route)
+	}
+	for _, route := range testMissing(spec, router) {
+		t.Errorf("[SpecMatchesRouter]:\tpath %s of swagger.yml is not registered in the router", route)
+	}
+	for route := range testUndocumentedRoutes {
+		if !router[route] {
+			t.Errorf("[SpecMatchesRouter]:\tundocumented route %s is not registered in the router", route)
+		}
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	testHandle("/openapi.json", w, req, ServeOpenAPI)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("[ServeOpenAPI]:\twrong StatusCode: got %d, expected %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("[ServeOpenAPI]:\twrong Content-Type: got %s", ct)
+	}
+
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas    map[string]any `json:"schemas"`
+			Parameters map[string]any `json:"parameters"`
+		} `json:"components"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("[ServeOpenAPI]:\tinvalid JSON: %v", err)
+	}
+	if doc.OpenAPI != OpenAPIVersion {
+		t.Errorf("[ServeOpenAPI]:\twrong version: got %s, expected %s", doc.OpenAPI, OpenAPIVersion)
+	}
+	for _, name := range []string{"Company", "Contract", "Purchase", "Problem"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("[ServeOpenAPI]:\tschema %s is missing", name)
+		}
+	}
+	if _, ok := doc.Components.Parameters["limit"]; !ok {
+		t.Errorf("[ServeOpenAPI]:\tparameter limit is missing")
+	}
+
+	body := w.Body.String()
+	for _, old := range []string{`"#/definitions/`, `"#/parameters/`, `"in":"body"`, `"x-nullable"`} {
+		if strings.Contains(body, old) {
+			t.Errorf("[ServeOpenAPI]:\tSwagger 2.0 construct %s is left in the document", old)
+		}
+	}
+
+	var post struct {
+		RequestBody struct {
+			Content map[string]any `json:"content"`
+		} `json:"requestBody"`
+		Responses map[string]struct {
+			Content map[string]any `json:"content"`
+		} `json:"responses"`
+	}
+	if err := json.Unmarshal(doc.Paths["/company"]["post"], &post); err != nil {
+		t.Fatalf("[ServeOpenAPI]:\tPOST /company: %v", err)
+	}
+	if _, ok := post.RequestBody.Content["application/json"]; !ok {
+		t.Errorf("[ServeOpenAPI]:\tPOST /company has no JSON request body")
+	}
+	if _, ok := post.Responses["400"].Content[ProblemContentType]; !ok {
+		t.Errorf("[ServeOpenAPI]:\tPOST /company error response is not a problem document")
+	}
+}
+
+func TestServeDocs(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/docs", nil)
+	w := httptest.NewRecorder()
+	testHandle("/docs", w, req, ServeDocs)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("[ServeDocs]:\twrong StatusCode: got %d, expected %d", w.Code, http.StatusOK)
+	}
+	if !strings.Contains(w.Body.String(), "openapi.json") {
+		t.Errorf("[ServeDocs]:\tdocs page doesn't load the spec")
+	}
+}
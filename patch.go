@@ -0,0 +1,79 @@
+package gontracts
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+)
+
+// MergePatchContentType is a media type of RFC 7396 merge patch
+const MergePatchContentType = "application/merge-patch+json"
+
+var (
+	// ErrPatchContentType patch request has unsupported media type
+	ErrPatchContentType = errors.New("patch content type must be " + MergePatchContentType)
+	// ErrPatchNotObject patch document isn't a JSON object
+	ErrPatchNotObject = errors.New("patch must be a JSON object")
+	// ErrPatchID patch changes ID of the item
+	ErrPatchID = errors.New("patch must not change ID")
+)
+
+// readPatch applies RFC 7396 merge patch from request body to item.
+// The result is validated against spec definition and decoded into a new item,
+// so fields removed by the patch are zeroed
+func readPatch[T any](r *http.Request, item *T, def string) (*T, error) {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mt != MergePatchContentType && mt != "application/json") {
+			return nil, ErrPatchContentType
+		}
+	}
+
+	var patch any
+	dc := json.NewDecoder(r.Body)
+	dc.UseNumber()
+	if err := dc.Decode(&patch); err != nil {
+		return nil, badRequest(err)
+	}
+	if _, ok := patch.(map[string]any); !ok {
+		return nil, badRequest(ErrPatchNotObject)
+	}
+
+	// item to generic JSON document
+	b, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err = json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	// patched document to new item
+	res := new(T)
+	if err = decodeDocument(mergePatch(doc, patch), def, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// mergePatch returns target with patch applied according to RFC 7396
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
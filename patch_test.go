@@ -0,0 +1,207 @@
+package gontracts
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func TestPatchCompany(t *testing.T) {
+	company := test.TestCompany{
+		CL: []*model.Company{
+			{1, "test1", testStrPtr("123"), 1, nil},
+		},
+	}
+
+	cases := []struct {
+		Num         string
+		ID          int
+		ContentType string
+		Request     string
+		Response    string
+		Status      int
+		Models      testModelSet
+	}{
+		// change single field
+		{
+			Num:         "1",
+			ID:          1,
+			ContentType: MergePatchContentType,
+			Request:     `{"name":"test2"}`,
+			Response:    `{"ID":1,"name":"test2","regcode":"123"}`,
+			Status:      http.StatusOK,
+			Models:      testModelSet{company: company},
+		},
+		// remove field
+		{
+			Num:         "2",
+			ID:          1,
+			ContentType: "application/json",
+			Request:     `{"regcode":null}`,
+			Response:    `{"ID":1,"name":"test1","regcode":null}`,
+			Status:      http.StatusOK,
+			Models:      testModelSet{company: company},
+		},
+		// company doesn't exist
+		{
+			Num:         "3",
+			ID:          2,
+			ContentType: MergePatchContentType,
+			Request:     `{"name":"test2"}`,
+			Response:    testProblem(http.StatusNotFound, "company_not_found", ErrCompanyNotFound.Error(), "/company/2"),
+			Status:      http.StatusNotFound,
+			Models:      testModelSet{company: company},
+		},
+		// id change
+		{
+			Num:         "4",
+			ID:          1,
+			ContentType: MergePatchContentType,
+			Request:     `{"ID":2}`,
+			Response:    testProblem(http.StatusBadRequest, codeInvalidRequest, ErrPatchID.Error(), "/company/1"),
+			Status:      http.StatusBadRequest,
+			Models:      testModelSet{company: company},
+		},
+		// patch isn't an object
+		{
+			Num:         "5",
+			ID:          1,
+			ContentType: MergePatchContentType,
+			Request:     `["name"]`,
+			Response:    testProblem(http.StatusBadRequest, codeInvalidRequest, ErrPatchNotObject.Error(), "/company/1"),
+			Status:      http.StatusBadRequest,
+			Models:      testModelSet{company: company},
+		},
+		// patched company isn't valid
+		{
+			Num:         "6",
+			ID:          1,
+			ContentType: MergePatchContentType,
+			Request:     `{"name":1}`,
+			Response:    testValidationProblem("/company/1", FieldError{"name", "must be a string"}),
+			Status:      http.StatusBadRequest,
+			Models:      testModelSet{company: company},
+		},
+		// required field removed
+		{
+			Num:         "9",
+			ID:          1,
+			ContentType: MergePatchContentType,
+			Request:     `{"name":null,"rating":5}`,
+			Response:    testValidationProblem("/company/1", FieldError{"name", "is required"}, FieldError{"rating", "is not allowed"}),
+			Status:      http.StatusBadRequest,
+			Models:      testModelSet{company: company},
+		},
+		// unsupported content type
+		{
+			Num:         "7",
+			ID:          1,
+			ContentType: "application/json-patch+json",
+			Request:     `[{"op":"remove","path":"/regcode"}]`,
+			Response:    testProblem(http.StatusUnsupportedMediaType, "unsupported_media_type", ErrPatchContentType.Error(), "/company/1"),
+			Status:      http.StatusUnsupportedMediaType,
+			Models:      testModelSet{company: company},
+		},
+		// error handling
+		{
+			Num:         "8",
+			ID:          1,
+			ContentType: MergePatchContentType,
+			Request:     `{"name":"test2"}`,
+			Response:    testProblem(http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError), "/company/1"),
+			Status:      http.StatusInternalServerError,
+			Models:      testModelSet{company: test.TestCompanyErr{}},
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+
+		url := fmt.Sprintf("/company/%d", c.ID)
+		req := httptest.NewRequest("PATCH", url, bytes.NewBufferString(c.Request))
+		req.Header.Set("Content-Type", c.ContentType)
+		w := httptest.NewRecorder()
+
+		testHandle("/company/{id:[0-9]+}", w, req, h.PatchCompany)
+		testCheckResponse("PatchCompany:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
+func TestPatchContract(t *testing.T) {
+	time1 := time.Date(2000, 01, 01, 00, 00, 00, 0, time.UTC)
+	company := test.TestCompany{
+		CL: []*model.Company{
+			{ID: 10, Name: "test1"},
+			{ID: 11, Name: "test2"},
+		},
+	}
+	contract := test.TestContract{
+		CL: []*model.Contract{
+			{1, 10, 11, time1, time1.AddDate(1, 0, 0), 10, 1, nil},
+		},
+	}
+
+	cases := []struct {
+		Num      string
+		ID       int
+		Request  string
+		Response string
+		Status   int
+		Models   testModelSet
+	}{
+		// extend validity
+		{
+			Num:      "1",
+			ID:       1,
+			Request:  `{"validTo":"2002-01-01T00:00:00Z"}`,
+			Response: `{"ID":1,"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z","validTo":"2002-01-01T00:00:00Z","amount":10}`,
+			Status:   http.StatusOK,
+			Models:   testModelSet{company: company, contract: contract},
+		},
+		// patched seller doesn't exist
+		{
+			Num:      "2",
+			ID:       1,
+			Request:  `{"sellerID":12}`,
+			Response: testProblem(http.StatusUnprocessableEntity, "seller_not_found", ErrSellerNotExist.Error(), "/contract/1"),
+			Status:   http.StatusUnprocessableEntity,
+			Models:   testModelSet{company: company, contract: contract},
+		},
+		// contract doesn't exist
+		{
+			Num:      "3",
+			ID:       2,
+			Request:  `{"amount":20}`,
+			Response: testProblem(http.StatusNotFound, "contract_not_found", ErrContractNotFound.Error(), "/contract/2"),
+			Status:   http.StatusNotFound,
+			Models:   testModelSet{company: company, contract: contract},
+		},
+		// malformed patch
+		{
+			Num:      "4",
+			ID:       1,
+			Request:  `{"amount":`,
+			Response: testProblem(http.StatusBadRequest, codeInvalidRequest, "unexpected EOF", "/contract/1"),
+			Status:   http.StatusBadRequest,
+			Models:   testModelSet{company: company, contract: contract},
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(c.Models.company, c.Models.contract, c.Models.purchase)
+
+		url := fmt.Sprintf("/contract/%d", c.ID)
+		req := httptest.NewRequest("PATCH", url, bytes.NewBufferString(c.Request))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		w := httptest.NewRecorder()
+
+		testHandle("/contract/{id:[0-9]+}", w, req, h.PatchContract)
+		testCheckResponse("PatchContract:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
@@ -0,0 +1,1679 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: gontracts.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Company struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name    string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Regcode *string                `protobuf:"bytes,3,opt,name=regcode,proto3,oneof" json:"regcode,omitempty"`
+	// version is incremented on every change.
+	// In update requests it is an expected version, zero matches any version
+	Version       int64 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Company) Reset() {
+	*x = Company{}
+	mi := &file_gontracts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Company) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Company) ProtoMessage() {}
+
+func (x *Company) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Company.ProtoReflect.Descriptor instead.
+func (*Company) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Company) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Company) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Company) GetRegcode() string {
+	if x != nil && x.Regcode != nil {
+		return *x.Regcode
+	}
+	return ""
+}
+
+func (x *Company) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Contract struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	SellerId  int64                  `protobuf:"varint,2,opt,name=seller_id,json=sellerId,proto3" json:"seller_id,omitempty"`
+	ClientId  int64                  `protobuf:"varint,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	ValidFrom *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=valid_from,json=validFrom,proto3" json:"valid_from,omitempty"`
+	ValidTo   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=valid_to,json=validTo,proto3" json:"valid_to,omitempty"`
+	Amount    int64                  `protobuf:"varint,6,opt,name=amount,proto3" json:"amount,omitempty"`
+	// version is incremented on every change.
+	// In update requests it is an expected version, zero matches any version
+	Version       int64 `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Contract) Reset() {
+	*x = Contract{}
+	mi := &file_gontracts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Contract) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Contract) ProtoMessage() {}
+
+func (x *Contract) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Contract.ProtoReflect.Descriptor instead.
+func (*Contract) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Contract) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Contract) GetSellerId() int64 {
+	if x != nil {
+		return x.SellerId
+	}
+	return 0
+}
+
+func (x *Contract) GetClientId() int64 {
+	if x != nil {
+		return x.ClientId
+	}
+	return 0
+}
+
+func (x *Contract) GetValidFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ValidFrom
+	}
+	return nil
+}
+
+func (x *Contract) GetValidTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ValidTo
+	}
+	return nil
+}
+
+func (x *Contract) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Contract) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Purchase struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ContractId    int64                  `protobuf:"varint,2,opt,name=contract_id,json=contractId,proto3" json:"contract_id,omitempty"`
+	Datetime      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=datetime,proto3" json:"datetime,omitempty"`
+	Amount        int64                  `protobuf:"varint,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Purchase) Reset() {
+	*x = Purchase{}
+	mi := &file_gontracts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Purchase) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Purchase) ProtoMessage() {}
+
+func (x *Purchase) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Purchase.ProtoReflect.Descriptor instead.
+func (*Purchase) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Purchase) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Purchase) GetContractId() int64 {
+	if x != nil {
+		return x.ContractId
+	}
+	return 0
+}
+
+func (x *Purchase) GetDatetime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Datetime
+	}
+	return nil
+}
+
+func (x *Purchase) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+// Sort is a list order, field is a JSON name as in the REST API
+type Sort struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Desc          bool                   `protobuf:"varint,2,opt,name=desc,proto3" json:"desc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sort) Reset() {
+	*x = Sort{}
+	mi := &file_gontracts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sort) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sort) ProtoMessage() {}
+
+func (x *Sort) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sort.ProtoReflect.Descriptor instead.
+func (*Sort) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Sort) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *Sort) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+// Page is a cursor based page request
+type Page struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor        string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Page) Reset() {
+	*x = Page{}
+	mi := &file_gontracts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Page) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Page) ProtoMessage() {}
+
+func (x *Page) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Page.ProtoReflect.Descriptor instead.
+func (*Page) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Page) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *Page) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type CreateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateResponse) Reset() {
+	*x = CreateResponse{}
+	mi := &file_gontracts_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateResponse) ProtoMessage() {}
+
+func (x *CreateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateResponse.ProtoReflect.Descriptor instead.
+func (*CreateResponse) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateResponse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// version is an expected version, zero matches any version
+	Version       int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_gontracts_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type GetCompanyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCompanyRequest) Reset() {
+	*x = GetCompanyRequest{}
+	mi := &file_gontracts_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCompanyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCompanyRequest) ProtoMessage() {}
+
+func (x *GetCompanyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCompanyRequest.ProtoReflect.Descriptor instead.
+func (*GetCompanyRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetCompanyRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListCompaniesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sort          *Sort                  `protobuf:"bytes,1,opt,name=sort,proto3" json:"sort,omitempty"`
+	Page          *Page                  `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCompaniesRequest) Reset() {
+	*x = ListCompaniesRequest{}
+	mi := &file_gontracts_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCompaniesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCompaniesRequest) ProtoMessage() {}
+
+func (x *ListCompaniesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCompaniesRequest.ProtoReflect.Descriptor instead.
+func (*ListCompaniesRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListCompaniesRequest) GetSort() *Sort {
+	if x != nil {
+		return x.Sort
+	}
+	return nil
+}
+
+func (x *ListCompaniesRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type ListCompaniesResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Companies []*Company             `protobuf:"bytes,1,rep,name=companies,proto3" json:"companies,omitempty"`
+	// next_cursor is empty on the last page
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCompaniesResponse) Reset() {
+	*x = ListCompaniesResponse{}
+	mi := &file_gontracts_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCompaniesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCompaniesResponse) ProtoMessage() {}
+
+func (x *ListCompaniesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCompaniesResponse.ProtoReflect.Descriptor instead.
+func (*ListCompaniesResponse) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListCompaniesResponse) GetCompanies() []*Company {
+	if x != nil {
+		return x.Companies
+	}
+	return nil
+}
+
+func (x *ListCompaniesResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type SearchCompaniesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchCompaniesRequest) Reset() {
+	*x = SearchCompaniesRequest{}
+	mi := &file_gontracts_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchCompaniesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchCompaniesRequest) ProtoMessage() {}
+
+func (x *SearchCompaniesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchCompaniesRequest.ProtoReflect.Descriptor instead.
+func (*SearchCompaniesRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SearchCompaniesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchCompaniesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type CompanyMatch struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Company *Company               `protobuf:"bytes,1,opt,name=company,proto3" json:"company,omitempty"`
+	Score   float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	// highlight contains HTML escaped matched fields with matches wrapped in <em> tags
+	Highlight     map[string]string `protobuf:"bytes,3,rep,name=highlight,proto3" json:"highlight,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompanyMatch) Reset() {
+	*x = CompanyMatch{}
+	mi := &file_gontracts_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompanyMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompanyMatch) ProtoMessage() {}
+
+func (x *CompanyMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompanyMatch.ProtoReflect.Descriptor instead.
+func (*CompanyMatch) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CompanyMatch) GetCompany() *Company {
+	if x != nil {
+		return x.Company
+	}
+	return nil
+}
+
+func (x *CompanyMatch) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *CompanyMatch) GetHighlight() map[string]string {
+	if x != nil {
+		return x.Highlight
+	}
+	return nil
+}
+
+type SearchCompaniesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matches       []*CompanyMatch        `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchCompaniesResponse) Reset() {
+	*x = SearchCompaniesResponse{}
+	mi := &file_gontracts_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchCompaniesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchCompaniesResponse) ProtoMessage() {}
+
+func (x *SearchCompaniesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchCompaniesResponse.ProtoReflect.Descriptor instead.
+func (*SearchCompaniesResponse) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SearchCompaniesResponse) GetMatches() []*CompanyMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+type CreateCompanyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Company       *Company               `protobuf:"bytes,1,opt,name=company,proto3" json:"company,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCompanyRequest) Reset() {
+	*x = CreateCompanyRequest{}
+	mi := &file_gontracts_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCompanyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCompanyRequest) ProtoMessage() {}
+
+func (x *CreateCompanyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCompanyRequest.ProtoReflect.Descriptor instead.
+func (*CreateCompanyRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CreateCompanyRequest) GetCompany() *Company {
+	if x != nil {
+		return x.Company
+	}
+	return nil
+}
+
+type UpdateCompanyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Company       *Company               `protobuf:"bytes,1,opt,name=company,proto3" json:"company,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCompanyRequest) Reset() {
+	*x = UpdateCompanyRequest{}
+	mi := &file_gontracts_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCompanyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCompanyRequest) ProtoMessage() {}
+
+func (x *UpdateCompanyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCompanyRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCompanyRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateCompanyRequest) GetCompany() *Company {
+	if x != nil {
+		return x.Company
+	}
+	return nil
+}
+
+type GetContractRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetContractRequest) Reset() {
+	*x = GetContractRequest{}
+	mi := &file_gontracts_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetContractRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetContractRequest) ProtoMessage() {}
+
+func (x *GetContractRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetContractRequest.ProtoReflect.Descriptor instead.
+func (*GetContractRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetContractRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListContractsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	SellerId int64                  `protobuf:"varint,1,opt,name=seller_id,json=sellerId,proto3" json:"seller_id,omitempty"`
+	ClientId int64                  `protobuf:"varint,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// active_from and active_to select contracts valid at some moment of the window
+	ActiveFrom    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=active_from,json=activeFrom,proto3" json:"active_from,omitempty"`
+	ActiveTo      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=active_to,json=activeTo,proto3" json:"active_to,omitempty"`
+	Sort          *Sort                  `protobuf:"bytes,5,opt,name=sort,proto3" json:"sort,omitempty"`
+	Page          *Page                  `protobuf:"bytes,6,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListContractsRequest) Reset() {
+	*x = ListContractsRequest{}
+	mi := &file_gontracts_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListContractsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListContractsRequest) ProtoMessage() {}
+
+func (x *ListContractsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListContractsRequest.ProtoReflect.Descriptor instead.
+func (*ListContractsRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListContractsRequest) GetSellerId() int64 {
+	if x != nil {
+		return x.SellerId
+	}
+	return 0
+}
+
+func (x *ListContractsRequest) GetClientId() int64 {
+	if x != nil {
+		return x.ClientId
+	}
+	return 0
+}
+
+func (x *ListContractsRequest) GetActiveFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ActiveFrom
+	}
+	return nil
+}
+
+func (x *ListContractsRequest) GetActiveTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ActiveTo
+	}
+	return nil
+}
+
+func (x *ListContractsRequest) GetSort() *Sort {
+	if x != nil {
+		return x.Sort
+	}
+	return nil
+}
+
+func (x *ListContractsRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type ListContractsResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Contracts []*Contract            `protobuf:"bytes,1,rep,name=contracts,proto3" json:"contracts,omitempty"`
+	// next_cursor is empty on the last page
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListContractsResponse) Reset() {
+	*x = ListContractsResponse{}
+	mi := &file_gontracts_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListContractsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListContractsResponse) ProtoMessage() {}
+
+func (x *ListContractsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListContractsResponse.ProtoReflect.Descriptor instead.
+func (*ListContractsResponse) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListContractsResponse) GetContracts() []*Contract {
+	if x != nil {
+		return x.Contracts
+	}
+	return nil
+}
+
+func (x *ListContractsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type CreateContractRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Contract      *Contract              `protobuf:"bytes,1,opt,name=contract,proto3" json:"contract,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateContractRequest) Reset() {
+	*x = CreateContractRequest{}
+	mi := &file_gontracts_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateContractRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateContractRequest) ProtoMessage() {}
+
+func (x *CreateContractRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateContractRequest.ProtoReflect.Descriptor instead.
+func (*CreateContractRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CreateContractRequest) GetContract() *Contract {
+	if x != nil {
+		return x.Contract
+	}
+	return nil
+}
+
+type UpdateContractRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Contract      *Contract              `protobuf:"bytes,1,opt,name=contract,proto3" json:"contract,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateContractRequest) Reset() {
+	*x = UpdateContractRequest{}
+	mi := &file_gontracts_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateContractRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateContractRequest) ProtoMessage() {}
+
+func (x *UpdateContractRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateContractRequest.ProtoReflect.Descriptor instead.
+func (*UpdateContractRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UpdateContractRequest) GetContract() *Contract {
+	if x != nil {
+		return x.Contract
+	}
+	return nil
+}
+
+type GetContractBalanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContractId    int64                  `protobuf:"varint,1,opt,name=contract_id,json=contractId,proto3" json:"contract_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetContractBalanceRequest) Reset() {
+	*x = GetContractBalanceRequest{}
+	mi := &file_gontracts_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetContractBalanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetContractBalanceRequest) ProtoMessage() {}
+
+func (x *GetContractBalanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetContractBalanceRequest.ProtoReflect.Descriptor instead.
+func (*GetContractBalanceRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetContractBalanceRequest) GetContractId() int64 {
+	if x != nil {
+		return x.ContractId
+	}
+	return 0
+}
+
+type ContractBalance struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContractId    int64                  `protobuf:"varint,1,opt,name=contract_id,json=contractId,proto3" json:"contract_id,omitempty"`
+	Amount        int64                  `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Spent         int64                  `protobuf:"varint,3,opt,name=spent,proto3" json:"spent,omitempty"`
+	Remaining     int64                  `protobuf:"varint,4,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContractBalance) Reset() {
+	*x = ContractBalance{}
+	mi := &file_gontracts_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContractBalance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContractBalance) ProtoMessage() {}
+
+func (x *ContractBalance) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContractBalance.ProtoReflect.Descriptor instead.
+func (*ContractBalance) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ContractBalance) GetContractId() int64 {
+	if x != nil {
+		return x.ContractId
+	}
+	return 0
+}
+
+func (x *ContractBalance) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ContractBalance) GetSpent() int64 {
+	if x != nil {
+		return x.Spent
+	}
+	return 0
+}
+
+func (x *ContractBalance) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+type CreatePurchaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Purchase      *Purchase              `protobuf:"bytes,1,opt,name=purchase,proto3" json:"purchase,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePurchaseRequest) Reset() {
+	*x = CreatePurchaseRequest{}
+	mi := &file_gontracts_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePurchaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePurchaseRequest) ProtoMessage() {}
+
+func (x *CreatePurchaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePurchaseRequest.ProtoReflect.Descriptor instead.
+func (*CreatePurchaseRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *CreatePurchaseRequest) GetPurchase() *Purchase {
+	if x != nil {
+		return x.Purchase
+	}
+	return nil
+}
+
+type GetPurchaseHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContractId    int64                  `protobuf:"varint,1,opt,name=contract_id,json=contractId,proto3" json:"contract_id,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	MinAmount     *int64                 `protobuf:"varint,4,opt,name=min_amount,json=minAmount,proto3,oneof" json:"min_amount,omitempty"`
+	MaxAmount     *int64                 `protobuf:"varint,5,opt,name=max_amount,json=maxAmount,proto3,oneof" json:"max_amount,omitempty"`
+	Sort          *Sort                  `protobuf:"bytes,6,opt,name=sort,proto3" json:"sort,omitempty"`
+	Page          *Page                  `protobuf:"bytes,7,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPurchaseHistoryRequest) Reset() {
+	*x = GetPurchaseHistoryRequest{}
+	mi := &file_gontracts_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPurchaseHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPurchaseHistoryRequest) ProtoMessage() {}
+
+func (x *GetPurchaseHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPurchaseHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetPurchaseHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetPurchaseHistoryRequest) GetContractId() int64 {
+	if x != nil {
+		return x.ContractId
+	}
+	return 0
+}
+
+func (x *GetPurchaseHistoryRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetPurchaseHistoryRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetPurchaseHistoryRequest) GetMinAmount() int64 {
+	if x != nil && x.MinAmount != nil {
+		return *x.MinAmount
+	}
+	return 0
+}
+
+func (x *GetPurchaseHistoryRequest) GetMaxAmount() int64 {
+	if x != nil && x.MaxAmount != nil {
+		return *x.MaxAmount
+	}
+	return 0
+}
+
+func (x *GetPurchaseHistoryRequest) GetSort() *Sort {
+	if x != nil {
+		return x.Sort
+	}
+	return nil
+}
+
+func (x *GetPurchaseHistoryRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type GetPurchaseHistoryResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Purchases []*Purchase            `protobuf:"bytes,1,rep,name=purchases,proto3" json:"purchases,omitempty"`
+	// next_cursor is empty on the last page
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPurchaseHistoryResponse) Reset() {
+	*x = GetPurchaseHistoryResponse{}
+	mi := &file_gontracts_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPurchaseHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPurchaseHistoryResponse) ProtoMessage() {}
+
+func (x *GetPurchaseHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gontracts_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPurchaseHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetPurchaseHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_gontracts_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetPurchaseHistoryResponse) GetPurchases() []*Purchase {
+	if x != nil {
+		return x.Purchases
+	}
+	return nil
+}
+
+func (x *GetPurchaseHistoryResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+var File_gontracts_proto protoreflect.FileDescriptor
+
+const file_gontracts_proto_rawDesc = "" +
+	"\n" +
+	"\x0fgontracts.proto\x12\fgontracts.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"r\n" +
+	"\aCompany\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\aregcode\x18\x03 \x01(\tH\x00R\aregcode\x88\x01\x01\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversionB\n" +
+	"\n" +
+	"\b_regcode\"\xf8\x01\n" +
+	"\bContract\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1b\n" +
+	"\tseller_id\x18\x02 \x01(\x03R\bsellerId\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\x03R\bclientId\x129\n" +
+	"\n" +
+	"valid_from\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tvalidFrom\x125\n" +
+	"\bvalid_to\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\avalidTo\x12\x16\n" +
+	"\x06amount\x18\x06 \x01(\x03R\x06amount\x12\x18\n" +
+	"\aversion\x18\a \x01(\x03R\aversion\"\x8b\x01\n" +
+	"\bPurchase\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1f\n" +
+	"\vcontract_id\x18\x02 \x01(\x03R\n" +
+	"contractId\x126\n" +
+	"\bdatetime\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bdatetime\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x03R\x06amount\"0\n" +
+	"\x04Sort\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x12\n" +
+	"\x04desc\x18\x02 \x01(\bR\x04desc\"4\n" +
+	"\x04Page\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\" \n" +
+	"\x0eCreateResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"9\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"#\n" +
+	"\x11GetCompanyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"f\n" +
+	"\x14ListCompaniesRequest\x12&\n" +
+	"\x04sort\x18\x01 \x01(\v2\x12.gontracts.v1.SortR\x04sort\x12&\n" +
+	"\x04page\x18\x02 \x01(\v2\x12.gontracts.v1.PageR\x04page\"m\n" +
+	"\x15ListCompaniesResponse\x123\n" +
+	"\tcompanies\x18\x01 \x03(\v2\x15.gontracts.v1.CompanyR\tcompanies\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"D\n" +
+	"\x16SearchCompaniesRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"\xdc\x01\n" +
+	"\fCompanyMatch\x12/\n" +
+	"\acompany\x18\x01 \x01(\v2\x15.gontracts.v1.CompanyR\acompany\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12G\n" +
+	"\thighlight\x18\x03 \x03(\v2).gontracts.v1.CompanyMatch.HighlightEntryR\thighlight\x1a<\n" +
+	"\x0eHighlightEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"O\n" +
+	"\x17SearchCompaniesResponse\x124\n" +
+	"\amatches\x18\x01 \x03(\v2\x1a.gontracts.v1.CompanyMatchR\amatches\"G\n" +
+	"\x14CreateCompanyRequest\x12/\n" +
+	"\acompany\x18\x01 \x01(\v2\x15.gontracts.v1.CompanyR\acompany\"G\n" +
+	"\x14UpdateCompanyRequest\x12/\n" +
+	"\acompany\x18\x01 \x01(\v2\x15.gontracts.v1.CompanyR\acompany\"$\n" +
+	"\x12GetContractRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x96\x02\n" +
+	"\x14ListContractsRequest\x12\x1b\n" +
+	"\tseller_id\x18\x01 \x01(\x03R\bsellerId\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\x03R\bclientId\x12;\n" +
+	"\vactive_from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"activeFrom\x127\n" +
+	"\tactive_to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\bactiveTo\x12&\n" +
+	"\x04sort\x18\x05 \x01(\v2\x12.gontracts.v1.SortR\x04sort\x12&\n" +
+	"\x04page\x18\x06 \x01(\v2\x12.gontracts.v1.PageR\x04page\"n\n" +
+	"\x15ListContractsResponse\x124\n" +
+	"\tcontracts\x18\x01 \x03(\v2\x16.gontracts.v1.ContractR\tcontracts\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"K\n" +
+	"\x15CreateContractRequest\x122\n" +
+	"\bcontract\x18\x01 \x01(\v2\x16.gontracts.v1.ContractR\bcontract\"K\n" +
+	"\x15UpdateContractRequest\x122\n" +
+	"\bcontract\x18\x01 \x01(\v2\x16.gontracts.v1.ContractR\bcontract\"<\n" +
+	"\x19GetContractBalanceRequest\x12\x1f\n" +
+	"\vcontract_id\x18\x01 \x01(\x03R\n" +
+	"contractId\"~\n" +
+	"\x0fContractBalance\x12\x1f\n" +
+	"\vcontract_id\x18\x01 \x01(\x03R\n" +
+	"contractId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x03R\x06amount\x12\x14\n" +
+	"\x05spent\x18\x03 \x01(\x03R\x05spent\x12\x1c\n" +
+	"\tremaining\x18\x04 \x01(\x03R\tremaining\"K\n" +
+	"\x15CreatePurchaseRequest\x122\n" +
+	"\bpurchase\x18\x01 \x01(\v2\x16.gontracts.v1.PurchaseR\bpurchase\"\xce\x02\n" +
+	"\x19GetPurchaseHistoryRequest\x12\x1f\n" +
+	"\vcontract_id\x18\x01 \x01(\x03R\n" +
+	"contractId\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\"\n" +
+	"\n" +
+	"min_amount\x18\x04 \x01(\x03H\x00R\tminAmount\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"max_amount\x18\x05 \x01(\x03H\x01R\tmaxAmount\x88\x01\x01\x12&\n" +
+	"\x04sort\x18\x06 \x01(\v2\x12.gontracts.v1.SortR\x04sort\x12&\n" +
+	"\x04page\x18\a \x01(\v2\x12.gontracts.v1.PageR\x04pageB\r\n" +
+	"\v_min_amountB\r\n" +
+	"\v_max_amount\"s\n" +
+	"\x1aGetPurchaseHistoryResponse\x124\n" +
+	"\tpurchases\x18\x01 \x03(\v2\x16.gontracts.v1.PurchaseR\tpurchases\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor2\x9a\t\n" +
+	"\tGontracts\x12D\n" +
+	"\n" +
+	"GetCompany\x12\x1f.gontracts.v1.GetCompanyRequest\x1a\x15.gontracts.v1.Company\x12X\n" +
+	"\rListCompanies\x12\".gontracts.v1.ListCompaniesRequest\x1a#.gontracts.v1.ListCompaniesResponse\x12^\n" +
+	"\x0fSearchCompanies\x12$.gontracts.v1.SearchCompaniesRequest\x1a%.gontracts.v1.SearchCompaniesResponse\x12Q\n" +
+	"\rCreateCompany\x12\".gontracts.v1.CreateCompanyRequest\x1a\x1c.gontracts.v1.CreateResponse\x12J\n" +
+	"\rUpdateCompany\x12\".gontracts.v1.UpdateCompanyRequest\x1a\x15.gontracts.v1.Company\x12D\n" +
+	"\rDeleteCompany\x12\x1b.gontracts.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\x12G\n" +
+	"\vGetContract\x12 .gontracts.v1.GetContractRequest\x1a\x16.gontracts.v1.Contract\x12X\n" +
+	"\rListContracts\x12\".gontracts.v1.ListContractsRequest\x1a#.gontracts.v1.ListContractsResponse\x12S\n" +
+	"\x0eCreateContract\x12#.gontracts.v1.CreateContractRequest\x1a\x1c.gontracts.v1.CreateResponse\x12M\n" +
+	"\x0eUpdateContract\x12#.gontracts.v1.UpdateContractRequest\x1a\x16.gontracts.v1.Contract\x12E\n" +
+	"\x0eDeleteContract\x12\x1b.gontracts.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\x12\\\n" +
+	"\x12GetContractBalance\x12'.gontracts.v1.GetContractBalanceRequest\x1a\x1d.gontracts.v1.ContractBalance\x12S\n" +
+	"\x0eCreatePurchase\x12#.gontracts.v1.CreatePurchaseRequest\x1a\x1c.gontracts.v1.CreateResponse\x12g\n" +
+	"\x12GetPurchaseHistory\x12'.gontracts.v1.GetPurchaseHistoryRequest\x1a(.gontracts.v1.GetPurchaseHistoryResponseB(Z&github.com/ilyakaznacheev/gontracts/pbb\x06proto3"
+
+var (
+	file_gontracts_proto_rawDescOnce sync.Once
+	file_gontracts_proto_rawDescData []byte
+)
+
+func file_gontracts_proto_rawDescGZIP() []byte {
+	file_gontracts_proto_rawDescOnce.Do(func() {
+		file_gontracts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gontracts_proto_rawDesc), len(file_gontracts_proto_rawDesc)))
+	})
+	return file_gontracts_proto_rawDescData
+}
+
+var file_gontracts_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_gontracts_proto_goTypes = []any{
+	(*Company)(nil),                    // 0: gontracts.v1.Company
+	(*Contract)(nil),                   // 1: gontracts.v1.Contract
+	(*Purchase)(nil),                   // 2: gontracts.v1.Purchase
+	(*Sort)(nil),                       // 3: gontracts.v1.Sort
+	(*Page)(nil),                       // 4: gontracts.v1.Page
+	(*CreateResponse)(nil),             // 5: gontracts.v1.CreateResponse
+	(*DeleteRequest)(nil),              // 6: gontracts.v1.DeleteRequest
+	(*GetCompanyRequest)(nil),          // 7: gontracts.v1.GetCompanyRequest
+	(*ListCompaniesRequest)(nil),       // 8: gontracts.v1.ListCompaniesRequest
+	(*ListCompaniesResponse)(nil),      // 9: gontracts.v1.ListCompaniesResponse
+	(*SearchCompaniesRequest)(nil),     // 10: gontracts.v1.SearchCompaniesRequest
+	(*CompanyMatch)(nil),               // 11: gontracts.v1.CompanyMatch
+	(*SearchCompaniesResponse)(nil),    // 12: gontracts.v1.SearchCompaniesResponse
+	(*CreateCompanyRequest)(nil),       // 13: gontracts.v1.CreateCompanyRequest
+	(*UpdateCompanyRequest)(nil),       // 14: gontracts.v1.UpdateCompanyRequest
+	(*GetContractRequest)(nil),         // 15: gontracts.v1.GetContractRequest
+	(*ListContractsRequest)(nil),       // 16: gontracts.v1.ListContractsRequest
+	(*ListContractsResponse)(nil),      // 17: gontracts.v1.ListContractsResponse
+	(*CreateContractRequest)(nil),      // 18: gontracts.v1.CreateContractRequest
+	(*UpdateContractRequest)(nil),      // 19: gontracts.v1.UpdateContractRequest
+	(*GetContractBalanceRequest)(nil),  // 20: gontracts.v1.GetContractBalanceRequest
+	(*ContractBalance)(nil),            // 21: gontracts.v1.ContractBalance
+	(*CreatePurchaseRequest)(nil),      // 22: gontracts.v1.CreatePurchaseRequest
+	(*GetPurchaseHistoryRequest)(nil),  // 23: gontracts.v1.GetPurchaseHistoryRequest
+	(*GetPurchaseHistoryResponse)(nil), // 24: gontracts.v1.GetPurchaseHistoryResponse
+	nil,                                // 25: gontracts.v1.CompanyMatch.HighlightEntry
+	(*timestamppb.Timestamp)(nil),      // 26: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),              // 27: google.protobuf.Empty
+}
+var file_gontracts_proto_depIdxs = []int32{
+	26, // 0: gontracts.v1.Contract.valid_from:type_name -> google.protobuf.Timestamp
+	26, // 1: gontracts.v1.Contract.valid_to:type_name -> google.protobuf.Timestamp
+	26, // 2: gontracts.v1.Purchase.datetime:type_name -> google.protobuf.Timestamp
+	3,  // 3: gontracts.v1.ListCompaniesRequest.sort:type_name -> gontracts.v1.Sort
+	4,  // 4: gontracts.v1.ListCompaniesRequest.page:type_name -> gontracts.v1.Page
+	0,  // 5: gontracts.v1.ListCompaniesResponse.companies:type_name -> gontracts.v1.Company
+	0,  // 6: gontracts.v1.CompanyMatch.company:type_name -> gontracts.v1.Company
+	25, // 7: gontracts.v1.CompanyMatch.highlight:type_name -> gontracts.v1.CompanyMatch.HighlightEntry
+	11, // 8: gontracts.v1.SearchCompaniesResponse.matches:type_name -> gontracts.v1.CompanyMatch
+	0,  // 9: gontracts.v1.CreateCompanyRequest.company:type_name -> gontracts.v1.Company
+	0,  // 10: gontracts.v1.UpdateCompanyRequest.company:type_name -> gontracts.v1.Company
+	26, // 11: gontracts.v1.ListContractsRequest.active_from:type_name -> google.protobuf.Timestamp
+	26, // 12: gontracts.v1.ListContractsRequest.active_to:type_name -> google.protobuf.Timestamp
+	3,  // 13: gontracts.v1.ListContractsRequest.sort:type_name -> gontracts.v1.Sort
+	4,  // 14: gontracts.v1.ListContractsRequest.page:type_name -> gontracts.v1.Page
+	1,  // 15: gontracts.v1.ListContractsResponse.contracts:type_name -> gontracts.v1.Contract
+	1,  // 16: gontracts.v1.CreateContractRequest.contract:type_name -> gontracts.v1.Contract
+	1,  // 17: gontracts.v1.UpdateContractRequest.contract:type_name -> gontracts.v1.Contract
+	2,  // 18: gontracts.v1.CreatePurchaseRequest.purchase:type_name -> gontracts.v1.Purchase
+	26, // 19: gontracts.v1.GetPurchaseHistoryRequest.from:type_name -> google.protobuf.Timestamp
+	26, // 20: gontracts.v1.GetPurchaseHistoryRequest.to:type_name -> google.protobuf.Timestamp
+	3,  // 21: gontracts.v1.GetPurchaseHistoryRequest.sort:type_name -> gontracts.v1.Sort
+	4,  // 22: gontracts.v1.GetPurchaseHistoryRequest.page:type_name -> gontracts.v1.Page
+	2,  // 23: gontracts.v1.GetPurchaseHistoryResponse.purchases:type_name -> gontracts.v1.Purchase
+	7,  // 24: gontracts.v1.Gontracts.GetCompany:input_type -> gontracts.v1.GetCompanyRequest
+	8,  // 25: gontracts.v1.Gontracts.ListCompanies:input_type -> gontracts.v1.ListCompaniesRequest
+	10, // 26: gontracts.v1.Gontracts.SearchCompanies:input_type -> gontracts.v1.SearchCompaniesRequest
+	13, // 27: gontracts.v1.Gontracts.CreateCompany:input_type -> gontracts.v1.CreateCompanyRequest
+	14, // 28: gontracts.v1.Gontracts.UpdateCompany:input_type -> gontracts.v1.UpdateCompanyRequest
+	6,  // 29: gontracts.v1.Gontracts.DeleteCompany:input_type -> gontracts.v1.DeleteRequest
+	15, // 30: gontracts.v1.Gontracts.GetContract:input_type -> gontracts.v1.GetContractRequest
+	16, // 31: gontracts.v1.Gontracts.ListContracts:input_type -> gontracts.v1.ListContractsRequest
+	18, // 32: gontracts.v1.Gontracts.CreateContract:input_type -> gontracts.v1.CreateContractRequest
+	19, // 33: gontracts.v1.Gontracts.UpdateContract:input_type -> gontracts.v1.UpdateContractRequest
+	6,  // 34: gontracts.v1.Gontracts.DeleteContract:input_type -> gontracts.v1.DeleteRequest
+	20, // 35: gontracts.v1.Gontracts.GetContractBalance:input_type -> gontracts.v1.GetContractBalanceRequest
+	22, // 36: gontracts.v1.Gontracts.CreatePurchase:input_type -> gontracts.v1.CreatePurchaseRequest
+	23, // 37: gontracts.v1.Gontracts.GetPurchaseHistory:input_type -> gontracts.v1.GetPurchaseHistoryRequest
+	0,  // 38: gontracts.v1.Gontracts.GetCompany:output_type -> gontracts.v1.Company
+	9,  // 39: gontracts.v1.Gontracts.ListCompanies:output_type -> gontracts.v1.ListCompaniesResponse
+	12, // 40: gontracts.v1.Gontracts.SearchCompanies:output_type -> gontracts.v1.SearchCompaniesResponse
+	5,  // 41: gontracts.v1.Gontracts.CreateCompany:output_type -> gontracts.v1.CreateResponse
+	0,  // 42: gontracts.v1.Gontracts.UpdateCompany:output_type -> gontracts.v1.Company
+	27, // 43: gontracts.v1.Gontracts.DeleteCompany:output_type -> google.protobuf.Empty
+	1,  // 44: gontracts.v1.Gontracts.GetContract:output_type -> gontracts.v1.Contract
+	17, // 45: gontracts.v1.Gontracts.ListContracts:output_type -> gontracts.v1.ListContractsResponse
+	5,  // 46: gontracts.v1.Gontracts.CreateContract:output_type -> gontracts.v1.CreateResponse
+	1,  // 47: gontracts.v1.Gontracts.UpdateContract:output_type -> gontracts.v1.Contract
+	27, // 48: gontracts.v1.Gontracts.DeleteContract:output_type -> google.protobuf.Empty
+	21, // 49: gontracts.v1.Gontracts.GetContractBalance:output_type -> gontracts.v1.ContractBalance
+	5,  // 50: gontracts.v1.Gontracts.CreatePurchase:output_type -> gontracts.v1.CreateResponse
+	24, // 51: gontracts.v1.Gontracts.GetPurchaseHistory:output_type -> gontracts.v1.GetPurchaseHistoryResponse
+	38, // [38:52] is the sub-list for method output_type
+	24, // [24:38] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
+}
+
+func init() { file_gontracts_proto_init() }
+func file_gontracts_proto_init() {
+	if File_gontracts_proto != nil {
+		return
+	}
+	file_gontracts_proto_msgTypes[0].OneofWrappers = []any{}
+	file_gontracts_proto_msgTypes[23].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gontracts_proto_rawDesc), len(file_gontracts_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   26,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gontracts_proto_goTypes,
+		DependencyIndexes: file_gontracts_proto_depIdxs,
+		MessageInfos:      file_gontracts_proto_msgTypes,
+	}.Build()
+	File_gontracts_proto = out.File
+	file_gontracts_proto_goTypes = nil
+	file_gontracts_proto_depIdxs = nil
+}
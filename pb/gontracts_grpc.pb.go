@@ -0,0 +1,628 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: gontracts.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Gontracts_GetCompany_FullMethodName         = "/gontracts.v1.Gontracts/GetCompany"
+	Gontracts_ListCompanies_FullMethodName      = "/gontracts.v1.Gontracts/ListCompanies"
+	Gontracts_SearchCompanies_FullMethodName    = "/gontracts.v1.Gontracts/SearchCompanies"
+	Gontracts_CreateCompany_FullMethodName      = "/gontracts.v1.Gontracts/CreateCompany"
+	Gontracts_UpdateCompany_FullMethodName      = "/gontracts.v1.Gontracts/UpdateCompany"
+	Gontracts_DeleteCompany_FullMethodName      = "/gontracts.v1.Gontracts/DeleteCompany"
+	Gontracts_GetContract_FullMethodName        = "/gontracts.v1.Gontracts/GetContract"
+	Gontracts_ListContracts_FullMethodName      = "/gontracts.v1.Gontracts/ListContracts"
+	Gontracts_CreateContract_FullMethodName     = "/gontracts.v1.Gontracts/CreateContract"
+	Gontracts_UpdateContract_FullMethodName     = "/gontracts.v1.Gontracts/UpdateContract"
+	Gontracts_DeleteContract_FullMethodName     = "/gontracts.v1.Gontracts/DeleteContract"
+	Gontracts_GetContractBalance_FullMethodName = "/gontracts.v1.Gontracts/GetContractBalance"
+	Gontracts_CreatePurchase_FullMethodName     = "/gontracts.v1.Gontracts/CreatePurchase"
+	Gontracts_GetPurchaseHistory_FullMethodName = "/gontracts.v1.Gontracts/GetPurchaseHistory"
+)
+
+// GontractsClient is the client API for Gontracts service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Gontracts exposes the same operations as the REST API.
+// Requests are authenticated with "authorization: Bearer <token>" or "x-api-key" metadata.
+// Errors carry google.rpc.ErrorInfo with the REST problem code as a reason.
+type GontractsClient interface {
+	GetCompany(ctx context.Context, in *GetCompanyRequest, opts ...grpc.CallOption) (*Company, error)
+	ListCompanies(ctx context.Context, in *ListCompaniesRequest, opts ...grpc.CallOption) (*ListCompaniesResponse, error)
+	SearchCompanies(ctx context.Context, in *SearchCompaniesRequest, opts ...grpc.CallOption) (*SearchCompaniesResponse, error)
+	CreateCompany(ctx context.Context, in *CreateCompanyRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// UpdateCompany creates company without id or updates existing one
+	UpdateCompany(ctx context.Context, in *UpdateCompanyRequest, opts ...grpc.CallOption) (*Company, error)
+	DeleteCompany(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetContract(ctx context.Context, in *GetContractRequest, opts ...grpc.CallOption) (*Contract, error)
+	ListContracts(ctx context.Context, in *ListContractsRequest, opts ...grpc.CallOption) (*ListContractsResponse, error)
+	CreateContract(ctx context.Context, in *CreateContractRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// UpdateContract creates contract without id or updates existing one
+	UpdateContract(ctx context.Context, in *UpdateContractRequest, opts ...grpc.CallOption) (*Contract, error)
+	DeleteContract(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetContractBalance(ctx context.Context, in *GetContractBalanceRequest, opts ...grpc.CallOption) (*ContractBalance, error)
+	CreatePurchase(ctx context.Context, in *CreatePurchaseRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	GetPurchaseHistory(ctx context.Context, in *GetPurchaseHistoryRequest, opts ...grpc.CallOption) (*GetPurchaseHistoryResponse, error)
+}
+
+type gontractsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGontractsClient(cc grpc.ClientConnInterface) GontractsClient {
+	return &gontractsClient{cc}
+}
+
+func (c *gontractsClient) GetCompany(ctx context.Context, in *GetCompanyRequest, opts ...grpc.CallOption) (*Company, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Company)
+	err := c.cc.Invoke(ctx, Gontracts_GetCompany_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) ListCompanies(ctx context.Context, in *ListCompaniesRequest, opts ...grpc.CallOption) (*ListCompaniesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCompaniesResponse)
+	err := c.cc.Invoke(ctx, Gontracts_ListCompanies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) SearchCompanies(ctx context.Context, in *SearchCompaniesRequest, opts ...grpc.CallOption) (*SearchCompaniesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchCompaniesResponse)
+	err := c.cc.Invoke(ctx, Gontracts_SearchCompanies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) CreateCompany(ctx context.Context, in *CreateCompanyRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, Gontracts_CreateCompany_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) UpdateCompany(ctx context.Context, in *UpdateCompanyRequest, opts ...grpc.CallOption) (*Company, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Company)
+	err := c.cc.Invoke(ctx, Gontracts_UpdateCompany_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) DeleteCompany(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Gontracts_DeleteCompany_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) GetContract(ctx context.Context, in *GetContractRequest, opts ...grpc.CallOption) (*Contract, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Contract)
+	err := c.cc.Invoke(ctx, Gontracts_GetContract_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) ListContracts(ctx context.Context, in *ListContractsRequest, opts ...grpc.CallOption) (*ListContractsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListContractsResponse)
+	err := c.cc.Invoke(ctx, Gontracts_ListContracts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) CreateContract(ctx context.Context, in *CreateContractRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, Gontracts_CreateContract_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) UpdateContract(ctx context.Context, in *UpdateContractRequest, opts ...grpc.CallOption) (*Contract, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Contract)
+	err := c.cc.Invoke(ctx, Gontracts_UpdateContract_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) DeleteContract(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Gontracts_DeleteContract_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) GetContractBalance(ctx context.Context, in *GetContractBalanceRequest, opts ...grpc.CallOption) (*ContractBalance, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ContractBalance)
+	err := c.cc.Invoke(ctx, Gontracts_GetContractBalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) CreatePurchase(ctx context.Context, in *CreatePurchaseRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, Gontracts_CreatePurchase_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gontractsClient) GetPurchaseHistory(ctx context.Context, in *GetPurchaseHistoryRequest, opts ...grpc.CallOption) (*GetPurchaseHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPurchaseHistoryResponse)
+	err := c.cc.Invoke(ctx, Gontracts_GetPurchaseHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GontractsServer is the server API for Gontracts service.
+// All implementations must embed UnimplementedGontractsServer
+// for forward compatibility.
+//
+// Gontracts exposes the same operations as the REST API.
+// Requests are authenticated with "authorization: Bearer <token>" or "x-api-key" metadata.
+// Errors carry google.rpc.ErrorInfo with the REST problem code as a reason.
+type GontractsServer interface {
+	GetCompany(context.Context, *GetCompanyRequest) (*Company, error)
+	ListCompanies(context.Context, *ListCompaniesRequest) (*ListCompaniesResponse, error)
+	SearchCompanies(context.Context, *SearchCompaniesRequest) (*SearchCompaniesResponse, error)
+	CreateCompany(context.Context, *CreateCompanyRequest) (*CreateResponse, error)
+	// UpdateCompany creates company without id or updates existing one
+	UpdateCompany(context.Context, *UpdateCompanyRequest) (*Company, error)
+	DeleteCompany(context.Context, *DeleteRequest) (*emptypb.Empty, error)
+	GetContract(context.Context, *GetContractRequest) (*Contract, error)
+	ListContracts(context.Context, *ListContractsRequest) (*ListContractsResponse, error)
+	CreateContract(context.Context, *CreateContractRequest) (*CreateResponse, error)
+	// UpdateContract creates contract without id or updates existing one
+	UpdateContract(context.Context, *UpdateContractRequest) (*Contract, error)
+	DeleteContract(context.Context, *DeleteRequest) (*emptypb.Empty, error)
+	GetContractBalance(context.Context, *GetContractBalanceRequest) (*ContractBalance, error)
+	CreatePurchase(context.Context, *CreatePurchaseRequest) (*CreateResponse, error)
+	GetPurchaseHistory(context.Context, *GetPurchaseHistoryRequest) (*GetPurchaseHistoryResponse, error)
+	mustEmbedUnimplementedGontractsServer()
+}
+
+// UnimplementedGontractsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGontractsServer struct{}
+
+func (UnimplementedGontractsServer) GetCompany(context.Context, *GetCompanyRequest) (*Company, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCompany not implemented")
+}
+func (UnimplementedGontractsServer) ListCompanies(context.Context, *ListCompaniesRequest) (*ListCompaniesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCompanies not implemented")
+}
+func (UnimplementedGontractsServer) SearchCompanies(context.Context, *SearchCompaniesRequest) (*SearchCompaniesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchCompanies not implemented")
+}
+func (UnimplementedGontractsServer) CreateCompany(context.Context, *CreateCompanyRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCompany not implemented")
+}
+func (UnimplementedGontractsServer) UpdateCompany(context.Context, *UpdateCompanyRequest) (*Company, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCompany not implemented")
+}
+func (UnimplementedGontractsServer) DeleteCompany(context.Context, *DeleteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCompany not implemented")
+}
+func (UnimplementedGontractsServer) GetContract(context.Context, *GetContractRequest) (*Contract, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetContract not implemented")
+}
+func (UnimplementedGontractsServer) ListContracts(context.Context, *ListContractsRequest) (*ListContractsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListContracts not implemented")
+}
+func (UnimplementedGontractsServer) CreateContract(context.Context, *CreateContractRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateContract not implemented")
+}
+func (UnimplementedGontractsServer) UpdateContract(context.Context, *UpdateContractRequest) (*Contract, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateContract not implemented")
+}
+func (UnimplementedGontractsServer) DeleteContract(context.Context, *DeleteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteContract not implemented")
+}
+func (UnimplementedGontractsServer) GetContractBalance(context.Context, *GetContractBalanceRequest) (*ContractBalance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetContractBalance not implemented")
+}
+func (UnimplementedGontractsServer) CreatePurchase(context.Context, *CreatePurchaseRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePurchase not implemented")
+}
+func (UnimplementedGontractsServer) GetPurchaseHistory(context.Context, *GetPurchaseHistoryRequest) (*GetPurchaseHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPurchaseHistory not implemented")
+}
+func (UnimplementedGontractsServer) mustEmbedUnimplementedGontractsServer() {}
+func (UnimplementedGontractsServer) testEmbeddedByValue()                   {}
+
+// UnsafeGontractsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GontractsServer will
+// result in compilation errors.
+type UnsafeGontractsServer interface {
+	mustEmbedUnimplementedGontractsServer()
+}
+
+func RegisterGontractsServer(s grpc.ServiceRegistrar, srv GontractsServer) {
+	// If the following call pancis, it indicates UnimplementedGontractsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Gontracts_ServiceDesc, srv)
+}
+
+func _Gontracts_GetCompany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCompanyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).GetCompany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_GetCompany_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).GetCompany(ctx, req.(*GetCompanyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_ListCompanies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCompaniesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).ListCompanies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_ListCompanies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).ListCompanies(ctx, req.(*ListCompaniesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_SearchCompanies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchCompaniesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).SearchCompanies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_SearchCompanies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).SearchCompanies(ctx, req.(*SearchCompaniesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_CreateCompany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCompanyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).CreateCompany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_CreateCompany_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).CreateCompany(ctx, req.(*CreateCompanyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_UpdateCompany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCompanyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).UpdateCompany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_UpdateCompany_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).UpdateCompany(ctx, req.(*UpdateCompanyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_DeleteCompany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).DeleteCompany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_DeleteCompany_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).DeleteCompany(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_GetContract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetContractRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).GetContract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_GetContract_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).GetContract(ctx, req.(*GetContractRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_ListContracts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListContractsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).ListContracts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_ListContracts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).ListContracts(ctx, req.(*ListContractsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_CreateContract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateContractRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).CreateContract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_CreateContract_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).CreateContract(ctx, req.(*CreateContractRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_UpdateContract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateContractRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).UpdateContract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_UpdateContract_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).UpdateContract(ctx, req.(*UpdateContractRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_DeleteContract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).DeleteContract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_DeleteContract_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).DeleteContract(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_GetContractBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetContractBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).GetContractBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_GetContractBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).GetContractBalance(ctx, req.(*GetContractBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_CreatePurchase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePurchaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).CreatePurchase(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_CreatePurchase_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).CreatePurchase(ctx, req.(*CreatePurchaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gontracts_GetPurchaseHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPurchaseHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GontractsServer).GetPurchaseHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gontracts_GetPurchaseHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GontractsServer).GetPurchaseHistory(ctx, req.(*GetPurchaseHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Gontracts_ServiceDesc is the grpc.ServiceDesc for Gontracts service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Gontracts_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gontracts.v1.Gontracts",
+	HandlerType: (*GontractsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCompany",
+			Handler:    _Gontracts_GetCompany_Handler,
+		},
+		{
+			MethodName: "ListCompanies",
+			Handler:    _Gontracts_ListCompanies_Handler,
+		},
+		{
+			MethodName: "SearchCompanies",
+			Handler:    _Gontracts_SearchCompanies_Handler,
+		},
+		{
+			MethodName: "CreateCompany",
+			Handler:    _Gontracts_CreateCompany_Handler,
+		},
+		{
+			MethodName: "UpdateCompany",
+			Handler:    _Gontracts_UpdateCompany_Handler,
+		},
+		{
+			MethodName: "DeleteCompany",
+			Handler:    _Gontracts_DeleteCompany_Handler,
+		},
+		{
+			MethodName: "GetContract",
+			Handler:    _Gontracts_GetContract_Handler,
+		},
+		{
+			MethodName: "ListContracts",
+			Handler:    _Gontracts_ListContracts_Handler,
+		},
+		{
+			MethodName: "CreateContract",
+			Handler:    _Gontracts_CreateContract_Handler,
+		},
+		{
+			MethodName: "UpdateContract",
+			Handler:    _Gontracts_UpdateContract_Handler,
+		},
+		{
+			MethodName: "DeleteContract",
+			Handler:    _Gontracts_DeleteContract_Handler,
+		},
+		{
+			MethodName: "GetContractBalance",
+			Handler:    _Gontracts_GetContractBalance_Handler,
+		},
+		{
+			MethodName: "CreatePurchase",
+			Handler:    _Gontracts_CreatePurchase_Handler,
+		},
+		{
+			MethodName: "GetPurchaseHistory",
+			Handler:    _Gontracts_GetPurchaseHistory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "gontracts.proto",
+}
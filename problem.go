@@ -0,0 +1,143 @@
+package gontracts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// ProblemContentType is a media type of error responses
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix makes problem type URI from error code
+const problemTypePrefix = "urn:gontracts:problem:"
+
+// Generic error codes
+const (
+	codeInvalidRequest = "invalid_request"
+	codeInternal       = "internal_error"
+)
+
+// Problem represents RFC 7807 error response
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+	// Errors lists invalid fields of request body
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// APIError is an error with stable machine-readable code and HTTP status
+type APIError struct {
+	Status int
+	Code   string
+	Err    error
+}
+
+func (e *APIError) Error() string { return e.Err.Error() }
+
+// Unwrap returns underlying error
+func (e *APIError) Unwrap() error { return e.Err }
+
+// errorCodes maps domain errors to their codes and HTTP statuses
+var errorCodes = []APIError{
+	{http.StatusNotFound, "company_not_found", ErrCompanyNotFound},
+	{http.StatusNotFound, "contract_not_found", ErrContractNotFound},
+	{http.StatusUnprocessableEntity, "seller_not_found", ErrSellerNotExist},
+	{http.StatusUnprocessableEntity, "client_not_found", ErrClientNotExist},
+	{http.StatusUnprocessableEntity, "purchase_date_not_valid", ErrDateNotValid},
+	{http.StatusConflict, "not_enough_money", ErrNotEnoughMoney},
+	{http.StatusConflict, "company_has_contracts", ErrCompanyHasContracts},
+	{http.StatusBadRequest, codeInvalidRequest, ErrSearchQueryEmpty},
+	{http.StatusBadRequest, codeInvalidRequest, ErrDateRange},
+	{http.StatusNotFound, "api_key_not_found", ErrAPIKeyNotFound},
+	{http.StatusBadRequest, "api_key_name_empty", ErrAPIKeyName},
+	{http.StatusBadRequest, "api_key_scope_not_valid", ErrAPIKeyScope},
+	{http.StatusUnauthorized, "api_key_invalid", ErrAPIKeyInvalid},
+	{http.StatusUnauthorized, "api_key_revoked", ErrAPIKeyRevoked},
+	{http.StatusUnauthorized, "api_key_expired", ErrAPIKeyExpired},
+	{http.StatusUnauthorized, "token_invalid", ErrTokenInvalid},
+	{http.StatusForbidden, "scope_not_allowed", ErrScopeNotAllowed},
+	{http.StatusBadRequest, "validation_failed", ErrValidation},
+	{http.StatusUnsupportedMediaType, "unsupported_media_type", ErrPatchContentType},
+	{http.StatusUnsupportedMediaType, "unsupported_media_type", ErrImportContentType},
+	{http.StatusBadRequest, "import_empty", ErrImportEmpty},
+	{http.StatusRequestEntityTooLarge, "import_too_large", ErrImportTooLarge},
+	{http.StatusBadRequest, "batch_empty", ErrBatchEmpty},
+	{http.StatusRequestEntityTooLarge, "batch_too_large", ErrBatchTooLarge},
+	{http.StatusBadRequest, "invalid_query", model.ErrInvalidQuery},
+	{http.StatusPreconditionFailed, "version_mismatch", model.ErrVersionMismatch},
+	{http.StatusPreconditionRequired, "if_match_required", ErrIfMatchRequired},
+	{http.StatusUnprocessableEntity, "idempotency_key_reused", ErrIdempotencyKeyReused},
+	{http.StatusConflict, "idempotency_key_in_use", ErrIdempotencyKeyInUse},
+	{http.StatusServiceUnavailable, "idempotency_store_full", ErrIdempotencyStoreFull},
+	{http.StatusRequestEntityTooLarge, "request_too_large", ErrRequestTooLarge},
+	{http.StatusNotFound, "not_found", model.ErrNotFound},
+	{http.StatusServiceUnavailable, "storage_unavailable", model.ErrUnavailable},
+}
+
+// badRequest marks error caused by malformed request
+func badRequest(err error) error {
+	return &APIError{http.StatusBadRequest, codeInvalidRequest, err}
+}
+
+// withStatus overrides HTTP status of a domain error
+func withStatus(err error, status int) error {
+	return &APIError{Status: status, Err: err}
+}
+
+// toAPIError resolves error code and status.
+// Unknown errors are hidden behind a generic internal error
+func toAPIError(err error) *APIError {
+	var ae *APIError
+	if errors.As(err, &ae) && ae.Code != "" {
+		return ae
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.Err) {
+			res := c
+			if ae != nil {
+				res.Status = ae.Status
+			}
+			return &res
+		}
+	}
+	return &APIError{
+		http.StatusInternalServerError,
+		codeInternal,
+		errors.New(http.StatusText(http.StatusInternalServerError)),
+	}
+}
+
+// writeProblem writes error as application/problem+json response
+func writeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	ae := toAPIError(err)
+	p := &Problem{
+		Type:      problemTypePrefix + ae.Code,
+		Title:     http.StatusText(ae.Status),
+		Status:    ae.Status,
+		Detail:    ae.Error(),
+		Instance:  r.URL.Path,
+		Code:      ae.Code,
+		RequestID: RequestIDFromContext(r.Context()),
+	}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		p.Errors = ve.Fields
+	}
+
+	// fill response json
+	resp, _ := json.Marshal(p)
+
+	// setup response
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(ae.Status)
+	w.Write(resp)
+}
@@ -0,0 +1,60 @@
+package gontracts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+func TestWriteProblem(t *testing.T) {
+	cases := []struct {
+		Num    string
+		Err    error
+		Status int
+		Code   string
+		Detail string
+	}{
+		// domain error
+		{"1", ErrNotEnoughMoney, http.StatusConflict, "not_enough_money", ErrNotEnoughMoney.Error()},
+		// wrapped domain error
+		{"2", fmt.Errorf("purchase: %w", ErrDateNotValid), http.StatusUnprocessableEntity, "purchase_date_not_valid", ErrDateNotValid.Error()},
+		// status override
+		{"3", withStatus(ErrContractNotFound, http.StatusUnprocessableEntity), http.StatusUnprocessableEntity, "contract_not_found", ErrContractNotFound.Error()},
+		// malformed request
+		{"4", badRequest(errors.New("unexpected EOF")), http.StatusBadRequest, codeInvalidRequest, "unexpected EOF"},
+		// storage failure
+		{"5", fmt.Errorf("%w: %w", model.ErrUnavailable, errors.New("dial tcp: connection refused")), http.StatusServiceUnavailable, "storage_unavailable", model.ErrUnavailable.Error()},
+		// malformed list query
+		{"6", fmt.Errorf("%w: malformed cursor", model.ErrInvalidQuery), http.StatusBadRequest, "invalid_query", model.ErrInvalidQuery.Error()},
+		// unknown errors are not exposed
+		{"7", errors.New("Error 1146: Table 'gontracts.company' doesn't exist"), http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError)},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		writeProblem(w, httptest.NewRequest("GET", "/purchase", nil), c.Err)
+
+		if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+			t.Errorf("[WriteProblem:%s]:\twrong Content-Type: got %s, expected %s", c.Num, ct, ProblemContentType)
+		}
+		testCheckResponse("WriteProblem:"+c.Num, t, w, c.Status, testProblem(c.Status, c.Code, c.Detail, "/purchase"))
+	}
+}
+
+func TestWriteProblemRequestID(t *testing.T) {
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, ErrCompanyNotFound)
+	}))
+
+	req := httptest.NewRequest("GET", "/company/1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	resp := `{"type":"urn:gontracts:problem:company_not_found","title":"Not Found","status":404,"detail":"company doesn't exist","instance":"/company/1","code":"company_not_found","requestId":"req-1"}`
+	testCheckResponse("WriteProblemRequestID", t, w, http.StatusNotFound, resp)
+}
@@ -1,24 +1,81 @@
 package gontracts
 
 import (
+	"context"
 	"crypto/rand"
-	"log"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/ilyakaznacheev/gontracts/db"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+// DefaultDrainTimeout is a default time to wait for in-flight requests on shutdown
+const DefaultDrainTimeout = 30 * time.Second
+
+// tracingShutdownTimeout limits flushing of pending spans on shutdown
+const tracingShutdownTimeout = 5 * time.Second
+
+// ErrServerStarted server is already running
+var ErrServerStarted = errors.New("server is already started")
+
 // Server is an main application server
 type Server struct {
-	stop func()
+	// Addr is a TCP address to listen on, ":8000" by default
+	Addr string
+	// TLS enables HTTPS if set
+	TLS *TLSConfig
+	// DrainTimeout limits time to wait for in-flight requests on shutdown
+	DrainTimeout time.Duration
+	// ShutdownDelay is a time between readiness probe failure and closing listeners,
+	// it lets load balancers stop routing traffic to the server
+	ShutdownDelay time.Duration
+	// Logger is a structured server logger, JSON to stderr by default
+	Logger *slog.Logger
+	// Tracing enables OpenTelemetry trace export if set
+	Tracing *TracingConfig
+	// RequireIfMatch rejects changes of existing items without If-Match header
+	RequireIfMatch bool
+	// GRPCAddr is a TCP address of gRPC API, gRPC is disabled if empty
+	GRPCAddr string
+	// DSN is a MySQL data source name, db.DefaultDSN by default
+	DSN string
+
+	mx       sync.Mutex
+	health   *HealthHandler
+	srv      *http.Server
+	listener net.Listener
+	cleanup  func() error
+	ready    chan struct{}
+	done     chan struct{}
+	started  bool
+	// halted is set by Stop before server listens, so that it doesn't start serving
+	halted  bool
+	stopped chan struct{}
 }
 
-// Start runs the server
+// Start runs the server and blocks until it is stopped.
+// Returns nil after graceful shutdown or if the server is stopped before it starts listening
 func (s *Server) Start() error {
+	s.mx.Lock()
+	if s.started {
+		s.mx.Unlock()
+		return ErrServerStarted
+	}
+	s.started = true
+	done := s.doneChan()
+	s.mx.Unlock()
+	defer close(done)
 
 	// generate random secret key for sesstion
 	key := make([]byte, 64)
@@ -27,66 +84,359 @@ func (s *Server) Start() error {
 		return err
 	}
 
-	dbConn, err := db.Connect()
+	if s.Logger == nil {
+		s.Logger = NewLogger(os.Stderr, slog.LevelInfo)
+	}
+	logger := s.Logger
+
+	shutdownTracing := func(context.Context) error { return nil }
+	if s.Tracing != nil {
+		shutdownTracing, err = SetupTracing(s.Tracing)
+		if err != nil {
+			return err
+		}
+	}
+
+	dsn := s.DSN
+	if dsn == "" {
+		dsn = db.DefaultDSN
+	}
+	dbConn, err := db.Connect(dsn)
 	if err != nil {
+		shutdownTracing(context.Background())
 		return err
 	}
 
 	h := NewHandler(
-		db.NewCompanyDAC(dbConn),
-		db.NewContractDAC(dbConn),
-		db.NewPurchaseDAC(dbConn),
+		db.NewCompanyDAC(dbConn, logger),
+		db.NewContractDAC(dbConn, logger),
+		db.NewPurchaseDAC(dbConn, logger),
 	)
+	h.SetLogger(logger)
+	h.SetRequireIfMatch(s.RequireIfMatch)
+	h.SetAudit(db.NewAuditDAC(dbConn, logger))
+
+	m := NewMetrics(dbConn)
+	h.SetMetrics(m)
 
-	a := NewAuthHandler(key)
+	a := NewAuthHandler(key, db.NewAPIKeyDAC(dbConn, logger))
+	a.SetLogger(logger)
+	if s.TLS != nil {
+		a.SetClientSubjects(s.TLS.ClientSubjects)
+	}
+
+	hh := NewHealthHandler(
+		ReadinessCheck{"db", func() error { return db.Ping(dbConn) }},
+		ReadinessCheck{"migrations", func() error { return db.CheckSchema(dbConn) }},
+		ReadinessCheck{"signing-keys", a.CheckSigningKey},
+	)
+	hh.SetLogger(logger)
+	s.mx.Lock()
+	s.health = hh
+	s.mx.Unlock()
+
+	// handle keyboard interrupt
+	stopInterrupt := s.handleInterrupt()
+	defer stopInterrupt()
+
+	// DB connection is closed and pending spans are flushed only after all handlers finish
+	cleanup := func() error {
+		err := dbConn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if terr := shutdownTracing(ctx); err == nil {
+			err = terr
+		}
+		return err
+	}
+
+	if s.GRPCAddr != "" {
+		stopGRPC, err := s.serveGRPC(h, a)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		closeAll := cleanup
+		cleanup = func() error {
+			stopGRPC()
+			return closeAll()
+		}
+	}
 
+	return s.serve(RequestID(AccessLog(logger)(newRouter(h, a, hh, m))), cleanup)
+}
+
+// serveGRPC serves gRPC API on GRPCAddr in background.
+// Returns function that stops the server, waiting for in-flight requests during DrainTimeout
+func (s *Server) serveGRPC(h *Handler, a *AuthHandler) (func(), error) {
+	var opts []grpc.ServerOption
+	if s.TLS != nil {
+		conf, err := s.TLS.serverConfig()
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.LoadX509KeyPair(s.TLS.CertFile, s.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.Certificates = []tls.Certificate{cert}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(conf)))
+	}
+
+	l, err := net.Listen("tcp", s.GRPCAddr)
+	if err != nil {
+		return nil, err
+	}
+	srv := NewGRPCServer(NewGRPCService(h), a, s.logger(), opts...)
+
+	go func() {
+		s.logger().Info("starting gRPC server", "addr", l.Addr().String())
+		if err := srv.Serve(l); err != nil {
+			s.logger().Error("gRPC server failed", "error", err)
+		}
+	}()
+
+	return func() {
+		timeout := s.DrainTimeout
+		if timeout <= 0 {
+			timeout = DefaultDrainTimeout
+		}
+		done := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			s.logger().Error("gRPC connection draining failed")
+			srv.Stop()
+		}
+	}, nil
+}
+
+// newRouter sets up uri handlers
+func newRouter(h *Handler, a *AuthHandler, hh *HealthHandler, m *Metrics) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(Tracing, m.Middleware)
+	a.SetIdempotency(NewIdempotency(DefaultIdempotencyTTL))
+
+	r.HandleFunc("/healthz", hh.Liveness).Methods("GET")
+	r.HandleFunc("/readyz", hh.Readiness).Methods("GET")
+	r.Handle("/metrics", m.Handler()).Methods("GET")
+	r.HandleFunc("/openapi.json", ServeOpenAPI).Methods("GET")
+	r.HandleFunc("/docs", ServeDocs).Methods("GET")
 
-	// setup uri handlers
 	r.Handle("/company/{id:[0-9]+}", a.HandlerFunc(h.GetCompany)).Methods("GET")
 	r.Handle("/company", a.HandlerFunc(h.CreateCompany)).Methods("POST")
 	r.Handle("/company", a.HandlerFunc(h.UpdateCompany)).Methods("PUT")
+	r.Handle("/company/{id:[0-9]+}", a.HandlerFunc(h.PatchCompany)).Methods("PATCH")
 	r.Handle("/company/{id:[0-9]+}", a.HandlerFunc(h.DeleteCompany)).Methods("DELETE")
+	r.Handle("/company/{id:[0-9]+}/restore", a.HandlerFunc(h.RestoreCompany)).Methods("POST")
 	r.Handle("/company", a.HandlerFunc(h.GetCompanyList)).Methods("GET")
+	r.Handle("/company/search", a.HandlerFunc(h.SearchCompanies)).Methods("GET")
 	r.Handle("/contract/{id:[0-9]+}", a.HandlerFunc(h.GetContract)).Methods("GET")
 	r.Handle("/contract", a.HandlerFunc(h.CreateContract)).Methods("POST")
 	r.Handle("/contract", a.HandlerFunc(h.UpdateContract)).Methods("PUT")
+	r.Handle("/contract/{id:[0-9]+}", a.HandlerFunc(h.PatchContract)).Methods("PATCH")
 	r.Handle("/contract/{id:[0-9]+}", a.HandlerFunc(h.DeleteContract)).Methods("DELETE")
+	r.Handle("/contract/{id:[0-9]+}/restore", a.HandlerFunc(h.RestoreContract)).Methods("POST")
 	r.Handle("/contract/{id:[0-9]+}/purchase", a.HandlerFunc(h.GetPurchaseHistory)).Methods("GET")
 	r.Handle("/contract", a.HandlerFunc(h.GetContractList)).Methods("GET")
 	r.Handle("/purchase", a.HandlerFunc(h.Purchase)).Methods("POST")
+	r.Handle("/purchase/batch", a.HandlerFunc(h.PurchaseBatch)).Methods("POST")
+	r.Handle("/import/{kind:company|contract}", a.HandlerFunc(h.Import)).Methods("POST")
+	r.Handle("/export/{kind:company|contract|purchase}", a.HandlerFunc(h.Export)).Methods("GET")
+	r.Handle("/graphql", a.ReadHandler(NewGraphQL(h))).Methods("GET", "POST")
+
+	r.Handle("/audit", a.AdminHandlerFunc(h.GetAuditList)).Methods("GET")
+	r.Handle("/admin/apikey", a.AdminHandlerFunc(a.GetAPIKeyList)).Methods("GET")
+	r.Handle("/admin/apikey", a.AdminHandlerFunc(a.CreateAPIKey)).Methods("POST")
+	r.Handle("/admin/apikey/{id:[0-9]+}", a.AdminHandlerFunc(a.RevokeAPIKey)).Methods("DELETE")
 
 	r.HandleFunc("/get-token", a.GenerateToken).Methods("GET")
 
-	// handle keyboard interrupt
-	s.stop = func() {
-		dbConn.Close()
+	return r
+}
+
+// serve listens on server address and serves requests with handler until server is stopped.
+// Cleanup is called after in-flight requests are drained
+func (s *Server) serve(handler http.Handler, cleanup func() error) error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":8000"
+	}
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+
+	var err error
+	if s.TLS != nil {
+		srv.TLSConfig, err = s.TLS.serverConfig()
+		if err != nil {
+			cleanup()
+			return err
+		}
 	}
 
-	s.handleInterrupt()
+	s.mx.Lock()
+	if s.srv != nil {
+		s.mx.Unlock()
+		cleanup()
+		return ErrServerStarted
+	}
+	if s.halted {
+		// stopped during startup
+		s.mx.Unlock()
+		return cleanup()
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		s.mx.Unlock()
+		cleanup()
+		return err
+	}
+	s.srv = srv
+	s.listener = l
+	s.cleanup = cleanup
+	s.stopped = make(chan struct{})
+	stopped := s.stopped
+	close(s.readyChan())
+	s.mx.Unlock()
 
 	// start server
-	log.Println("starting server")
-	err = http.ListenAndServe(":8000", r)
-	log.Fatal(err)
-	return err
+	if s.TLS != nil {
+		s.logger().Info("starting HTTPS server", "addr", l.Addr().String())
+		err = srv.ServeTLS(l, s.TLS.CertFile, s.TLS.KeyFile)
+	} else {
+		s.logger().Info("starting server", "addr", l.Addr().String())
+		err = srv.Serve(l)
+	}
+	if err != http.ErrServerClosed {
+		// server failed by itself, nobody is going to call Stop
+		go s.Stop()
+		<-stopped
+		return err
+	}
 
+	// wait for the end of connection draining
+	<-stopped
+	return nil
 }
 
-// Stop shuts the server down
-func (s *Server) Stop() {
-	log.Println("shutdown server")
-	s.stop()
+func (s *Server) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
 }
 
-func (s *Server) handleInterrupt() {
-	var signalChan = make(chan os.Signal)
-	signal.Notify(signalChan, syscall.SIGTERM)
-	signal.Notify(signalChan, syscall.SIGINT)
+// Ready returns a channel that is closed when server starts accepting connections.
+// It isn't closed if server fails to start, wait for Done as well
+func (s *Server) Ready() <-chan struct{} {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.readyChan()
+}
+
+func (s *Server) readyChan() chan struct{} {
+	if s.ready == nil {
+		s.ready = make(chan struct{})
+	}
+	return s.ready
+}
+
+// Done returns a channel that is closed when Start returns, e.g. because server failed to start
+func (s *Server) Done() <-chan struct{} {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.doneChan()
+}
+
+func (s *Server) doneChan() chan struct{} {
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	return s.done
+}
+
+// ListenAddr returns network address server listens on or nil if server isn't started
+func (s *Server) ListenAddr() net.Addr {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Stop shuts the server down.
+// Readiness probe starts failing, new connections are rejected after ShutdownDelay,
+// in-flight requests are drained during DrainTimeout, and after that the DB connection is closed.
+// Server stopped before it starts listening doesn't start serving
+func (s *Server) Stop() error {
+	s.mx.Lock()
+	srv, cleanup, stopped, health := s.srv, s.cleanup, s.stopped, s.health
+	s.cleanup = nil
+	if srv == nil {
+		s.halted = true
+	}
+	s.mx.Unlock()
+	if srv == nil || cleanup == nil {
+		// not started or already stopped
+		return nil
+	}
+
+	s.logger().Info("shutdown server")
+
+	// fail readiness probe first so that no new traffic is routed here
+	if health != nil {
+		health.SetShuttingDown()
+		time.Sleep(s.ShutdownDelay)
+	}
+
+	timeout := s.DrainTimeout
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		s.logger().Error("connection draining failed", "error", err)
+		srv.Close()
+	}
+
+	if cerr := cleanup(); cerr != nil {
+		s.logger().Error("cleanup failed", "error", cerr)
+		if err == nil {
+			err = cerr
+		}
+	}
+
+	close(stopped)
+	return err
+}
+
+// handleInterrupt stops the server on SIGINT or SIGTERM.
+// Returns function that stops interrupt handling
+func (s *Server) handleInterrupt() func() {
+	var signalChan = make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGTERM, syscall.SIGINT)
+	done := make(chan struct{})
 
 	go func() {
-		<-signalChan
-		s.Stop()
-		os.Exit(0)
+		select {
+		case <-signalChan:
+			s.Stop()
+		case <-done:
+		}
 	}()
+
+	return func() {
+		signal.Stop(signalChan)
+		close(done)
+	}
 }
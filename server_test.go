@@ -0,0 +1,233 @@
+package gontracts
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func TestServerGracefulShutdown(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var finished, cleanedUp int32
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		atomic.StoreInt32(&finished, 1)
+		w.Write([]byte(http.StatusText(http.StatusOK)))
+	})
+	cleanup := func() error {
+		if atomic.LoadInt32(&finished) == 0 {
+			t.Error("[ServerGracefulShutdown]:\tcleanup called before in-flight request finished")
+		}
+		atomic.StoreInt32(&cleanedUp, 1)
+		return nil
+	}
+
+	s := &Server{Addr: "127.0.0.1:0", DrainTimeout: 5 * time.Second}
+	served := make(chan error, 1)
+	go func() { served <- s.serve(handler, cleanup) }()
+	<-s.Ready()
+
+	// start in-flight request
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + s.ListenAddr().String())
+		if err != nil {
+			t.Errorf("[ServerGracefulShutdown]:\trequest failed: %v", err)
+		}
+		respCh <- resp
+	}()
+	<-entered
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop() }()
+
+	// server must wait for the request
+	select {
+	case <-stopped:
+		t.Fatal("[ServerGracefulShutdown]:\tserver stopped before in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	if resp := <-respCh; resp != nil {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "OK" {
+			t.Errorf("[ServerGracefulShutdown]:\twrong response: %d %s", resp.StatusCode, body)
+		}
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("[ServerGracefulShutdown]:\tStop returned error: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("[ServerGracefulShutdown]:\tserve returned error: %v", err)
+	}
+	if atomic.LoadInt32(&cleanedUp) == 0 {
+		t.Error("[ServerGracefulShutdown]:\tcleanup wasn't called")
+	}
+
+	// stop is idempotent
+	if err := s.Stop(); err != nil {
+		t.Errorf("[ServerGracefulShutdown]:\trepeated Stop returned error: %v", err)
+	}
+}
+
+func TestServerDrainTimeout(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	var cleanedUp int32
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+	cleanup := func() error {
+		atomic.StoreInt32(&cleanedUp, 1)
+		return nil
+	}
+
+	s := &Server{Addr: "127.0.0.1:0", DrainTimeout: 50 * time.Millisecond}
+	served := make(chan error, 1)
+	go func() { served <- s.serve(handler, cleanup) }()
+	<-s.Ready()
+
+	go http.Get("http://" + s.ListenAddr().String())
+	<-entered
+
+	if err := s.Stop(); err != context.DeadlineExceeded {
+		t.Errorf("[ServerDrainTimeout]:\twrong Stop error: got %v, expected %v", err, context.DeadlineExceeded)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("[ServerDrainTimeout]:\tserve returned error: %v", err)
+	}
+	if atomic.LoadInt32(&cleanedUp) == 0 {
+		t.Error("[ServerDrainTimeout]:\tcleanup wasn't called")
+	}
+}
+
+func TestServerStopNotStarted(t *testing.T) {
+	s := &Server{}
+	if err := s.Stop(); err != nil {
+		t.Errorf("[ServerStopNotStarted]:\tStop returned error: %v", err)
+	}
+}
+
+func TestServerStopDuringStartup(t *testing.T) {
+	var cleanedUp int32
+	cleanup := func() error {
+		atomic.StoreInt32(&cleanedUp, 1)
+		return nil
+	}
+
+	s := &Server{Addr: "127.0.0.1:0"}
+	if err := s.Stop(); err != nil {
+		t.Errorf("[ServerStopDuringStartup]:\tStop returned error: %v", err)
+	}
+	if err := s.serve(http.NotFoundHandler(), cleanup); err != nil {
+		t.Errorf("[ServerStopDuringStartup]:\tserve returned error: %v", err)
+	}
+	if s.ListenAddr() != nil {
+		t.Errorf("[ServerStopDuringStartup]:\tserver listens on %s after Stop", s.ListenAddr())
+	}
+	if atomic.LoadInt32(&cleanedUp) == 0 {
+		t.Error("[ServerStopDuringStartup]:\tcleanup wasn't called")
+	}
+}
+
+func TestServerStartFailure(t *testing.T) {
+	// nothing listens on port 1
+	s := &Server{Addr: "127.0.0.1:0", DSN: "default:1234@tcp(127.0.0.1:1)/gontracts?parseTime=true"}
+	started := make(chan error, 1)
+	go func() { started <- s.Start() }()
+
+	select {
+	case <-s.Ready():
+		t.Fatal("[ServerStartFailure]:\tserver is ready without DB")
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("[ServerStartFailure]:\tserver isn't done after failed start")
+	}
+	if err := <-started; err == nil {
+		t.Error("[ServerStartFailure]:\tStart returned no error")
+	}
+}
+
+// TestServerIsolation checks that servers in one process have independent storage, keys and metrics
+func TestServerIsolation(t *testing.T) {
+	type instance struct {
+		s    *Server
+		key  string
+		name string
+		done chan error
+	}
+	var servers []*instance
+	for _, name := range []string{"Megacom", "Supercom"} {
+		ms := testClientModels()
+		ms.company = test.TestCompany{CL: []*model.Company{{1, name, nil, 1, nil}}}
+		in := &instance{
+			s:    &Server{Addr: "127.0.0.1:0", DrainTimeout: time.Second},
+			key:  "key-" + name,
+			name: name,
+			done: make(chan error, 1),
+		}
+		go func() { in.done <- in.s.serve(testClientServer(ms, in.key), func() error { return nil }) }()
+		<-in.s.Ready()
+		servers = append(servers, in)
+	}
+
+	get := func(in *instance, path, key string) (int, string) {
+		req, _ := http.NewRequest("GET", "http://"+in.s.ListenAddr().String()+path, nil)
+		req.Header.Set("Authorization", "Bearer "+testBearerToken([]byte(key)))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("[ServerIsolation]:\trequest failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	for i, in := range servers {
+		status, body := get(in, "/company/1", in.key)
+		if status != http.StatusOK || !strings.Contains(body, in.name) {
+			t.Errorf("[ServerIsolation:%d]:\tgot %d %s, expected company %s", i, status, body, in.name)
+		}
+
+		// token is signed by key of another server
+		other := servers[(i+1)%len(servers)]
+		if status, _ = get(in, "/company/1", other.key); status != http.StatusUnauthorized {
+			t.Errorf("[ServerIsolation:%d]:\tgot status %d for foreign token, expected %d", i, status, http.StatusUnauthorized)
+		}
+	}
+
+	// each server counts only its own requests
+	for i, in := range servers {
+		_, metrics := get(in, "/metrics", in.key)
+		line := `gontracts_http_requests_total{method="GET",route="/company/{id:[0-9]+}",status="200"} 1`
+		if !strings.Contains(metrics, line) {
+			t.Errorf("[ServerIsolation:%d]:\tmetric not found: %s", i, line)
+		}
+	}
+
+	// stopping one server doesn't affect another
+	if err := servers[0].s.Stop(); err != nil {
+		t.Errorf("[ServerIsolation]:\tStop returned error: %v", err)
+	}
+	<-servers[0].done
+	if status, _ := get(servers[1], "/company/1", servers[1].key); status != http.StatusOK {
+		t.Errorf("[ServerIsolation]:\tgot status %d after another server stopped, expected %d", status, http.StatusOK)
+	}
+	servers[1].s.Stop()
+	<-servers[1].done
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// Auditor identifies actor of changes kept in audit trail
+type Auditor interface {
+	// Actor returns principal and correlation ID of request making the change from its context
+	Actor(ctx context.Context) model.AuditActor
+}
+
+// SetAuditor enables audit trail of companies, contracts and purchases.
+// Storage records every change in its transaction, so that failed change isn't recorded
+// and change that can't be recorded fails
+func (s *Service) SetAuditor(a Auditor) {
+	s.auditor = a
+}
+
+// audited returns context of change recorded on behalf of its actor, if audit trail is kept
+func (s *Service) audited(ctx context.Context) context.Context {
+	if s.auditor == nil {
+		return ctx
+	}
+	return model.WithAuditActor(ctx, s.auditor.Actor(ctx))
+}
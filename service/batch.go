@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// CreatePurchases checks purchases in order with the rules of CreatePurchase and creates valid ones.
+// Credits spent by preceding purchases of the batch are counted as spent for the following ones
+func (s *Service) CreatePurchases(ctx context.Context, rows []ImportRow[model.Purchase], opts ImportOptions) (*ImportReport, error) {
+	// purchases of the batch are checked against the same balance
+	s.purchaseMX.Lock()
+	defer s.purchaseMX.Unlock()
+
+	contracts := make(map[int]*model.Contract)
+	remaining := make(map[int]int)
+	check := func(ctx context.Context, p *model.Purchase) error {
+		if err := ValidatePurchase(p); err != nil {
+			return err
+		}
+		contract, ok := contracts[p.ContractID]
+		if !ok {
+			var err error
+			if contract, err = s.purchaseContract(ctx, p.ContractID); err != nil {
+				return err
+			}
+			sum, err := s.mh.GetContractPurchaseSum(ctx, p.ContractID)
+			if err != nil {
+				return err
+			}
+			contracts[p.ContractID] = contract
+			remaining[p.ContractID] = contract.CreditAmount - sum
+		}
+
+		if err := s.checkPurchaseDate(contract, p); err != nil {
+			return err
+		}
+		if remaining[p.ContractID] < p.CreditSpent {
+			s.rejected(ReasonNotEnoughMoney)
+			return ErrNotEnoughMoney
+		}
+		remaining[p.ContractID] -= p.CreditSpent
+		return nil
+	}
+
+	create := func(ctx context.Context, p *model.Purchase) (int, error) {
+		idx, err := s.mh.CreatePurchase(ctx, p)
+		if err != nil {
+			// credits of purchase that failed to be created stay for the following ones
+			remaining[p.ContractID] += p.CreditSpent
+		}
+		return idx, err
+	}
+
+	report, err := importRows(s.audited(ctx), rows, opts, check, create, s.mh.CreatePurchases)
+	if err != nil || s.observer == nil {
+		return report, err
+	}
+	spent := make(map[int]int, len(rows))
+	for _, r := range rows {
+		if r.Item != nil {
+			spent[r.Row] = r.Item.CreditSpent
+		}
+	}
+	for _, c := range report.Created {
+		s.observer.PurchaseAccepted(spent[c.Row])
+	}
+	return report, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// ErrDateRange date range ends before it starts
+var ErrDateRange = errors.New("date range ends before it starts")
+
+// checkDateRange checks that export window isn't empty
+func checkDateRange(q model.ExportQuery) error {
+	if q.From != nil && q.To != nil && q.To.Before(*q.From) {
+		return ErrDateRange
+	}
+	return nil
+}
+
+// ExportCompanies calls fn for every company matching the query ordered by id
+func (s *Service) ExportCompanies(ctx context.Context, q model.ExportQuery, fn func(*model.Company) error) error {
+	if err := checkDateRange(q); err != nil {
+		return err
+	}
+	return s.mh.ExportCompanies(ctx, q, fn)
+}
+
+// ExportContracts calls fn for every contract matching the query ordered by id
+func (s *Service) ExportContracts(ctx context.Context, q model.ExportQuery, fn func(*model.Contract) error) error {
+	if err := checkDateRange(q); err != nil {
+		return err
+	}
+	return s.mh.ExportContracts(ctx, q, fn)
+}
+
+// ExportPurchases calls fn for every purchase matching the query ordered by id
+func (s *Service) ExportPurchases(ctx context.Context, q model.ExportQuery, fn func(*model.Purchase) error) error {
+	if err := checkDateRange(q); err != nil {
+		return err
+	}
+	return s.mh.ExportPurchases(ctx, q, fn)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// ImportOptions control bulk import
+type ImportOptions struct {
+	// DryRun validates rows without creating items
+	DryRun bool
+	// Atomic creates all rows in a single transaction, or none of them if any row is invalid.
+	// Otherwise valid rows are created one by one and invalid ones are skipped
+	Atomic bool
+}
+
+// ImportRow is an item of bulk import. Row is a position of the item in the source,
+// Err is set if the item couldn't be decoded
+type ImportRow[T any] struct {
+	Row  int
+	Item *T
+	Err  error
+}
+
+// RowError is a failure of import row
+type RowError struct {
+	Row int
+	Err error
+}
+
+// RowID is an id of item created from import row
+type RowID struct {
+	Row int
+	ID  int
+}
+
+// ImportReport is a result of bulk import
+type ImportReport struct {
+	// Valid is a number of rows that passed validation
+	Valid int
+	// Created lists items created from rows, it is empty on dry run
+	Created []RowID
+	// Errors lists invalid rows and rows that failed to be created
+	Errors []RowError
+}
+
+// ImportCompanies validates companies with the rules of CreateCompany and creates valid ones
+func (s *Service) ImportCompanies(ctx context.Context, rows []ImportRow[model.Company], opts ImportOptions) (*ImportReport, error) {
+	return importRows(s.audited(ctx), rows, opts,
+		func(ctx context.Context, c *model.Company) error { return ValidateCompany(c) },
+		s.mh.CreateCompany,
+		s.mh.CreateCompanies,
+	)
+}
+
+// ImportContracts validates contracts with the rules of CreateContract and creates valid ones
+func (s *Service) ImportContracts(ctx context.Context, rows []ImportRow[model.Contract], opts ImportOptions) (*ImportReport, error) {
+	return importRows(s.audited(ctx), rows, opts,
+		s.checkContract,
+		s.mh.CreateContract,
+		s.mh.CreateContracts,
+	)
+}
+
+// importRows checks every row and creates valid items according to options.
+// Returned error means that import failed as a whole
+func importRows[T any](
+	ctx context.Context,
+	rows []ImportRow[T],
+	opts ImportOptions,
+	check func(context.Context, *T) error,
+	create func(context.Context, *T) (int, error),
+	createAll func(context.Context, []*T) ([]int, error),
+) (*ImportReport, error) {
+	report := &ImportReport{}
+
+	// best-effort import creates every valid row before the next one is checked,
+	// so that a row that failed to be created doesn't change checks of the following rows
+	if !opts.Atomic && !opts.DryRun {
+		for _, r := range rows {
+			err := r.Err
+			if err == nil {
+				err = check(ctx, r.Item)
+			}
+			if err != nil {
+				report.Errors = append(report.Errors, RowError{r.Row, err})
+				continue
+			}
+			report.Valid++
+			idx, err := create(ctx, r.Item)
+			if err != nil {
+				report.Errors = append(report.Errors, RowError{r.Row, referenceError(err)})
+				continue
+			}
+			report.Created = append(report.Created, RowID{r.Row, idx})
+		}
+		return report, nil
+	}
+
+	// validate all rows first
+	var valid []ImportRow[T]
+	for _, r := range rows {
+		err := r.Err
+		if err == nil {
+			err = check(ctx, r.Item)
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, RowError{r.Row, err})
+			continue
+		}
+		valid = append(valid, r)
+	}
+	report.Valid = len(valid)
+
+	if opts.DryRun || (opts.Atomic && len(report.Errors) > 0) {
+		return report, nil
+	}
+
+	items := make([]*T, len(valid))
+	for i, r := range valid {
+		items[i] = r.Item
+	}
+	ids, err := createAll(ctx, items)
+	if err != nil {
+		return nil, referenceError(err)
+	}
+	for i, r := range valid {
+		report.Created = append(report.Created, RowID{r.Row, ids[i]})
+	}
+	return report, nil
+}
@@ -0,0 +1,367 @@
+// Package service implements business rules of companies, contracts and purchases
+// independently of transport. It validates items, checks that related items exist
+// and returns domain errors that transports map to their own responses
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+var (
+	// ErrCompanyNotFound company doesn't exist in DB
+	ErrCompanyNotFound = errors.New("company doesn't exist")
+	// ErrContractNotFound contract doesn't exist in DB
+	ErrContractNotFound = errors.New("contract doesn't exist")
+	// ErrSellerNotExist seller company doesn't exist in DB
+	ErrSellerNotExist = errors.New("seller company doesn't exist")
+	// ErrClientNotExist client company doesn't exist in DB
+	ErrClientNotExist = errors.New("client company doesn't exist")
+	// ErrDateNotValid purchase date is outside the contract date range
+	ErrDateNotValid = errors.New("purchase date is outside the contract date range")
+	// ErrNotEnoughMoney not enough money for the purchase
+	ErrNotEnoughMoney = errors.New("not enough money for the purchase")
+	// ErrSearchQueryEmpty search query isn't set
+	ErrSearchQueryEmpty = errors.New("search query is empty")
+	// ErrCompanyHasContracts company can't be deleted while it has contracts
+	ErrCompanyHasContracts = errors.New("company has contracts")
+)
+
+// Purchase rejection reasons
+const (
+	ReasonContractNotFound = "contract_not_found"
+	ReasonDateNotValid     = "date_not_valid"
+	ReasonNotEnoughMoney   = "not_enough_money"
+)
+
+// Observer is notified about business events, e.g. to count them
+type Observer interface {
+	// PurchaseAccepted is called after purchase is created
+	PurchaseAccepted(amount int)
+	// PurchaseRejected is called when purchase breaks contract terms
+	PurchaseRejected(reason string)
+}
+
+// Service is a transport-agnostic business logic over persistent data
+type Service struct {
+	mh         *model.ModelHandler
+	purchaseMX *sync.Mutex
+	observer   Observer
+	auditor    Auditor
+}
+
+// New creates service over model handler
+func New(mh *model.ModelHandler) *Service {
+	return &Service{
+		mh:         mh,
+		purchaseMX: &sync.Mutex{},
+	}
+}
+
+// SetObserver sets business event observer
+func (s *Service) SetObserver(o Observer) {
+	s.observer = o
+}
+
+// notFound replaces model not found error with item specific one
+func notFound(err, target error) error {
+	if errors.Is(err, model.ErrNotFound) {
+		return target
+	}
+	return err
+}
+
+// referenceError replaces model error of deleted referenced item with item specific one
+func referenceError(err error) error {
+	switch {
+	case errors.Is(err, model.ErrSellerDeleted):
+		return ErrSellerNotExist
+	case errors.Is(err, model.ErrClientDeleted):
+		return ErrClientNotExist
+	case errors.Is(err, model.ErrContractDeleted):
+		return ErrContractNotFound
+	}
+	return err
+}
+
+// GetCompany returns company by id
+func (s *Service) GetCompany(ctx context.Context, id int) (*model.Company, error) {
+	c, err := s.mh.GetCompany(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrCompanyNotFound)
+	}
+	return c, nil
+}
+
+// GetCompanies returns existing companies of ids
+func (s *Service) GetCompanies(ctx context.Context, ids []int) ([]*model.Company, error) {
+	return s.mh.GetCompanies(ctx, ids)
+}
+
+// GetCompanyList returns page of companies and next page cursor
+func (s *Service) GetCompanyList(ctx context.Context, q model.CompanyQuery) ([]*model.Company, string, error) {
+	return s.mh.GetCompanyList(ctx, q)
+}
+
+// SearchCompanies returns companies matching the query, the most relevant first
+func (s *Service) SearchCompanies(ctx context.Context, q model.CompanySearch) ([]*model.CompanyMatch, error) {
+	q.Query = strings.TrimSpace(q.Query)
+	if q.Query == "" {
+		return nil, ErrSearchQueryEmpty
+	}
+	return s.mh.SearchCompanies(ctx, q)
+}
+
+// CreateCompany validates and creates new company
+func (s *Service) CreateCompany(ctx context.Context, c *model.Company) (int, error) {
+	if err := ValidateCompany(c); err != nil {
+		return 0, err
+	}
+	return s.mh.CreateCompany(s.audited(ctx), c)
+}
+
+// UpdateCompany validates and updates company of expected version.
+// Version 0 updates company regardless of its current version
+func (s *Service) UpdateCompany(ctx context.Context, c *model.Company) error {
+	if err := ValidateCompany(c); err != nil {
+		return err
+	}
+	if err := s.mh.UpdateCompany(s.audited(ctx), c); err != nil {
+		return notFound(err, ErrCompanyNotFound)
+	}
+	return nil
+}
+
+// DeleteCompany soft deletes company of expected version.
+// Company with contracts isn't deleted unless cascade deletes its contracts as well
+func (s *Service) DeleteCompany(ctx context.Context, id, version int, cascade bool) error {
+	err := s.mh.DeleteCompany(s.audited(ctx), id, version, cascade)
+	if errors.Is(err, model.ErrReferenced) {
+		return ErrCompanyHasContracts
+	}
+	if err != nil {
+		return notFound(err, ErrCompanyNotFound)
+	}
+	return nil
+}
+
+// RestoreCompany restores soft deleted company of expected version, its contracts stay deleted
+func (s *Service) RestoreCompany(ctx context.Context, id, version int) (*model.Company, error) {
+	if err := s.mh.RestoreCompany(s.audited(ctx), id, version); err != nil {
+		return nil, notFound(err, ErrCompanyNotFound)
+	}
+	return s.GetCompany(ctx, id)
+}
+
+// GetContract returns contract by id
+func (s *Service) GetContract(ctx context.Context, id int) (*model.Contract, error) {
+	c, err := s.mh.GetContract(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrContractNotFound)
+	}
+	return c, nil
+}
+
+// GetContractList returns page of contracts and next page cursor
+func (s *Service) GetContractList(ctx context.Context, q model.ContractQuery) ([]*model.Contract, string, error) {
+	return s.mh.GetContractList(ctx, q)
+}
+
+// GetCompaniesContracts returns contracts where any of companies is seller or client
+func (s *Service) GetCompaniesContracts(ctx context.Context, ids []int) ([]*model.Contract, error) {
+	return s.mh.GetCompaniesContracts(ctx, ids)
+}
+
+// CreateContract validates and creates new contract between existing companies
+func (s *Service) CreateContract(ctx context.Context, c *model.Contract) (int, error) {
+	if err := s.checkContract(ctx, c); err != nil {
+		return 0, err
+	}
+	idx, err := s.mh.CreateContract(s.audited(ctx), c)
+	return idx, referenceError(err)
+}
+
+// UpdateContract validates and updates contract of expected version.
+// Version 0 updates contract regardless of its current version
+func (s *Service) UpdateContract(ctx context.Context, c *model.Contract) error {
+	if err := s.checkContract(ctx, c); err != nil {
+		return err
+	}
+	if err := s.mh.UpdateContract(s.audited(ctx), c); err != nil {
+		return notFound(referenceError(err), ErrContractNotFound)
+	}
+	return nil
+}
+
+// DeleteContract soft deletes contract of expected version, its purchases are kept
+func (s *Service) DeleteContract(ctx context.Context, id, version int) error {
+	if err := s.mh.DeleteContract(s.audited(ctx), id, version); err != nil {
+		return notFound(err, ErrContractNotFound)
+	}
+	return nil
+}
+
+// RestoreContract restores soft deleted contract of expected version.
+// Contract of deleted seller or client stays deleted, the company has to be restored first
+func (s *Service) RestoreContract(ctx context.Context, id, version int) (*model.Contract, error) {
+	if err := s.mh.RestoreContract(s.audited(ctx), id, version); err != nil {
+		return nil, notFound(referenceError(err), ErrContractNotFound)
+	}
+	return s.GetContract(ctx, id)
+}
+
+// checkContract validates contract and checks that its seller and client companies exist
+func (s *Service) checkContract(ctx context.Context, contract *model.Contract) error {
+	if err := ValidateContract(contract); err != nil {
+		return err
+	}
+	return s.checkCompanies(ctx, contract)
+}
+
+// checkCompanies checks that seller and client companies of contract exist
+func (s *Service) checkCompanies(ctx context.Context, contract *model.Contract) error {
+	for _, c := range []struct {
+		id      int
+		missing error
+	}{
+		{contract.SellerID, ErrSellerNotExist},
+		{contract.ClientID, ErrClientNotExist},
+	} {
+		exist, err := s.mh.CheckCompanyExist(ctx, c.id)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return c.missing
+		}
+	}
+	return nil
+}
+
+// Balance is a credit state of contract
+type Balance struct {
+	ContractID int
+	Amount     int
+	Spent      int
+	Remaining  int
+}
+
+// GetContractBalance returns credit amount of contract, spent and remaining credits
+func (s *Service) GetContractBalance(ctx context.Context, id int) (*Balance, error) {
+	c, err := s.GetContract(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := s.mh.GetContractPurchaseSum(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &Balance{c.ID, c.CreditAmount, sum, c.CreditAmount - sum}, nil
+}
+
+// GetContractsPurchaseSums returns sums of purchases by contract id
+func (s *Service) GetContractsPurchaseSums(ctx context.Context, ids []int) (map[int]int, error) {
+	return s.mh.GetContractsPurchaseSums(ctx, ids)
+}
+
+// CreatePurchase checks that contract is valid at purchase date and has enough money,
+// and creates purchase document
+func (s *Service) CreatePurchase(ctx context.Context, purchase *model.Purchase) (int, error) {
+	if err := ValidatePurchase(purchase); err != nil {
+		return 0, err
+	}
+
+	// read contract data from DB
+	contract, err := s.purchaseContract(ctx, purchase.ContractID)
+	if err != nil {
+		return 0, err
+	}
+
+	// check if purchase document in valid date range of contract
+	if err = s.checkPurchaseDate(contract, purchase); err != nil {
+		return 0, err
+	}
+
+	s.purchaseMX.Lock()
+	defer s.purchaseMX.Unlock()
+	// read sum of existing purchase documents
+	sum, err := s.mh.GetContractPurchaseSum(ctx, purchase.ContractID)
+	if err != nil {
+		return 0, err
+	}
+
+	// calculate remain credits and check
+	// if there is enough money to process new payment
+	if contract.CreditAmount-sum < purchase.CreditSpent {
+		s.rejected(ReasonNotEnoughMoney)
+		return 0, ErrNotEnoughMoney
+	}
+
+	// create new payment document in DB
+	idx, err := s.mh.CreatePurchase(s.audited(ctx), purchase)
+	if errors.Is(err, model.ErrContractDeleted) {
+		s.rejected(ReasonContractNotFound)
+		return 0, ErrContractNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if s.observer != nil {
+		s.observer.PurchaseAccepted(purchase.CreditSpent)
+	}
+	return idx, nil
+}
+
+// purchaseContract reads contract of purchase
+func (s *Service) purchaseContract(ctx context.Context, id int) (*model.Contract, error) {
+	contract, err := s.mh.GetContract(ctx, id)
+	if errors.Is(err, model.ErrNotFound) {
+		s.rejected(ReasonContractNotFound)
+		return nil, ErrContractNotFound
+	}
+	return contract, err
+}
+
+// checkPurchaseDate checks that purchase is made within contract date range
+func (s *Service) checkPurchaseDate(contract *model.Contract, purchase *model.Purchase) error {
+	if purchase.PurchaseDateTime.Before(contract.ValidFrom) || purchase.PurchaseDateTime.After(contract.ValidTo) {
+		s.rejected(ReasonDateNotValid)
+		return ErrDateNotValid
+	}
+	return nil
+}
+
+// rejected notifies observer about rejected purchase
+func (s *Service) rejected(reason string) {
+	if s.observer != nil {
+		s.observer.PurchaseRejected(reason)
+	}
+}
+
+// GetPurchaseHistory returns page of purchase history of existing contract and next page cursor
+func (s *Service) GetPurchaseHistory(ctx context.Context, q model.PurchaseQuery) ([]*model.Purchase, string, error) {
+	list, next, err := s.mh.GetContractPurchaseHistory(ctx, q)
+	if err != nil {
+		return nil, "", err
+	}
+	// empty page doesn't tell whether contract exists
+	if len(list) == 0 {
+		exist, err := s.mh.CheckContractsExist(ctx, q.ContractID)
+		if err != nil {
+			return nil, "", err
+		}
+		if !exist {
+			return nil, "", ErrContractNotFound
+		}
+	}
+	return list, next, nil
+}
+
+// GetContractsPurchases returns purchases of contracts ordered by date
+func (s *Service) GetContractsPurchases(ctx context.Context, ids []int) ([]*model.Purchase, error) {
+	return s.mh.GetContractsPurchases(ctx, ids)
+}
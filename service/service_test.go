@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+// testObserver records business events
+type testObserver struct {
+	accepted []int
+	rejected []string
+}
+
+func (o *testObserver) PurchaseAccepted(amount int)    { o.accepted = append(o.accepted, amount) }
+func (o *testObserver) PurchaseRejected(reason string) { o.rejected = append(o.rejected, reason) }
+
+func TestCreateContract(t *testing.T) {
+	time1 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	time2 := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := New(model.NewModelHandler(
+		test.TestCompany{
+			CL: []*model.Company{{ID: 1, Name: "Megacom"}, {ID: 2, Name: "Supercom"}},
+		},
+		test.TestContract{},
+		test.TestPurchase{},
+	))
+
+	cases := []struct {
+		Num      string
+		Contract model.Contract
+		Err      error
+	}{
+		{"1", model.Contract{SellerID: 1, ClientID: 2, ValidFrom: time1, ValidTo: time2}, nil},
+		{"2", model.Contract{SellerID: 9, ClientID: 2, ValidFrom: time1, ValidTo: time2}, ErrSellerNotExist},
+		{"3", model.Contract{SellerID: 1, ClientID: 9, ValidFrom: time1, ValidTo: time2}, ErrClientNotExist},
+		{"4", model.Contract{SellerID: 1, ClientID: 1, ValidFrom: time1, ValidTo: time2}, ErrValidation},
+		{"5", model.Contract{SellerID: 1, ClientID: 2, ValidFrom: time2, ValidTo: time1}, ErrValidation},
+		{"6", model.Contract{SellerID: 1, ClientID: 2, ValidFrom: time1, ValidTo: time2, CreditAmount: -1}, ErrValidation},
+	}
+
+	for _, c := range cases {
+		_, err := s.CreateContract(context.Background(), &c.Contract)
+		if !errors.Is(err, c.Err) {
+			t.Errorf("[CreateContract:%s]:\tgot error %v, expected %v", c.Num, err, c.Err)
+		}
+	}
+}
+
+func TestCreatePurchase(t *testing.T) {
+	time1 := time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC)
+	time2 := time.Date(2000, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	o := &testObserver{}
+	s := New(model.NewModelHandler(
+		nil,
+		test.TestContract{
+			CL: []*model.Contract{
+				{ID: 1, SellerID: 10, ClientID: 11, ValidFrom: time1, ValidTo: time2, CreditAmount: 10},
+			},
+		},
+		test.TestPurchase{
+			CL: []*model.Purchase{{ID: 1, ContractID: 1, PurchaseDateTime: time1, CreditSpent: 4}},
+		},
+	))
+	s.SetObserver(o)
+
+	cases := []struct {
+		Num      string
+		Purchase model.Purchase
+		Err      error
+	}{
+		{"1", model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: 6}, nil},
+		{"2", model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: 7}, ErrNotEnoughMoney},
+		{"3", model.Purchase{ContractID: 1, PurchaseDateTime: time2.Add(time.Hour), CreditSpent: 1}, ErrDateNotValid},
+		{"4", model.Purchase{ContractID: 9, PurchaseDateTime: time1, CreditSpent: 1}, ErrContractNotFound},
+		// negative amount must not raise remaining credit
+		{"5", model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: -5}, ErrValidation},
+		{"6", model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: 0}, ErrValidation},
+	}
+
+	for _, c := range cases {
+		_, err := s.CreatePurchase(context.Background(), &c.Purchase)
+		if !errors.Is(err, c.Err) {
+			t.Errorf("[CreatePurchase:%s]:\tgot error %v, expected %v", c.Num, err, c.Err)
+		}
+	}
+
+	if len(o.accepted) != 1 || o.accepted[0] != 6 {
+		t.Errorf("[CreatePurchase]:\tgot accepted %v, expected [6]", o.accepted)
+	}
+	rejected := []string{ReasonNotEnoughMoney, ReasonDateNotValid, ReasonContractNotFound}
+	if len(o.rejected) != len(rejected) {
+		t.Fatalf("[CreatePurchase]:\tgot rejected %v, expected %v", o.rejected, rejected)
+	}
+	for i := range rejected {
+		if o.rejected[i] != rejected[i] {
+			t.Errorf("[CreatePurchase]:\tgot rejected %v, expected %v", o.rejected, rejected)
+		}
+	}
+}
+
+func TestCreatePurchases(t *testing.T) {
+	time1 := time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC)
+	time2 := time.Date(2000, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	// contract 1 has 6 credits remaining
+	rows := []ImportRow[model.Purchase]{
+		{Row: 0, Item: &model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: 4}},
+		{Row: 1, Item: &model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: 3}},
+		{Row: 2, Item: &model.Purchase{ContractID: 9, PurchaseDateTime: time1, CreditSpent: 1}},
+		{Row: 3, Item: &model.Purchase{ContractID: 1, PurchaseDateTime: time2.Add(time.Hour), CreditSpent: 1}},
+		{Row: 4, Item: &model.Purchase{ContractID: 1, PurchaseDateTime: time2, CreditSpent: 2}},
+		{Row: 5, Item: &model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: -3}},
+	}
+	errs := map[int]error{1: ErrNotEnoughMoney, 2: ErrContractNotFound, 3: ErrDateNotValid, 5: ErrValidation}
+	// invalid rows aren't reported to observer
+	rejected := 3
+
+	cases := []struct {
+		Num      string
+		Opts     ImportOptions
+		Created  []int
+		Accepted []int
+	}{
+		{"1", ImportOptions{Atomic: true}, nil, nil},
+		{"2", ImportOptions{Atomic: false}, []int{0, 4}, []int{4, 2}},
+		{"3", ImportOptions{DryRun: true}, nil, nil},
+	}
+
+	for _, c := range cases {
+		o := &testObserver{}
+		s := New(model.NewModelHandler(
+			nil,
+			test.TestContract{
+				CL: []*model.Contract{
+					{ID: 1, SellerID: 10, ClientID: 11, ValidFrom: time1, ValidTo: time2, CreditAmount: 10},
+				},
+			},
+			test.TestPurchase{
+				CL: []*model.Purchase{{ID: 1, ContractID: 1, PurchaseDateTime: time1, CreditSpent: 4}},
+			},
+		))
+		s.SetObserver(o)
+
+		report, err := s.CreatePurchases(context.Background(), rows, c.Opts)
+		if err != nil {
+			t.Fatalf("[CreatePurchases:%s]:\tunexpected error %v", c.Num, err)
+		}
+		if report.Valid != 2 || len(report.Errors) != len(errs) {
+			t.Errorf("[CreatePurchases:%s]:\tgot %d valid and errors %v", c.Num, report.Valid, report.Errors)
+		}
+		for _, e := range report.Errors {
+			if !errors.Is(e.Err, errs[e.Row]) {
+				t.Errorf("[CreatePurchases:%s]:\tgot error %v of row %d, expected %v", c.Num, e.Err, e.Row, errs[e.Row])
+			}
+		}
+		if len(report.Created) != len(c.Created) {
+			t.Fatalf("[CreatePurchases:%s]:\tgot created %v, expected rows %v", c.Num, report.Created, c.Created)
+		}
+		for i, r := range c.Created {
+			if report.Created[i].Row != r {
+				t.Errorf("[CreatePurchases:%s]:\tgot created %v, expected rows %v", c.Num, report.Created, c.Created)
+			}
+		}
+		if len(o.accepted) != len(c.Accepted) || len(o.rejected) != rejected {
+			t.Errorf("[CreatePurchases:%s]:\tgot accepted %v and rejected %v", c.Num, o.accepted, o.rejected)
+		}
+	}
+}
+
+// testFailingPurchase fails to create purchases of the amount
+type testFailingPurchase struct {
+	test.TestPurchase
+	amount int
+}
+
+func (t testFailingPurchase) AddItem(ctx context.Context, pur *model.Purchase) (int, error) {
+	if pur.CreditSpent == t.amount {
+		return 0, test.ErrTest
+	}
+	return t.TestPurchase.AddItem(ctx, pur)
+}
+
+func TestCreatePurchasesFailure(t *testing.T) {
+	time1 := time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	// contract 1 has 6 credits remaining, credits of failed purchase are left for the next one
+	rows := []ImportRow[model.Purchase]{
+		{Row: 0, Item: &model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: 5}},
+		{Row: 1, Item: &model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: 4}},
+	}
+	s := New(model.NewModelHandler(
+		nil,
+		test.TestContract{
+			CL: []*model.Contract{
+				{ID: 1, SellerID: 10, ClientID: 11, ValidFrom: time1, ValidTo: time1, CreditAmount: 10},
+			},
+		},
+		testFailingPurchase{
+			TestPurchase: test.TestPurchase{
+				CL: []*model.Purchase{{ID: 1, ContractID: 1, PurchaseDateTime: time1, CreditSpent: 4}},
+			},
+			amount: 5,
+		},
+	))
+
+	report, err := s.CreatePurchases(context.Background(), rows, ImportOptions{})
+	if err != nil {
+		t.Fatalf("[CreatePurchasesFailure]:\tunexpected error %v", err)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Row != 0 || !errors.Is(report.Errors[0].Err, test.ErrTest) {
+		t.Errorf("[CreatePurchasesFailure]:\tgot errors %v, expected test error of row 0", report.Errors)
+	}
+	if len(report.Created) != 1 || report.Created[0].Row != 1 {
+		t.Errorf("[CreatePurchasesFailure]:\tgot created %v, expected row 1", report.Created)
+	}
+}
+
+// testAuditor makes all changes on behalf of the same actor
+type testAuditor struct{}
+
+func (testAuditor) Actor(ctx context.Context) model.AuditActor {
+	return model.AuditActor{Actor: "test", RequestID: "req"}
+}
+
+func TestImportAudit(t *testing.T) {
+	a := &test.TestAudit{}
+	s := New(model.NewModelHandler(
+		test.TestCompany{CL: []*model.Company{{ID: 1, Name: "Megacom"}}, Audit: a},
+		test.TestContract{},
+		test.TestPurchase{},
+	))
+	s.SetAuditor(testAuditor{})
+
+	rows := []ImportRow[model.Company]{
+		{Row: 2, Item: &model.Company{Name: "Newcom"}},
+		{Row: 3, Item: &model.Company{Name: "Otherco"}},
+	}
+	if _, err := s.ImportCompanies(context.Background(), rows, ImportOptions{DryRun: true}); err != nil {
+		t.Fatalf("[ImportAudit]:\tunexpected error %v", err)
+	}
+	if len(a.RL) != 0 {
+		t.Errorf("[ImportAudit]:\tdry run is recorded %v", a.RL)
+	}
+	if _, err := s.ImportCompanies(context.Background(), rows, ImportOptions{Atomic: true}); err != nil {
+		t.Fatalf("[ImportAudit]:\tunexpected error %v", err)
+	}
+
+	// created companies are recorded with their new ids
+	after := []string{`{"ID":2,"name":"Newcom","regcode":null}`, `{"ID":3,"name":"Otherco","regcode":null}`}
+	if len(a.RL) != len(after) {
+		t.Fatalf("[ImportAudit]:\tgot %d records, expected %d", len(a.RL), len(after))
+	}
+	for i, rec := range a.RL {
+		if rec.Entity != model.EntityCompany || rec.Action != model.ActionCreate || rec.Before != nil || string(rec.After) != after[i] {
+			t.Errorf("[ImportAudit]:\twrong record %+v, expected after %s", *rec, after[i])
+		}
+	}
+}
+
+func TestDeleteCompanyAudit(t *testing.T) {
+	time1 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	contract := &model.Contract{ID: 1, SellerID: 2, ClientID: 1, ValidFrom: time1, ValidTo: time1.AddDate(1, 0, 0), CreditAmount: 10}
+
+	companies := []*model.Company{{ID: 1, Name: "Megacom"}, {ID: 2, Name: "Supercom"}}
+
+	a := &test.TestAudit{}
+	s := New(model.NewModelHandler(
+		test.TestCompany{
+			CL:        companies,
+			Contracts: []*model.Contract{contract},
+			Audit:     a,
+		},
+		test.TestContract{CL: []*model.Contract{contract}, Companies: companies, Audit: a},
+		test.TestPurchase{},
+	))
+	s.SetAuditor(testAuditor{})
+	ctx := context.Background()
+
+	if err := s.DeleteCompany(ctx, 1, 0, false); !errors.Is(err, ErrCompanyHasContracts) {
+		t.Fatalf("[DeleteCompanyAudit]:\tgot error %v, expected %v", err, ErrCompanyHasContracts)
+	}
+	if err := s.DeleteCompany(ctx, 1, 0, true); err != nil {
+		t.Fatalf("[DeleteCompanyAudit]:\tunexpected error %v", err)
+	}
+	if contract.DeletedAt == nil {
+		t.Errorf("[DeleteCompanyAudit]:\tcontract isn't deleted by cascade")
+	}
+
+	// contract can't be restored before its client
+	if _, err := s.RestoreContract(ctx, 1, 0); !errors.Is(err, ErrClientNotExist) {
+		t.Errorf("[DeleteCompanyAudit]:\tgot error %v, expected %v", err, ErrClientNotExist)
+	}
+	if contract.DeletedAt == nil {
+		t.Errorf("[DeleteCompanyAudit]:\tcontract of deleted client is restored")
+	}
+	if _, err := s.RestoreCompany(ctx, 1, 0); err != nil {
+		t.Fatalf("[DeleteCompanyAudit]:\tunexpected error %v", err)
+	}
+	if _, err := s.RestoreContract(ctx, 1, 0); err != nil {
+		t.Fatalf("[DeleteCompanyAudit]:\tunexpected error %v", err)
+	}
+
+	// rejected changes aren't recorded
+	expected := []struct {
+		entity string
+		id     int
+		action string
+	}{
+		{model.EntityCompany, 1, model.ActionDelete},
+		{model.EntityContract, 1, model.ActionDelete},
+		{model.EntityCompany, 1, model.ActionRestore},
+		{model.EntityContract, 1, model.ActionRestore},
+	}
+	if len(a.RL) != len(expected) {
+		t.Fatalf("[DeleteCompanyAudit]:\tgot %d records, expected %d", len(a.RL), len(expected))
+	}
+	for i, e := range expected {
+		rec := a.RL[i]
+		if rec.Entity != e.entity || rec.EntityID != e.id || rec.Action != e.action || rec.Actor != "test" {
+			t.Errorf("[DeleteCompanyAudit]:\twrong record %+v, expected %+v", *rec, e)
+		}
+	}
+}
+
+func TestAuditFailure(t *testing.T) {
+	company := &model.Company{ID: 1, Name: "Megacom", Version: 1}
+	s := New(model.NewModelHandler(
+		test.TestCompany{CL: []*model.Company{company}, Audit: test.TestAuditErr{}},
+		test.TestContract{},
+		test.TestPurchase{},
+	))
+	ctx := context.Background()
+
+	// changes aren't audited without auditor
+	if err := s.DeleteCompany(ctx, 1, 0, false); err != nil {
+		t.Fatalf("[AuditFailure]:\tunexpected error %v", err)
+	}
+
+	// change that can't be recorded fails and isn't applied
+	s.SetAuditor(testAuditor{})
+	if _, err := s.RestoreCompany(ctx, 1, 0); !errors.Is(err, test.ErrTest) {
+		t.Errorf("[AuditFailure]:\tgot error %v, expected %v", err, test.ErrTest)
+	}
+	if company.DeletedAt == nil {
+		t.Errorf("[AuditFailure]:\tcompany is restored")
+	}
+	if _, err := s.CreateCompany(ctx, &model.Company{Name: "Newcom"}); !errors.Is(err, test.ErrTest) {
+		t.Errorf("[AuditFailure]:\tgot error %v, expected %v", err, test.ErrTest)
+	}
+}
+
+func TestDeletedReferences(t *testing.T) {
+	time1 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	time2 := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// companies and contract are deleted after they are checked by service, before the change is stored
+	companies := []*model.Company{{ID: 1, Name: "Megacom"}, {ID: 2, Name: "Supercom"}}
+	contract := &model.Contract{ID: 1, SellerID: 1, ClientID: 2, ValidFrom: time1, ValidTo: time2, CreditAmount: 10}
+	s := New(model.NewModelHandler(
+		test.TestCompany{CL: companies},
+		test.TestContract{
+			CL:        []*model.Contract{contract},
+			Companies: []*model.Company{{ID: 1, Name: "Megacom", DeletedAt: &time1}, {ID: 2, Name: "Supercom"}},
+		},
+		test.TestPurchase{Contracts: []*model.Contract{}},
+	))
+	ctx := context.Background()
+
+	if _, err := s.CreateContract(ctx, &model.Contract{SellerID: 1, ClientID: 2, ValidFrom: time1, ValidTo: time2}); !errors.Is(err, ErrSellerNotExist) {
+		t.Errorf("[DeletedReferences:create]:\tgot error %v, expected %v", err, ErrSellerNotExist)
+	}
+	if err := s.UpdateContract(ctx, &model.Contract{ID: 1, SellerID: 1, ClientID: 2, ValidFrom: time1, ValidTo: time2}); !errors.Is(err, ErrSellerNotExist) {
+		t.Errorf("[DeletedReferences:update]:\tgot error %v, expected %v", err, ErrSellerNotExist)
+	}
+	if _, err := s.CreatePurchase(ctx, &model.Purchase{ContractID: 1, PurchaseDateTime: time1, CreditSpent: 1}); !errors.Is(err, ErrContractNotFound) {
+		t.Errorf("[DeletedReferences:purchase]:\tgot error %v, expected %v", err, ErrContractNotFound)
+	}
+	rows := []ImportRow[model.Contract]{{Row: 1, Item: &model.Contract{SellerID: 1, ClientID: 2, ValidFrom: time1, ValidTo: time2}}}
+	report, err := s.ImportContracts(ctx, rows, ImportOptions{})
+	if err != nil || len(report.Errors) != 1 || !errors.Is(report.Errors[0].Err, ErrSellerNotExist) {
+		t.Errorf("[DeletedReferences:import]:\tgot report %+v and error %v", report, err)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+)
+
+// ErrValidation item doesn't match API specification or business rules
+var ErrValidation = errors.New("request body is not valid")
+
+// FieldError describes invalid field of item
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError contains all invalid fields of item
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		reasons = append(reasons, f.Field+": "+f.Reason)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(reasons, "; ")
+}
+
+// Unwrap returns ErrValidation
+func (e *ValidationError) Unwrap() error { return ErrValidation }
+
+// ValidateCompany checks company against business rules
+func ValidateCompany(c *model.Company) error {
+	var fields []FieldError
+	if strings.TrimSpace(c.Name) == "" {
+		fields = append(fields, FieldError{"name", "must not be blank"})
+	}
+	return validationError(fields)
+}
+
+// ValidateContract checks contract against business rules
+func ValidateContract(c *model.Contract) error {
+	var fields []FieldError
+	if c.SellerID == c.ClientID {
+		fields = append(fields, FieldError{"clientID", "must differ from sellerID"})
+	}
+	if c.ValidTo.Before(c.ValidFrom) {
+		fields = append(fields, FieldError{"validTo", "must not be before validFrom"})
+	}
+	if c.CreditAmount < 0 {
+		fields = append(fields, FieldError{"amount", "must not be less than 0"})
+	}
+	return validationError(fields)
+}
+
+// ValidatePurchase checks purchase against business rules
+func ValidatePurchase(p *model.Purchase) error {
+	var fields []FieldError
+	if p.CreditSpent <= 0 {
+		fields = append(fields, FieldError{"amount", "must be greater than 0"})
+	}
+	return validationError(fields)
+}
+
+// validationError returns nil if there are no invalid fields
+func validationError(fields []FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{fields}
+}
@@ -1,7 +1,12 @@
 package test
 
 import (
+	"context"
 	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ilyakaznacheev/gontracts/model"
 )
@@ -10,162 +15,687 @@ import (
 
 var ErrTest = errors.New("test error")
 
+// paginate returns bounds of the requested page and next page cursor.
+// Mock cursor is an offset of the page
+func paginate(n int, p model.Page) (start, end int, next string) {
+	limit := p.Limit
+	if limit <= 0 {
+		limit = model.DefaultLimit
+	}
+	if p.Cursor != "" {
+		start, _ = strconv.Atoi(p.Cursor)
+	}
+	if start > n {
+		start = n
+	}
+	end = start + limit
+	if end >= n {
+		return start, n, ""
+	}
+	return start, end, strconv.Itoa(end)
+}
+
+// createItems returns ids of n items created after existing ones
+func createItems(existing, n int) []int {
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = existing + i + 1
+	}
+	return ids
+}
+
+func contains(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditTrail is an audit mock written by item mocks, as audit table is written by item DACs
+type AuditTrail interface {
+	model.AuditModel
+	write(*model.AuditRecord) error
+}
+
+// audit appends record of change to audit mock if the change is audited
+func audit[T any](ctx context.Context, a AuditTrail, entity string, id int, action string, before, after *T) error {
+	rec := model.NewAuditRecord(ctx, entity, id, action, before, after)
+	if a == nil || rec == nil {
+		return nil
+	}
+	return a.write(rec)
+}
+
+// createdItems records items created with ids
+func createdItems[T any](ctx context.Context, a AuditTrail, entity string, items []*T, ids []int, setID func(*T, int)) error {
+	for i, item := range items {
+		created := *item
+		setID(&created, ids[i])
+		if err := audit(ctx, a, entity, ids[i], model.ActionCreate, nil, &created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// companyExist checks is company with id in the list and not deleted
+func companyExist(companies []*model.Company, id int) bool {
+	for _, c := range companies {
+		if c.ID == id && c.DeletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCompanies checks that seller and client of contract exist, nil companies aren't checked
+func checkCompanies(companies []*model.Company, c *model.Contract) error {
+	if companies != nil && !companyExist(companies, c.SellerID) {
+		return model.ErrSellerDeleted
+	}
+	if companies != nil && !companyExist(companies, c.ClientID) {
+		return model.ErrClientDeleted
+	}
+	return nil
+}
+
 type TestCompany struct {
 	CL []*model.Company
+	// Contracts reference companies on delete
+	Contracts []*model.Contract
+	// Audit records audited changes
+	Audit AuditTrail
 }
 
-func (t TestCompany) GetList() ([]*model.Company, error) {
-	return t.CL, nil
+func (t TestCompany) GetList(ctx context.Context, q model.CompanyQuery) ([]*model.Company, string, error) {
+	var list []*model.Company
+	for _, c := range t.CL {
+		if c.DeletedAt == nil || q.IncludeDeleted {
+			list = append(list, c)
+		}
+	}
+	start, end, next := paginate(len(list), q.Page)
+	return list[start:end], next, nil
 }
-func (t TestCompany) GetItem(id int) (*model.Company, error) {
+func (t TestCompany) GetItem(ctx context.Context, id int) (*model.Company, error) {
 	for _, c := range t.CL {
-		if c.ID == id {
-			return c, nil
+		if c.ID == id && c.DeletedAt == nil {
+			// copy keeps read item unchanged by later deletion
+			item := *c
+			return &item, nil
 		}
 	}
-	return nil, ErrTest
+	return nil, model.ErrNotFound
 }
 
-func (t TestCompany) CreateItem(comp *model.Company) (int, error) {
+func (t TestCompany) CreateItem(ctx context.Context, comp *model.Company) (int, error) {
 	t.CL = append(t.CL, comp)
-	return len(t.CL), nil
+	created := *comp
+	created.ID = len(t.CL)
+	return created.ID, audit(ctx, t.Audit, model.EntityCompany, created.ID, model.ActionCreate, nil, &created)
+}
+
+func (t TestCompany) CreateItems(ctx context.Context, comps []*model.Company) ([]int, error) {
+	ids := createItems(len(t.CL), len(comps))
+	return ids, createdItems(ctx, t.Audit, model.EntityCompany, comps, ids, func(c *model.Company, id int) { c.ID = id })
 }
 
-func (t TestCompany) UpdateItem(comp *model.Company) error {
+func (t TestCompany) UpdateItem(ctx context.Context, comp *model.Company) error {
 	for _, c := range t.CL {
-		if c.ID == comp.ID {
-			c = comp
+		if c.ID == comp.ID && c.DeletedAt == nil {
+			if comp.Version != 0 && comp.Version != c.Version {
+				return model.ErrVersionMismatch
+			}
+			if err := audit(ctx, t.Audit, model.EntityCompany, c.ID, model.ActionUpdate, c, comp); err != nil {
+				return err
+			}
+			comp.Version = c.Version + 1
 			return nil
 		}
 	}
-	return ErrTest
+	return model.ErrNotFound
 }
 
-func (t TestCompany) DeleteItem(id int) error {
-	for idx, c := range t.CL {
-		if c.ID == id {
-			t.CL = append(t.CL[:idx], t.CL[idx+1:]...)
+func (t TestCompany) DeleteItem(ctx context.Context, id, version int, cascade bool) error {
+	for _, c := range t.CL {
+		if c.ID == id && c.DeletedAt == nil {
+			if version != 0 && version != c.Version {
+				return model.ErrVersionMismatch
+			}
+			var contracts []*model.Contract
+			for _, contr := range t.Contracts {
+				if contr.DeletedAt != nil || (contr.SellerID != id && contr.ClientID != id) {
+					continue
+				}
+				if !cascade {
+					return model.ErrReferenced
+				}
+				contracts = append(contracts, contr)
+			}
+			if err := audit[model.Company](ctx, t.Audit, model.EntityCompany, id, model.ActionDelete, c, nil); err != nil {
+				return err
+			}
+			for _, contr := range contracts {
+				if err := audit[model.Contract](ctx, t.Audit, model.EntityContract, contr.ID, model.ActionDelete, contr, nil); err != nil {
+					return err
+				}
+			}
+			now := time.Now()
+			for _, contr := range contracts {
+				contr.DeletedAt = &now
+			}
+			c.DeletedAt = &now
 			return nil
 		}
 	}
-	return ErrTest
+	return model.ErrNotFound
 }
 
-func (t TestCompany) CheckExist(id int) bool {
+func (t TestCompany) RestoreItem(ctx context.Context, id, version int) error {
 	for _, c := range t.CL {
-		if c.ID == id {
-			return true
+		if c.ID == id && c.DeletedAt != nil {
+			if version != 0 && version != c.Version {
+				return model.ErrVersionMismatch
+			}
+			restored := *c
+			restored.DeletedAt = nil
+			if err := audit(ctx, t.Audit, model.EntityCompany, id, model.ActionRestore, nil, &restored); err != nil {
+				return err
+			}
+			c.DeletedAt = nil
+			return nil
 		}
 	}
-	return false
+	return model.ErrNotFound
+}
+
+func (t TestCompany) CheckExist(ctx context.Context, id int) (bool, error) {
+	for _, c := range t.CL {
+		if c.ID == id && c.DeletedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Search matches query words as case-insensitive substrings,
+// score is a number of matched words
+func (t TestCompany) Search(ctx context.Context, q model.CompanySearch) ([]*model.CompanyMatch, error) {
+	res := make([]*model.CompanyMatch, 0)
+	for _, c := range t.CL {
+		if c.DeletedAt != nil {
+			continue
+		}
+		text := strings.ToLower(c.Name)
+		if c.RegCode != nil {
+			text += " " + strings.ToLower(*c.RegCode)
+		}
+		var score float64
+		for _, w := range strings.Fields(strings.ToLower(q.Query)) {
+			if strings.Contains(text, w) {
+				score++
+			}
+		}
+		if score > 0 {
+			res = append(res, &model.CompanyMatch{Company: *c, Score: score})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Score > res[j].Score })
+	start, end, _ := paginate(len(res), model.Page{Limit: q.Limit})
+	return res[start:end], nil
+}
+
+func (t TestCompany) GetItems(ctx context.Context, ids []int) ([]*model.Company, error) {
+	var list []*model.Company
+	for _, c := range t.CL {
+		if contains(ids, c.ID) && c.DeletedAt == nil {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+// Export ignores date range, mock companies don't know their contracts
+func (t TestCompany) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Company) error) error {
+	for _, c := range t.CL {
+		if c.DeletedAt != nil || q.CompanyID != 0 && c.ID != q.CompanyID {
+			continue
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type TestCompanyErr struct {
 }
 
-func (t TestCompanyErr) GetList() ([]*model.Company, error)          { return nil, ErrTest }
-func (t TestCompanyErr) GetItem(id int) (*model.Company, error)      { return nil, ErrTest }
-func (t TestCompanyErr) CreateItem(comp *model.Company) (int, error) { return 0, ErrTest }
-func (t TestCompanyErr) UpdateItem(comp *model.Company) error        { return ErrTest }
-func (t TestCompanyErr) DeleteItem(id int) error                     { return ErrTest }
-func (t TestCompanyErr) CheckExist(id int) bool                      { return false }
+func (t TestCompanyErr) GetList(ctx context.Context, q model.CompanyQuery) ([]*model.Company, string, error) {
+	return nil, "", ErrTest
+}
+func (t TestCompanyErr) GetItem(ctx context.Context, id int) (*model.Company, error) {
+	return nil, ErrTest
+}
+func (t TestCompanyErr) CreateItem(ctx context.Context, comp *model.Company) (int, error) {
+	return 0, ErrTest
+}
+func (t TestCompanyErr) CreateItems(ctx context.Context, comps []*model.Company) ([]int, error) {
+	return nil, ErrTest
+}
+func (t TestCompanyErr) UpdateItem(ctx context.Context, comp *model.Company) error { return ErrTest }
+func (t TestCompanyErr) DeleteItem(ctx context.Context, id, version int, cascade bool) error {
+	return ErrTest
+}
+func (t TestCompanyErr) RestoreItem(ctx context.Context, id, version int) error { return ErrTest }
+func (t TestCompanyErr) CheckExist(ctx context.Context, id int) (bool, error)   { return false, ErrTest }
+func (t TestCompanyErr) Search(ctx context.Context, q model.CompanySearch) ([]*model.CompanyMatch, error) {
+	return nil, ErrTest
+}
+func (t TestCompanyErr) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Company) error) error {
+	return ErrTest
+}
+func (t TestCompanyErr) GetItems(ctx context.Context, ids []int) ([]*model.Company, error) {
+	return nil, ErrTest
+}
 
 type TestContract struct {
 	CL []*model.Contract
+	// Companies are checked on create, update and restore, unless nil
+	Companies []*model.Company
+	// Audit records audited changes
+	Audit AuditTrail
 }
 
-func (t TestContract) GetList() ([]*model.Contract, error) {
-	return t.CL, nil
+func (t TestContract) GetList(ctx context.Context, q model.ContractQuery) ([]*model.Contract, string, error) {
+	var list []*model.Contract
+	for _, c := range t.CL {
+		switch {
+		case c.DeletedAt != nil && !q.IncludeDeleted,
+			q.SellerID != 0 && c.SellerID != q.SellerID,
+			q.ClientID != 0 && c.ClientID != q.ClientID,
+			q.ActiveFrom != nil && c.ValidTo.Before(*q.ActiveFrom),
+			q.ActiveTo != nil && c.ValidFrom.After(*q.ActiveTo):
+			continue
+		}
+		list = append(list, c)
+	}
+	start, end, next := paginate(len(list), q.Page)
+	return list[start:end], next, nil
 }
 
-func (t TestContract) GetItem(id int) (*model.Contract, error) {
+func (t TestContract) GetItem(ctx context.Context, id int) (*model.Contract, error) {
 	for _, c := range t.CL {
-		if c.ID == id {
-			return c, nil
+		if c.ID == id && c.DeletedAt == nil {
+			// copy keeps read item unchanged by later deletion
+			item := *c
+			return &item, nil
 		}
 	}
-	return nil, ErrTest
+	return nil, model.ErrNotFound
 }
 
-func (t TestContract) CreateItem(contr *model.Contract) (int, error) {
+func (t TestContract) CreateItem(ctx context.Context, contr *model.Contract) (int, error) {
+	if err := checkCompanies(t.Companies, contr); err != nil {
+		return 0, err
+	}
 	t.CL = append(t.CL, contr)
-	return len(t.CL), nil
+	created := *contr
+	created.ID = len(t.CL)
+	return created.ID, audit(ctx, t.Audit, model.EntityContract, created.ID, model.ActionCreate, nil, &created)
 }
 
-func (t TestContract) UpdateItem(contr *model.Contract) error {
+func (t TestContract) CreateItems(ctx context.Context, contrs []*model.Contract) ([]int, error) {
+	for _, c := range contrs {
+		if err := checkCompanies(t.Companies, c); err != nil {
+			return nil, err
+		}
+	}
+	ids := createItems(len(t.CL), len(contrs))
+	return ids, createdItems(ctx, t.Audit, model.EntityContract, contrs, ids, func(c *model.Contract, id int) { c.ID = id })
+}
+
+func (t TestContract) UpdateItem(ctx context.Context, contr *model.Contract) error {
 	for _, c := range t.CL {
-		if c.ID == contr.ID {
-			c = contr
+		if c.ID == contr.ID && c.DeletedAt == nil {
+			if contr.Version != 0 && contr.Version != c.Version {
+				return model.ErrVersionMismatch
+			}
+			if err := checkCompanies(t.Companies, contr); err != nil {
+				return err
+			}
+			if err := audit(ctx, t.Audit, model.EntityContract, c.ID, model.ActionUpdate, c, contr); err != nil {
+				return err
+			}
+			contr.Version = c.Version + 1
 			return nil
 		}
 	}
-	return ErrTest
+	return model.ErrNotFound
 }
 
-func (t TestContract) DeleteItem(id int) error {
-	for idx, c := range t.CL {
-		if c.ID == id {
-			t.CL = append(t.CL[:idx], t.CL[idx+1:]...)
+func (t TestContract) DeleteItem(ctx context.Context, id, version int) error {
+	for _, c := range t.CL {
+		if c.ID == id && c.DeletedAt == nil {
+			if version != 0 && version != c.Version {
+				return model.ErrVersionMismatch
+			}
+			if err := audit[model.Contract](ctx, t.Audit, model.EntityContract, id, model.ActionDelete, c, nil); err != nil {
+				return err
+			}
+			now := time.Now()
+			c.DeletedAt = &now
 			return nil
 		}
 	}
-	return ErrTest
+	return model.ErrNotFound
 }
 
-func (t TestContract) CheckExist(id int) bool {
+func (t TestContract) RestoreItem(ctx context.Context, id, version int) error {
 	for _, c := range t.CL {
-		if c.ID == id {
-			return true
+		if c.ID == id && c.DeletedAt != nil {
+			if version != 0 && version != c.Version {
+				return model.ErrVersionMismatch
+			}
+			if err := checkCompanies(t.Companies, c); err != nil {
+				return err
+			}
+			restored := *c
+			restored.DeletedAt = nil
+			if err := audit(ctx, t.Audit, model.EntityContract, id, model.ActionRestore, nil, &restored); err != nil {
+				return err
+			}
+			c.DeletedAt = nil
+			return nil
 		}
 	}
-	return false
+	return model.ErrNotFound
+}
+
+func (t TestContract) CheckExist(ctx context.Context, id int) (bool, error) {
+	for _, c := range t.CL {
+		if c.ID == id && c.DeletedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (t TestContract) GetCompanyItems(ctx context.Context, companyIDs []int) ([]*model.Contract, error) {
+	var list []*model.Contract
+	for _, c := range t.CL {
+		if c.DeletedAt == nil && (contains(companyIDs, c.SellerID) || contains(companyIDs, c.ClientID)) {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (t TestContract) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Contract) error) error {
+	for _, c := range t.CL {
+		switch {
+		case c.DeletedAt != nil,
+			q.CompanyID != 0 && c.SellerID != q.CompanyID && c.ClientID != q.CompanyID,
+			q.From != nil && c.ValidTo.Before(*q.From),
+			q.To != nil && c.ValidFrom.After(*q.To):
+			continue
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type TestContractErr struct {
 }
 
-func (t TestContractErr) GetList() ([]*model.Contract, error)           { return nil, ErrTest }
-func (t TestContractErr) GetItem(id int) (*model.Contract, error)       { return nil, ErrTest }
-func (t TestContractErr) CreateItem(contr *model.Contract) (int, error) { return 0, ErrTest }
-func (t TestContractErr) UpdateItem(contr *model.Contract) error        { return ErrTest }
-func (t TestContractErr) DeleteItem(id int) error                       { return ErrTest }
-func (t TestContractErr) CheckExist(id int) bool                        { return false }
+func (t TestContractErr) GetList(ctx context.Context, q model.ContractQuery) ([]*model.Contract, string, error) {
+	return nil, "", ErrTest
+}
+func (t TestContractErr) GetItem(ctx context.Context, id int) (*model.Contract, error) {
+	return nil, ErrTest
+}
+func (t TestContractErr) CreateItem(ctx context.Context, contr *model.Contract) (int, error) {
+	return 0, ErrTest
+}
+func (t TestContractErr) CreateItems(ctx context.Context, contrs []*model.Contract) ([]int, error) {
+	return nil, ErrTest
+}
+func (t TestContractErr) UpdateItem(ctx context.Context, contr *model.Contract) error { return ErrTest }
+func (t TestContractErr) DeleteItem(ctx context.Context, id, version int) error       { return ErrTest }
+func (t TestContractErr) RestoreItem(ctx context.Context, id, version int) error      { return ErrTest }
+func (t TestContractErr) CheckExist(ctx context.Context, id int) (bool, error)        { return false, ErrTest }
+func (t TestContractErr) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Contract) error) error {
+	return ErrTest
+}
+func (t TestContractErr) GetCompanyItems(ctx context.Context, companyIDs []int) ([]*model.Contract, error) {
+	return nil, ErrTest
+}
 
 type TestPurchase struct {
 	CL []*model.Purchase
+	// Contracts are checked on create, unless nil
+	Contracts []*model.Contract
+	// Audit records audited changes
+	Audit AuditTrail
+}
+
+// checkContract checks that contract of purchase exists, nil contracts aren't checked
+func (t TestPurchase) checkContract(p *model.Purchase) error {
+	if t.Contracts == nil {
+		return nil
+	}
+	for _, c := range t.Contracts {
+		if c.ID == p.ContractID && c.DeletedAt == nil {
+			return nil
+		}
+	}
+	return model.ErrContractDeleted
 }
 
-func (t TestPurchase) AddItem(pur *model.Purchase) (int, error) {
+func (t TestPurchase) AddItem(ctx context.Context, pur *model.Purchase) (int, error) {
+	if err := t.checkContract(pur); err != nil {
+		return 0, err
+	}
 	t.CL = append(t.CL, pur)
-	return len(t.CL), nil
+	created := *pur
+	created.ID = len(t.CL)
+	return created.ID, audit(ctx, t.Audit, model.EntityPurchase, created.ID, model.ActionCreate, nil, &created)
+}
+
+func (t TestPurchase) CreateItems(ctx context.Context, purs []*model.Purchase) ([]int, error) {
+	for _, p := range purs {
+		if err := t.checkContract(p); err != nil {
+			return nil, err
+		}
+	}
+	ids := createItems(len(t.CL), len(purs))
+	return ids, createdItems(ctx, t.Audit, model.EntityPurchase, purs, ids, func(p *model.Purchase, id int) { p.ID = id })
 }
 
-func (t TestPurchase) GetContractHistory(id int) ([]*model.Purchase, error) {
+func (t TestPurchase) GetContractHistory(ctx context.Context, q model.PurchaseQuery) ([]*model.Purchase, string, error) {
 	var hist []*model.Purchase
 	for _, c := range t.CL {
-		if c.ContractID == id {
-			hist = append(hist, c)
+		switch {
+		case c.ContractID != q.ContractID,
+			q.From != nil && c.PurchaseDateTime.Before(*q.From),
+			q.To != nil && c.PurchaseDateTime.After(*q.To),
+			q.MinAmount != nil && c.CreditSpent < *q.MinAmount,
+			q.MaxAmount != nil && c.CreditSpent > *q.MaxAmount:
+			continue
 		}
+		hist = append(hist, c)
 	}
-	if len(hist) == 0 {
-		return nil, ErrTest
-	}
-	return hist, nil
+	start, end, next := paginate(len(hist), q.Page)
+	return hist[start:end], next, nil
 }
 
-func (t TestPurchase) GetContractSum(id int) int {
+func (t TestPurchase) GetContractSum(ctx context.Context, id int) (int, error) {
 	var sum int
 	for _, c := range t.CL {
 		if c.ContractID == id {
 			sum += c.CreditSpent
 		}
 	}
-	return sum
+	return sum, nil
+}
+
+func (t TestPurchase) GetContractItems(ctx context.Context, contractIDs []int) ([]*model.Purchase, error) {
+	var list []*model.Purchase
+	for _, c := range t.CL {
+		if contains(contractIDs, c.ContractID) {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (t TestPurchase) GetContractSums(ctx context.Context, contractIDs []int) (map[int]int, error) {
+	sums := make(map[int]int)
+	for _, c := range t.CL {
+		if contains(contractIDs, c.ContractID) {
+			sums[c.ContractID] += c.CreditSpent
+		}
+	}
+	return sums, nil
+}
+
+// Export ignores company filter, mock purchases don't know contract companies
+func (t TestPurchase) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Purchase) error) error {
+	for _, p := range t.CL {
+		switch {
+		case q.From != nil && p.PurchaseDateTime.Before(*q.From),
+			q.To != nil && p.PurchaseDateTime.After(*q.To),
+			t.checkContract(p) != nil:
+			continue
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type TestPurchaseErr struct {
 }
 
-func (t TestPurchaseErr) AddItem(pur *model.Purchase) (int, error)             { return 0, ErrTest }
-func (t TestPurchaseErr) GetContractHistory(id int) ([]*model.Purchase, error) { return nil, ErrTest }
-func (t TestPurchaseErr) GetContractSum(id int) int                            { return 0 }
+func (t TestPurchaseErr) AddItem(ctx context.Context, pur *model.Purchase) (int, error) {
+	return 0, ErrTest
+}
+func (t TestPurchaseErr) GetContractHistory(ctx context.Context, q model.PurchaseQuery) ([]*model.Purchase, string, error) {
+	return nil, "", ErrTest
+}
+func (t TestPurchaseErr) GetContractSum(ctx context.Context, id int) (int, error) {
+	return 0, ErrTest
+}
+func (t TestPurchaseErr) CreateItems(ctx context.Context, purs []*model.Purchase) ([]int, error) {
+	return nil, ErrTest
+}
+func (t TestPurchaseErr) Export(ctx context.Context, q model.ExportQuery, fn func(*model.Purchase) error) error {
+	return ErrTest
+}
+func (t TestPurchaseErr) GetContractItems(ctx context.Context, contractIDs []int) ([]*model.Purchase, error) {
+	return nil, ErrTest
+}
+func (t TestPurchaseErr) GetContractSums(ctx context.Context, contractIDs []int) (map[int]int, error) {
+	return nil, ErrTest
+}
+
+type TestAPIKey struct {
+	KL []*model.APIKey
+}
+
+func (t *TestAPIKey) GetList(ctx context.Context) ([]*model.APIKey, error) {
+	return t.KL, nil
+}
+
+func (t *TestAPIKey) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	for _, k := range t.KL {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (t *TestAPIKey) CreateItem(ctx context.Context, key *model.APIKey) (int, error) {
+	t.KL = append(t.KL, key)
+	key.ID = len(t.KL)
+	return key.ID, nil
+}
+
+func (t *TestAPIKey) RevokeItem(ctx context.Context, id int) error {
+	for _, k := range t.KL {
+		if k.ID == id && k.RevokedAt == nil {
+			now := time.Now()
+			k.RevokedAt = &now
+			return nil
+		}
+	}
+	return model.ErrNotFound
+}
+
+func (t *TestAPIKey) UpdateLastUsed(ctx context.Context, id int, used time.Time) error {
+	for _, k := range t.KL {
+		if k.ID == id {
+			k.LastUsedAt = &used
+			return nil
+		}
+	}
+	return model.ErrNotFound
+}
+
+type TestAPIKeyErr struct {
+}
+
+func (t TestAPIKeyErr) GetList(ctx context.Context) ([]*model.APIKey, error) { return nil, ErrTest }
+func (t TestAPIKeyErr) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	return nil, ErrTest
+}
+func (t TestAPIKeyErr) CreateItem(ctx context.Context, key *model.APIKey) (int, error) {
+	return 0, ErrTest
+}
+func (t TestAPIKeyErr) RevokeItem(ctx context.Context, id int) error { return ErrTest }
+func (t TestAPIKeyErr) UpdateLastUsed(ctx context.Context, id int, used time.Time) error {
+	return ErrTest
+}
+
+type TestAudit struct {
+	RL []*model.AuditRecord
+}
+
+func (t *TestAudit) write(rec *model.AuditRecord) error {
+	t.RL = append(t.RL, rec)
+	rec.ID = len(t.RL)
+	return nil
+}
+
+func (t *TestAudit) GetList(ctx context.Context, q model.AuditQuery) ([]*model.AuditRecord, string, error) {
+	var list []*model.AuditRecord
+	for _, r := range t.RL {
+		switch {
+		case q.Entity != "" && r.Entity != q.Entity,
+			q.EntityID != 0 && r.EntityID != q.EntityID,
+			q.Actor != "" && r.Actor != q.Actor,
+			q.From != nil && r.Time.Before(*q.From),
+			q.To != nil && r.Time.After(*q.To):
+			continue
+		}
+		list = append(list, r)
+	}
+	start, end, next := paginate(len(list), q.Page)
+	return list[start:end], next, nil
+}
+
+type TestAuditErr struct {
+}
+
+func (t TestAuditErr) write(rec *model.AuditRecord) error {
+	return ErrTest
+}
+func (t TestAuditErr) GetList(ctx context.Context, q model.AuditQuery) ([]*model.AuditRecord, string, error) {
+	return nil, "", ErrTest
+}
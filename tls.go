@@ -0,0 +1,93 @@
+package gontracts
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+)
+
+var (
+	// ErrClientCA client CA bundle contains no certificates
+	ErrClientCA = errors.New("client CA bundle contains no certificates")
+	// ErrClientCARequired client certificates are configured without CA bundle to verify them
+	ErrClientCARequired = errors.New("client CA bundle is required to verify client certificates")
+)
+
+// TLSConfig is a HTTPS server configuration
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM encoded server certificate and private key
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile is a PEM encoded CA bundle used to verify client certificates.
+	// Client certificates are not requested if it is empty
+	ClientCAFile string
+
+	// RequireClientCert rejects connections without a valid client certificate
+	RequireClientCert bool
+
+	// ClientSubjects maps full subject DN of client certificate (e.g. "CN=batch,O=Acme") to granted scopes
+	ClientSubjects map[string][]string
+}
+
+// LoadClientSubjects reads subject to scopes mapping from JSON file
+func LoadClientSubjects(path string) (map[string][]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	subjects := make(map[string][]string)
+	err = json.Unmarshal(data, &subjects)
+	return subjects, err
+}
+
+// serverConfig returns TLS config of HTTP server
+func (c *TLSConfig) serverConfig() (*tls.Config, error) {
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if c.ClientCAFile == "" {
+		if c.RequireClientCert || len(c.ClientSubjects) > 0 {
+			return nil, ErrClientCARequired
+		}
+		return conf, nil
+	}
+
+	pem, err := ioutil.ReadFile(c.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, ErrClientCA
+	}
+
+	conf.ClientCAs = pool
+	if c.RequireClientCert {
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return conf, nil
+}
+
+// certIdentity maps verified client certificate of the connection to identity.
+// Subject is matched by full DN only, so that another certificate with the same common name isn't trusted.
+// Returns nil if there is no verified certificate or its subject is unknown
+func certIdentity(state *tls.ConnectionState, subjects map[string][]string) *Identity {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	subject := state.VerifiedChains[0][0].Subject.String()
+
+	scopes, ok := subjects[subject]
+	if !ok || subject == "" {
+		return nil
+	}
+	return &Identity{
+		Subject: "cert:" + subject,
+		Scopes:  scopes,
+	}
+}
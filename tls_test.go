@@ -0,0 +1,246 @@
+package gontracts
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func testNewCert(t *testing.T, subject pkix.Name, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	parentCert, parentKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert, key, der}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{
+		Certificate: [][]byte{c.der},
+		PrivateKey:  c.key,
+	}
+}
+
+func TestTLSServerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gontracts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := testNewCert(t, pkix.Name{CommonName: "test CA"}, nil)
+	caFile := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.der}), 0600)
+	emptyFile := filepath.Join(dir, "empty.pem")
+	ioutil.WriteFile(emptyFile, []byte("no certificates"), 0600)
+
+	cases := []struct {
+		Num        string
+		Config     TLSConfig
+		ClientAuth tls.ClientAuthType
+		Err        bool
+	}{
+		// no client certificates
+		{
+			Num:        "1",
+			Config:     TLSConfig{},
+			ClientAuth: tls.NoClientCert,
+		},
+		// optional client certificates
+		{
+			Num:        "2",
+			Config:     TLSConfig{ClientCAFile: caFile},
+			ClientAuth: tls.VerifyClientCertIfGiven,
+		},
+		// required client certificates
+		{
+			Num:        "3",
+			Config:     TLSConfig{ClientCAFile: caFile, RequireClientCert: true},
+			ClientAuth: tls.RequireAndVerifyClientCert,
+		},
+		// CA bundle doesn't exist
+		{
+			Num:    "4",
+			Config: TLSConfig{ClientCAFile: filepath.Join(dir, "missing.pem")},
+			Err:    true,
+		},
+		// CA bundle is empty
+		{
+			Num:    "5",
+			Config: TLSConfig{ClientCAFile: emptyFile},
+			Err:    true,
+		},
+		// client certificates can't be verified without CA bundle
+		{
+			Num:    "6",
+			Config: TLSConfig{RequireClientCert: true},
+			Err:    true,
+		},
+		{
+			Num:    "7",
+			Config: TLSConfig{ClientSubjects: map[string][]string{"CN=batch": {ScopeRead}}},
+			Err:    true,
+		},
+	}
+
+	for _, c := range cases {
+		conf, err := c.Config.serverConfig()
+		if (err != nil) != c.Err {
+			t.Errorf("[TLSServerConfig:%s]:\tunexpected error: %v", c.Num, err)
+			continue
+		}
+		if err == nil && conf.ClientAuth != c.ClientAuth {
+			t.Errorf("[TLSServerConfig:%s]:\twrong ClientAuth: got %v, expected %v",
+				c.Num, conf.ClientAuth, c.ClientAuth)
+		}
+	}
+}
+
+func TestClientCertAuth(t *testing.T) {
+	ca := testNewCert(t, pkix.Name{CommonName: "test CA"}, nil)
+	batch := testNewCert(t, pkix.Name{CommonName: "batch", Organization: []string{"Acme"}}, ca)
+	reader := testNewCert(t, pkix.Name{CommonName: "reader"}, ca)
+	unknown := testNewCert(t, pkix.Name{CommonName: "unknown"}, ca)
+	impostor := testNewCert(t, pkix.Name{CommonName: "batch", Organization: []string{"Other"}}, ca)
+
+	a := NewAuthHandler([]byte("test"), nil)
+	a.SetClientSubjects(map[string][]string{
+		"CN=batch,O=Acme": {ScopeRead, ScopeWrite},
+		"CN=reader":       {ScopeRead},
+	})
+
+	srv := httptest.NewUnstartedServer(a.HandlerFunc(testOKHandler))
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	srv.TLS = &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	cases := []struct {
+		Num      string
+		Method   string
+		Cert     *testCert
+		Response string
+		Status   int
+	}{
+		// subject matched by DN
+		{
+			Num:      "1",
+			Method:   "POST",
+			Cert:     batch,
+			Response: "cert:CN=batch,O=Acme",
+			Status:   http.StatusOK,
+		},
+		{
+			Num:      "2",
+			Method:   "GET",
+			Cert:     reader,
+			Response: "cert:CN=reader",
+			Status:   http.StatusOK,
+		},
+		// scope is not granted
+		{
+			Num:      "3",
+			Method:   "POST",
+			Cert:     reader,
+			Response: testProblem(http.StatusForbidden, "scope_not_allowed", ErrScopeNotAllowed.Error(), "/"),
+			Status:   http.StatusForbidden,
+		},
+		// unknown subject falls back to bearer token
+		{
+			Num:      "4",
+			Method:   "GET",
+			Cert:     unknown,
+			Response: testProblem(http.StatusUnauthorized, "token_invalid", ErrTokenInvalid.Error(), "/"),
+			Status:   http.StatusUnauthorized,
+		},
+		// no certificate
+		{
+			Num:      "5",
+			Method:   "GET",
+			Response: testProblem(http.StatusUnauthorized, "token_invalid", ErrTokenInvalid.Error(), "/"),
+			Status:   http.StatusUnauthorized,
+		},
+		// common name of known subject with another DN
+		{
+			Num:      "6",
+			Method:   "GET",
+			Cert:     impostor,
+			Response: testProblem(http.StatusUnauthorized, "token_invalid", ErrTokenInvalid.Error(), "/"),
+			Status:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, c := range cases {
+		client := srv.Client()
+		transport := client.Transport.(*http.Transport).Clone()
+		if c.Cert != nil {
+			transport.TLSClientConfig.Certificates = []tls.Certificate{c.Cert.tlsCertificate()}
+		}
+		client.Transport = transport
+
+		req, _ := http.NewRequest(c.Method, srv.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[ClientCertAuth:%s]:\trequest failed: %v", c.Num, err)
+			continue
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != c.Status {
+			t.Errorf("[ClientCertAuth:%s]:\twrong StatusCode: got %d, expected %d",
+				c.Num, resp.StatusCode, c.Status)
+		}
+		if string(body) != c.Response {
+			t.Errorf("[ClientCertAuth:%s]:\twrong Response: got %s, expected %s",
+				c.Num, body, c.Response)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package gontracts
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func testSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	sr := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return sr
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	sr := testSpanRecorder(t)
+
+	r := mux.NewRouter()
+	r.Use(Tracing)
+	r.HandleFunc("/company/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed", http.StatusInternalServerError)
+	}).Methods("GET")
+	r.HandleFunc("/company", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}).Methods("GET")
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	type testCase struct {
+		Num         string
+		URL         string
+		TraceParent string
+		Name        string
+		Status      codes.Code
+		Parent      bool
+	}
+
+	testList := []testCase{
+		{"1", "/company", "", "GET /company", codes.Unset, false},
+		{"2", "/company/1", "", "GET /company/{id:[0-9]+}", codes.Error, false},
+		{"3", "/company", parent, "GET /company", codes.Unset, true},
+	}
+
+	for _, tc := range testList {
+		req := httptest.NewRequest("GET", tc.URL, nil)
+		if tc.TraceParent != "" {
+			req.Header.Set("traceparent", tc.TraceParent)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := sr.Ended()
+		span := spans[len(spans)-1]
+		if span.Name() != tc.Name {
+			t.Errorf("[%s]:\twrong span name %q, expected %q", tc.Num, span.Name(), tc.Name)
+		}
+		if span.Status().Code != tc.Status {
+			t.Errorf("[%s]:\twrong span status %v, expected %v", tc.Num, span.Status().Code, tc.Status)
+		}
+		if tc.Parent {
+			if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("[%s]:\ttrace id isn't propagated: %s", tc.Num, span.SpanContext().TraceID())
+			}
+			if span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+				t.Errorf("[%s]:\twrong parent span: %s", tc.Num, span.Parent().SpanID())
+			}
+		} else if span.Parent().IsValid() {
+			t.Errorf("[%s]:\tunexpected parent span", tc.Num)
+		}
+	}
+}
+
+func TestTracingAccessLog(t *testing.T) {
+	testSpanRecorder(t)
+
+	r := mux.NewRouter()
+	r.Use(Tracing)
+	r.HandleFunc("/company", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}).Methods("GET")
+
+	var buf bytes.Buffer
+	h := RequestID(AccessLog(NewLogger(&buf, 0))(r))
+
+	req := httptest.NewRequest("GET", "/company", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("[TracingAccessLog]:\ttrace id not logged: %s", buf.String())
+	}
+}
+
+func TestSetupTracing(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+
+	_, err := SetupTracing(&TracingConfig{Exporter: "zipkin"})
+	if err != ErrTraceExporter {
+		t.Errorf("[SetupTracing]:\twrong error %v, expected %v", err, ErrTraceExporter)
+	}
+
+	var buf bytes.Buffer
+	shutdown, err := SetupTracing(&TracingConfig{Exporter: TraceExporterStdout, Writer: &buf})
+	if err != nil {
+		t.Fatalf("[SetupTracing]:\tunexpected error %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	if err = shutdown(context.Background()); err != nil {
+		t.Errorf("[SetupTracing]:\tshutdown failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"Name":"test-span"`) || !strings.Contains(buf.String(), `"Value":"gontracts"`) {
+		t.Errorf("[SetupTracing]:\tspan isn't exported: %s", buf.String())
+	}
+}
@@ -0,0 +1,288 @@
+package gontracts
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/service"
+	"gopkg.in/yaml.v3"
+)
+
+// swaggerSpec is an API specification request bodies are validated against
+//
+//go:embed swagger.yml
+var swaggerSpec []byte
+
+// definitionRef is a prefix of spec definition references
+const definitionRef = "#/definitions/"
+
+// ErrValidation request body doesn't match API specification or business rules
+var ErrValidation = service.ErrValidation
+
+// FieldError describes invalid field of request body
+type FieldError = service.FieldError
+
+// ValidationError contains all invalid fields of request body
+type ValidationError = service.ValidationError
+
+// schema is a subset of swagger schema object used by the spec.
+// Nullable fields are marked with x-nullable extension
+type schema struct {
+	Ref        string             `yaml:"$ref"`
+	Type       string             `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Required   []string           `yaml:"required"`
+	Properties map[string]*schema `yaml:"properties"`
+	Items      *schema            `yaml:"items"`
+	Enum       []string           `yaml:"enum"`
+	Minimum    *float64           `yaml:"minimum"`
+	Maximum    *float64           `yaml:"maximum"`
+	MinLength  *int               `yaml:"minLength"`
+	MaxLength  *int               `yaml:"maxLength"`
+	MinItems   *int               `yaml:"minItems"`
+	Nullable   bool               `yaml:"x-nullable"`
+}
+
+// specDefinitions returns schema definitions of the spec
+var specDefinitions = sync.OnceValues(func() (map[string]*schema, error) {
+	var spec struct {
+		Definitions map[string]*schema `yaml:"definitions"`
+	}
+	if err := yaml.Unmarshal(swaggerSpec, &spec); err != nil {
+		return nil, fmt.Errorf("swagger spec: %w", err)
+	}
+	return spec.Definitions, nil
+})
+
+// decodeBody validates request body against spec definition and business rules and decodes it into v
+func decodeBody(r *http.Request, def string, v any) error {
+	var doc any
+	dc := json.NewDecoder(r.Body)
+	dc.UseNumber()
+	if err := dc.Decode(&doc); err != nil {
+		return badRequest(err)
+	}
+	return decodeDocument(doc, def, v)
+}
+
+// validateItem validates item built outside of HTTP request, e.g. from gRPC message, against spec definition and business rules
+func validateItem(v any, def string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc any
+	dc := json.NewDecoder(bytes.NewReader(b))
+	dc.UseNumber()
+	if err := dc.Decode(&doc); err != nil {
+		return err
+	}
+	return decodeDocument(doc, def, v)
+}
+
+// decodeDocument validates generic JSON document and decodes it into v
+func decodeDocument(doc any, def string, v any) error {
+	defs, err := specDefinitions()
+	if err != nil {
+		return err
+	}
+	s, ok := defs[def]
+	if !ok {
+		return fmt.Errorf("swagger spec: unknown definition %s", def)
+	}
+
+	var fields []FieldError
+	s.validate(defs, "", doc, &fields)
+	if len(fields) > 0 {
+		return &ValidationError{fields}
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return badRequest(err)
+	}
+
+	return businessRules(v)
+}
+
+// businessRules checks decoded request against rules the spec can't express
+func businessRules(v any) error {
+	switch v := v.(type) {
+	case *model.Company:
+		return service.ValidateCompany(v)
+	case *model.Contract:
+		return service.ValidateContract(v)
+	case *model.Purchase:
+		return service.ValidatePurchase(v)
+	case *APIKeyRequest:
+		var fields []FieldError
+		if strings.TrimSpace(v.Name) == "" {
+			fields = append(fields, FieldError{"name", "must not be blank"})
+		}
+		if v.ExpiresAt != nil && v.ExpiresAt.Before(time.Now()) {
+			fields = append(fields, FieldError{"expiresAt", "must be in the future"})
+		}
+		if len(fields) > 0 {
+			return &ValidationError{fields}
+		}
+	}
+	return nil
+}
+
+// validate appends errors of value v at path to fields
+func (s *schema) validate(defs map[string]*schema, path string, v any, fields *[]FieldError) {
+	if s.Ref != "" {
+		ref, ok := defs[strings.TrimPrefix(s.Ref, definitionRef)]
+		if !ok {
+			*fields = append(*fields, FieldError{fieldName(path), "has unknown schema " + s.Ref})
+			return
+		}
+		s = ref
+	}
+
+	fail := func(format string, args ...any) {
+		*fields = append(*fields, FieldError{fieldName(path), fmt.Sprintf(format, args...)})
+	}
+
+	if v == nil {
+		if !s.Nullable {
+			fail("must not be null")
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				*fields = append(*fields, FieldError{fieldName(joinPath(path, name)), "is required"})
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				*fields = append(*fields, FieldError{fieldName(joinPath(path, name)), "is not allowed"})
+				continue
+			}
+			prop.validate(defs, joinPath(path, name), obj[name], fields)
+		}
+
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			fail("must contain at least %d items", *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				s.Items.validate(defs, path+"["+strconv.Itoa(i)+"]", item, fields)
+			}
+		}
+
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		n := utf8.RuneCountInString(str)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must not be shorter than %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must not be longer than %d characters", *s.MaxLength)
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			fail("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				fail("must be an RFC 3339 date-time")
+			}
+		}
+
+	case "integer", "number":
+		num, ok := number(v)
+		if !ok {
+			fail("must be a number")
+			return
+		}
+		if s.Type == "integer" && num != float64(int64(num)) {
+			fail("must be an integer")
+			return
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			fail("must not be less than %v", *s.Minimum)
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			fail("must not be greater than %v", *s.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("must be a boolean")
+		}
+	}
+}
+
+// number converts decoded JSON number
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// fieldName returns name of the field at path, the whole body has empty path
+func fieldName(path string) string {
+	if path == "" {
+		return "body"
+	}
+	return path
+}
@@ -0,0 +1,136 @@
+package gontracts
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ilyakaznacheev/gontracts/model"
+	"github.com/ilyakaznacheev/gontracts/test"
+)
+
+func TestSpecDefinitions(t *testing.T) {
+	defs, err := specDefinitions()
+	if err != nil {
+		t.Fatalf("[SpecDefinitions]:\tspec is not loaded: %v", err)
+	}
+	for _, name := range []string{"CompanyRequest", "ContractRequest", "Purchase", "APIKeyRequest", "Company", "Contract"} {
+		if defs[name] == nil || defs[name].Type != "object" {
+			t.Errorf("[SpecDefinitions]:\tdefinition %s is missing", name)
+		}
+	}
+}
+
+func TestValidateBody(t *testing.T) {
+	models := testModelSet{
+		company: test.TestCompany{
+			CL: []*model.Company{
+				{ID: 10, Name: "test1"},
+				{ID: 11, Name: "test2"},
+			},
+		},
+		contract: test.TestContract{},
+		purchase: test.TestPurchase{},
+	}
+	contract := `"sellerID":10,"clientID":11,"validFrom":"2000-01-01T00:00:00Z"`
+
+	cases := []struct {
+		Num      string
+		Path     string
+		Request  string
+		Response string
+		Status   int
+	}{
+		// valid company with null reg code
+		{
+			Num:      "1",
+			Path:     "/company",
+			Request:  `{"name":"test3","regcode":null}`,
+			Response: `{"ID":3}`,
+			Status:   http.StatusCreated,
+		},
+		// missing and unknown fields
+		{
+			Num:      "2",
+			Path:     "/company",
+			Request:  `{"title":"test2"}`,
+			Response: testValidationProblem("/company", FieldError{"name", "is required"}, FieldError{"title", "is not allowed"}),
+			Status:   http.StatusBadRequest,
+		},
+		// blank name
+		{
+			Num:      "3",
+			Path:     "/company",
+			Request:  `{"name":"  "}`,
+			Response: testValidationProblem("/company", FieldError{"name", "must not be blank"}),
+			Status:   http.StatusBadRequest,
+		},
+		// wrong types
+		{
+			Num:      "4",
+			Path:     "/contract",
+			Request:  `{"sellerID":"10","clientID":11.5,"validFrom":"2000-01-01","validTo":"2001-01-01T00:00:00Z","amount":10}`,
+			Response: testValidationProblem("/contract", FieldError{"clientID", "must be an integer"}, FieldError{"sellerID", "must be a number"}, FieldError{"validFrom", "must be an RFC 3339 date-time"}),
+			Status:   http.StatusBadRequest,
+		},
+		// negative amount
+		{
+			Num:      "5",
+			Path:     "/contract",
+			Request:  `{` + contract + `,"validTo":"2001-01-01T00:00:00Z","amount":-1}`,
+			Response: testValidationProblem("/contract", FieldError{"amount", "must not be less than 0"}),
+			Status:   http.StatusBadRequest,
+		},
+		// validity ends before it starts
+		{
+			Num:      "6",
+			Path:     "/contract",
+			Request:  `{` + contract + `,"validTo":"1999-01-01T00:00:00Z","amount":10}`,
+			Response: testValidationProblem("/contract", FieldError{"validTo", "must not be before validFrom"}),
+			Status:   http.StatusBadRequest,
+		},
+		// empty purchase
+		{
+			Num:      "7",
+			Path:     "/purchase",
+			Request:  `{"contractID":1,"datetime":"2000-01-01T00:00:00Z","amount":0}`,
+			Response: testValidationProblem("/purchase", FieldError{"amount", "must not be less than 1"}),
+			Status:   http.StatusBadRequest,
+		},
+		// body isn't an object
+		{
+			Num:      "8",
+			Path:     "/purchase",
+			Request:  `[]`,
+			Response: testValidationProblem("/purchase", FieldError{"body", "must be an object"}),
+			Status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		h := NewHandler(models.company, models.contract, models.purchase)
+		handler := map[string]func(http.ResponseWriter, *http.Request){
+			"/company":  h.CreateCompany,
+			"/contract": h.CreateContract,
+			"/purchase": h.Purchase,
+		}[c.Path]
+
+		req := httptest.NewRequest("POST", c.Path, bytes.NewBufferString(c.Request))
+		w := httptest.NewRecorder()
+
+		testHandle(c.Path, w, req, handler)
+		testCheckResponse("ValidateBody:"+c.Num, t, w, c.Status, c.Response)
+	}
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	a := NewAuthHandler([]byte("test"), &test.TestAPIKey{})
+
+	req := httptest.NewRequest("POST", "/admin/apikey", bytes.NewBufferString(`{"name":"batch","scopes":["read","root"],"expiresAt":"2000-01-01T00:00:00Z"}`))
+	w := httptest.NewRecorder()
+
+	testHandle("/admin/apikey", w, req, a.CreateAPIKey)
+	testCheckResponse("ValidateAPIKey", t, w, http.StatusBadRequest,
+		testValidationProblem("/admin/apikey", FieldError{"scopes[1]", "must be one of read, write, admin"}))
+}